@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// AccountsAndCategories is a user's transaction accounts and categories looked up together.
+// Each list succeeds or fails on its own: a failed list is nil with its error set, so
+// callers that can do with one of them still get it.
+type AccountsAndCategories struct {
+	Accounts      []domain.TransactionAccount
+	Categories    []domain.Category
+	AccountsErr   error
+	CategoriesErr error
+}
+
+// Err returns nil when both lists were looked up, otherwise the failures, each saying
+// which list it belongs to and wrapping the cause
+func (r AccountsAndCategories) Err() error {
+	switch {
+	case r.AccountsErr != nil && r.CategoriesErr != nil:
+		return fmt.Errorf("failed to get transaction accounts: %w; failed to get categories: %w", r.AccountsErr, r.CategoriesErr)
+	case r.AccountsErr != nil:
+		return fmt.Errorf("failed to get transaction accounts: %w", r.AccountsErr)
+	case r.CategoriesErr != nil:
+		return fmt.Errorf("failed to get categories: %w", r.CategoriesErr)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountsAndCategoriesErr(t *testing.T) {
+	accountsFailure := errors.New("accounts down")
+	categoriesFailure := errors.New("categories down")
+	tests := []struct {
+		name  string
+		lists AccountsAndCategories
+		want  string
+	}{
+		{name: "both looked up", lists: AccountsAndCategories{}},
+		{name: "accounts failed", lists: AccountsAndCategories{AccountsErr: accountsFailure}, want: "failed to get transaction accounts: accounts down"},
+		{name: "categories failed", lists: AccountsAndCategories{CategoriesErr: categoriesFailure}, want: "failed to get categories: categories down"},
+		{
+			name:  "both failed",
+			lists: AccountsAndCategories{AccountsErr: accountsFailure, CategoriesErr: categoriesFailure},
+			want:  "failed to get transaction accounts: accounts down; failed to get categories: categories down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.lists.Err()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Err() = %v, want %q", err, tt.want)
+			}
+
+			// Every cause stays reachable
+			if tt.lists.AccountsErr != nil && !errors.Is(err, accountsFailure) {
+				t.Errorf("Err() doesn't wrap %v", accountsFailure)
+			}
+			if tt.lists.CategoriesErr != nil && !errors.Is(err, categoriesFailure) {
+				t.Errorf("Err() doesn't wrap %v", categoriesFailure)
+			}
+		})
+	}
+}
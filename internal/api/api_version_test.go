@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: "v2"},
+		{value: " V3 ", want: "v3"},
+		{value: "v10", want: "v10"},
+		{value: "3", wantErr: true},
+		{value: "v2/evil", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAPIVersion(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAPIVersion(%q) = %q, %v, want %q (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAPIVersionInURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		prefix  string
+	}{
+		{name: "default", prefix: "/v2"},
+		{name: "configured", version: "v3", prefix: "/v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.prefix
+			// Accounts and categories are fetched concurrently, so the URLs are compared sorted
+			var mu sync.Mutex
+			var urls []string
+			transport := &fakeTransport{
+				responses: map[string]fakeResponse{
+					"GET " + p + "/me": {status: http.StatusOK, body: `{"id": 42}`},
+					"GET " + p + "/users/42/transaction_accounts":        {status: http.StatusOK, body: `[{"id": 5}]`},
+					"GET " + p + "/users/42/categories":                  {status: http.StatusOK, body: `[]`},
+					"POST " + p + "/transaction_accounts/5/transactions": {status: http.StatusCreated, body: `{"id": 101}`},
+					"GET " + p + "/transaction_accounts/5/transactions":  {status: http.StatusOK, body: `[]`},
+				},
+				before: func(req *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					urls = append(urls, req.Method+" "+req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+				},
+			}
+			c := newTestClient(newMemoryCache(), transport, Options{APIVersion: tt.version})
+			ctx := context.Background()
+
+			if _, err := c.RefreshUser(ctx); err != nil {
+				t.Fatalf("RefreshUser() error = %v", err)
+			}
+			if _, err := c.GetTransactionAccounts(ctx, 42); err != nil {
+				t.Fatalf("GetTransactionAccounts() error = %v", err)
+			}
+			if _, err := c.CreateTransaction(ctx, 5, &domain.PocketSmithTransaction{Payee: "Corner Store", Amount: "-5.00", Date: "2025-01-13"}); err != nil {
+				t.Fatalf("CreateTransaction() error = %v", err)
+			}
+			if err := c.ListTransactions(ctx, 5, "2025-01-01", "2025-01-31", func([]domain.TransactionRecord) error { return nil }); err != nil {
+				t.Fatalf("ListTransactions() error = %v", err)
+			}
+
+			host := "https://api.pocketsmith.com" + p
+			want := []string{
+				"GET " + host + "/me",
+				"GET " + host + "/transaction_accounts/5/transactions",
+				"GET " + host + "/users/42/categories",
+				"GET " + host + "/users/42/transaction_accounts",
+				"POST " + host + "/transaction_accounts/5/transactions",
+			}
+			sort.Strings(urls)
+			if !reflect.DeepEqual(urls, want) {
+				t.Errorf("requested %q, want %q", urls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AuthMode selects how the PocketSmith credential is sent
+type AuthMode string
+
+const (
+	// AuthDeveloperKey sends "X-Developer-Key: <key>" (default)
+	AuthDeveloperKey AuthMode = "developer_key"
+	// AuthOAuth sends "Authorization: Bearer <token>" for OAuth access tokens
+	AuthOAuth AuthMode = "oauth"
+)
+
+// ParseAuthMode parses a PocketSmith auth mode, defaulting to developer_key when empty
+func ParseAuthMode(value string) (AuthMode, error) {
+	switch mode := AuthMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return AuthDeveloperKey, nil
+	case AuthDeveloperKey, AuthOAuth:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown PocketSmith auth mode: %s", value)
+	}
+}
+
+// authorize sets the PocketSmith credential on a request, per the auth mode
+func (c *HTTPPocketSmithClient) authorize(req *http.Request) {
+	if c.options.AuthMode == AuthOAuth {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return
+	}
+	req.Header.Set("X-Developer-Key", c.apiKey)
+}
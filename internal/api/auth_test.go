@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestParseAuthMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    AuthMode
+		wantErr bool
+	}{
+		{value: "", want: AuthDeveloperKey},
+		{value: "developer_key", want: AuthDeveloperKey},
+		{value: " OAuth ", want: AuthOAuth},
+		{value: "basic", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAuthMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAuthMode(%q) = %q, %v, want %q (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAuthorizeHeaderPerMode(t *testing.T) {
+	tests := []struct {
+		name              string
+		mode              AuthMode
+		wantDeveloperKey  string
+		wantAuthorization string
+	}{
+		{name: "default", wantDeveloperKey: "test-key"},
+		{name: "developer key", mode: AuthDeveloperKey, wantDeveloperKey: "test-key"},
+		{name: "OAuth", mode: AuthOAuth, wantAuthorization: "Bearer test-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			transport := &fakeTransport{
+				responses: map[string]fakeResponse{"GET /v2/me": {status: http.StatusOK, body: `{"id": 42}`}},
+				before:    func(req *http.Request) { header = req.Header.Clone() },
+			}
+			c := newTestClient(newMemoryCache(), transport, Options{AuthMode: tt.mode})
+
+			if _, err := c.RefreshUser(context.Background()); err != nil {
+				t.Fatalf("RefreshUser() error = %v", err)
+			}
+			if got := header.Get("X-Developer-Key"); got != tt.wantDeveloperKey {
+				t.Errorf("X-Developer-Key = %q, want %q", got, tt.wantDeveloperKey)
+			}
+			if got := header.Get("Authorization"); got != tt.wantAuthorization {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuthorization)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// Cache status values reported for a request's cache lookups
+const (
+	CacheStatusHit     = "HIT"
+	CacheStatusMiss    = "MISS"
+	CacheStatusPartial = "PARTIAL"
+)
+
+// cacheStatusKey is the context key under which cache lookups are tallied
+type cacheStatusKey struct{}
+
+// cacheStatus tallies the cache hits and misses of a single request
+type cacheStatus struct {
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// WithCacheStatus returns a context that tallies the client's cache hits and misses
+func WithCacheStatus(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheStatusKey{}, &cacheStatus{})
+}
+
+// CacheStatus summarizes the cache lookups made with ctx: HIT when every entity came
+// from cache, MISS when every entity was fetched, PARTIAL when mixed, and empty when
+// nothing was looked up or ctx doesn't tally lookups
+func CacheStatus(ctx context.Context) string {
+	status, _ := ctx.Value(cacheStatusKey{}).(*cacheStatus)
+	if status == nil {
+		return ""
+	}
+
+	status.mu.Lock()
+	defer status.mu.Unlock()
+
+	switch {
+	case status.hits > 0 && status.misses > 0:
+		return CacheStatusPartial
+	case status.hits > 0:
+		return CacheStatusHit
+	case status.misses > 0:
+		return CacheStatusMiss
+	default:
+		return ""
+	}
+}
+
+// recordCacheLookup tallies a cache hit or miss in ctx, if it tallies lookups
+func recordCacheLookup(ctx context.Context, hit bool) {
+	status, _ := ctx.Value(cacheStatusKey{}).(*cacheStatus)
+	if status == nil {
+		return
+	}
+
+	status.mu.Lock()
+	defer status.mu.Unlock()
+
+	if hit {
+		status.hits++
+	} else {
+		status.misses++
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestCacheStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		userCached     bool
+		entitiesCached bool
+		lookUp         bool
+		want           string
+	}{
+		{name: "everything cached", userCached: true, entitiesCached: true, lookUp: true, want: CacheStatusHit},
+		{name: "nothing cached", lookUp: true, want: CacheStatusMiss},
+		{name: "only the user cached", userCached: true, lookUp: true, want: CacheStatusPartial},
+		{name: "only the entities cached", entitiesCached: true, lookUp: true, want: CacheStatusPartial},
+		{name: "nothing looked up"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMemoryCache()
+			if tt.userCached {
+				cache.user = &domain.User{ID: 42}
+			}
+			if tt.entitiesCached {
+				cache.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, []domain.Category{{ID: 7}})
+			}
+			transport := &fakeTransport{responses: map[string]fakeResponse{
+				"GET /v2/me":    {status: http.StatusOK, body: `{"id": 42}`},
+				accountsRoute:   {status: http.StatusOK, body: `[{"id": 1}]`},
+				categoriesRoute: {status: http.StatusOK, body: `[{"id": 7}]`},
+			}}
+			c := newTestClient(cache, transport, Options{})
+			ctx := WithCacheStatus(context.Background())
+
+			if tt.lookUp {
+				user, err := c.GetMe(ctx)
+				if err != nil {
+					t.Fatalf("GetMe() error = %v", err)
+				}
+				if err := c.GetAccountsAndCategories(ctx, user.ID).Err(); err != nil {
+					t.Fatalf("GetAccountsAndCategories() error = %v", err)
+				}
+			}
+			if got := CacheStatus(ctx); got != tt.want {
+				t.Errorf("CacheStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without a tally there is nothing to report, and lookups don't fail for it
+	c := newTestClient(newMemoryCache(), &fakeTransport{}, Options{})
+	c.GetMe(context.Background())
+	if got := CacheStatus(context.Background()); got != "" {
+		t.Errorf("CacheStatus() without a tally = %q, want empty", got)
+	}
+}
@@ -2,13 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/pocketsmith-proxy/internal/clock"
 	"github.com/pocketsmith-proxy/internal/domain"
 	"github.com/pocketsmith-proxy/internal/repository"
 	spinhttp "github.com/spinframework/spin-go-sdk/v2/http"
@@ -17,13 +27,107 @@ import (
 // PocketSmithClient defines the interface for interacting with PocketSmith API
 type PocketSmithClient interface {
 	// GetMe gets the authenticated user's information
-	GetMe() (*domain.User, error)
-	// GetTransactionAccounts gets all transaction accounts for a user
-	GetTransactionAccounts(userID int) ([]domain.TransactionAccount, error)
-	// GetCategories gets all categories for a user
-	GetCategories(userID int) ([]domain.Category, error)
-	// CreateTransaction creates a new transaction in the specified account
-	CreateTransaction(accountID int, transaction *domain.PocketSmithTransaction) error
+	GetMe(ctx context.Context) (*domain.User, error)
+	// RefreshUser fetches the authenticated user's full profile from the API and overwrites the cached one
+	RefreshUser(ctx context.Context) (*domain.User, error)
+	// GetTransactionAccounts gets all transaction accounts for a user. They're cached with the
+	// categories, so a cache miss refetches both.
+	GetTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error)
+	// CreateAccount creates a transaction account, and an institution for it when none is given,
+	// then drops the cached accounts. It returns the new transaction account's ID.
+	CreateAccount(ctx context.Context, userID int, params *domain.AccountParams) (int, error)
+	// GetAccountsAndCategories gets a user's transaction accounts and categories together.
+	// Both are cached in one entry, so a cache miss fetches both concurrently, and they're
+	// only cached when both fetches succeeded, so one is never refreshed without the other.
+	// Each list carries its own error, so callers can decide how to combine them.
+	GetAccountsAndCategories(ctx context.Context, userID int) AccountsAndCategories
+	// GetCategories gets all categories for a user. They're cached with the transaction
+	// accounts, so a cache miss refetches both.
+	GetCategories(ctx context.Context, userID int) ([]domain.Category, error)
+	// CreateCategory creates a category, under parentID when not nil, and adds it to the
+	// cached categories without refetching them
+	CreateCategory(ctx context.Context, userID int, title string, parentID *int) (*domain.Category, error)
+	// PostCategory creates a category like CreateCategory but leaves the cache alone, for callers
+	// creating several that drop the cached categories once afterwards with InvalidateCategories
+	PostCategory(ctx context.Context, userID int, title string, parentID *int) (*domain.Category, error)
+	// InvalidateCategories drops a user's cached categories, along with the accounts cached
+	// with them, so the next lookup refetches both
+	InvalidateCategories(ctx context.Context, userID int) error
+	// UpdateCachedAccountBalance sets one account's balance in the cached accounts without
+	// refetching them, dropping the cached accounts and categories when that isn't possible
+	UpdateCachedAccountBalance(ctx context.Context, userID, accountID int, balance float64)
+	// RefreshTransactionAccounts fetches transaction accounts from the API, along with the
+	// categories cached with them, and overwrites the cache when both fetches succeeded
+	RefreshTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error)
+	// RefreshCategories fetches categories from the API, along with the transaction accounts
+	// cached with them, and overwrites the cache when both fetches succeeded
+	RefreshCategories(ctx context.Context, userID int) ([]domain.Category, error)
+	// RefreshAccountsAndCategories fetches a user's transaction accounts and categories from
+	// the API concurrently, and overwrites both cached lists only when both fetches succeeded
+	RefreshAccountsAndCategories(ctx context.Context, userID int) ([]domain.TransactionAccount, []domain.Category, error)
+	// AcquireCooldown starts a named per-user cooldown, reporting false when one is already
+	// running. Cooldowns are kept in the cache so they hold across requests.
+	AcquireCooldown(ctx context.Context, userID int, name string, period time.Duration) (bool, error)
+	// ReleaseCooldown ends a named per-user cooldown early
+	ReleaseCooldown(ctx context.Context, userID int, name string) error
+	// GetJob gets a user's batch job, kept in the cache (nil when it doesn't exist)
+	GetJob(ctx context.Context, userID int, jobID string) (*domain.Job, error)
+	// SaveJob stores a user's batch job in the cache
+	SaveJob(ctx context.Context, userID int, job *domain.Job) error
+	// GetCacheTTL returns how long a user's cached entity remains valid
+	GetCacheTTL(ctx context.Context, userID int, entity domain.CacheEntity) (time.Duration, error)
+	// FlushCache deletes every cached entry of every user and returns how many keys were removed
+	FlushCache(ctx context.Context) (int, error)
+	// GetCacheStats returns the cumulative cache hits and misses per entity
+	GetCacheStats(ctx context.Context) (domain.CacheStats, error)
+	// RateLimit returns the rate-limit values from the latest PocketSmith response
+	RateLimit() domain.RateLimit
+	// CreateTransaction creates a new transaction in the specified account and returns its ID
+	// (0 when PocketSmith's response doesn't say)
+	CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error)
+	// UpdateTransaction overwrites an existing transaction
+	UpdateTransaction(ctx context.Context, transactionID int, transaction *domain.PocketSmithTransaction) error
+	// ListTransactions fetches an account's transactions between two dates (inclusive)
+	// page by page, passing each page to visit as soon as it arrives
+	ListTransactions(ctx context.Context, accountID int, startDate, endDate string, visit func([]domain.TransactionRecord) error) error
+}
+
+// pocketSmithHost is the PocketSmith API origin; it must match allowed_outbound_hosts in spin.toml
+const pocketSmithHost = "https://api.pocketsmith.com"
+
+// DefaultAPIVersion is the PocketSmith API version used when none is configured
+const DefaultAPIVersion = "v2"
+
+// apiVersionPattern matches PocketSmith API versions such as v2
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// ParseAPIVersion parses a PocketSmith API version such as v2, defaulting to DefaultAPIVersion when empty
+func ParseAPIVersion(value string) (string, error) {
+	version := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case version == "":
+		return DefaultAPIVersion, nil
+	case apiVersionPattern.MatchString(version):
+		return version, nil
+	default:
+		return "", fmt.Errorf("invalid PocketSmith API version %q, expected e.g. %s", value, DefaultAPIVersion)
+	}
+}
+
+// rateLimitWarningThreshold is the remaining request count below which a warning is logged
+const rateLimitWarningThreshold = 10
+
+// Options holds optional client behaviors
+type Options struct {
+	// MaxEntities caps how many accounts or top-level categories are cached and returned (0 disables)
+	MaxEntities int
+	// AuthMode selects how the API key is sent (developer key header or OAuth bearer token)
+	AuthMode AuthMode
+	// APIVersion is the PocketSmith API version every endpoint is requested under
+	// (defaults to DefaultAPIVersion)
+	APIVersion string
+	// Clock tells the time (defaults to the wall clock)
+	Clock clock.Clock
 }
 
 // HTTPPocketSmithClient implements PocketSmithClient using HTTP
@@ -31,42 +135,60 @@ type HTTPPocketSmithClient struct {
 	apiKey  string
 	baseURL string
 	cache   repository.CacheRepository
+	options Options
+	// transport sends each request to PocketSmith (spinhttp.Send outside tests)
+	transport func(*http.Request) (*http.Response, error)
+
+	rateLimitMu sync.Mutex
+	rateLimit   domain.RateLimit
 }
 
 // NewHTTPPocketSmithClient creates a new HTTP-based PocketSmith client
-func NewHTTPPocketSmithClient(apiKey string, cache repository.CacheRepository) PocketSmithClient {
+func NewHTTPPocketSmithClient(apiKey string, cache repository.CacheRepository, options Options) PocketSmithClient {
+	version := options.APIVersion
+	if version == "" {
+		version = DefaultAPIVersion
+	}
+	options.Clock = clock.OrReal(options.Clock)
 	return &HTTPPocketSmithClient{
-		apiKey:  apiKey,
-		baseURL: "https://api.pocketsmith.com/v2",
-		cache:   cache,
+		apiKey:    apiKey,
+		baseURL:   pocketSmithHost + "/" + version,
+		cache:     cache,
+		options:   options,
+		transport: spinhttp.Send,
 	}
 }
 
 // GetMe implements PocketSmithClient.GetMe
-func (c *HTTPPocketSmithClient) GetMe() (*domain.User, error) {
+func (c *HTTPPocketSmithClient) GetMe(ctx context.Context) (*domain.User, error) {
 	// Try to get from cache first
-	userID, err := c.cache.GetUserID()
+	user, err := c.cache.GetUser()
+	recordCacheLookup(ctx, err == nil)
 	if err == nil {
 		// Cache hit
-		return &domain.User{ID: userID}, nil
+		return user, nil
 	}
 
 	// Cache miss - fetch from API
-	log.Printf("Cache miss for user ID, fetching from PocketSmith API")
+	log.Printf("Cache miss for user, fetching from PocketSmith API")
+	return c.RefreshUser(ctx)
+}
 
+// RefreshUser implements PocketSmithClient.RefreshUser
+func (c *HTTPPocketSmithClient) RefreshUser(ctx context.Context) (*domain.User, error) {
 	// Create HTTP request
 	url := fmt.Sprintf("%s/me", c.baseURL)
-	httpReq, err := http.NewRequest("GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Set headers
 	httpReq.Header.Set("accept", "application/json")
-	httpReq.Header.Set("X-Developer-Key", c.apiKey)
+	c.authorize(httpReq)
 
 	// Send request to PocketSmith API
-	resp, err := spinhttp.Send(httpReq)
+	resp, err := c.send(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request to PocketSmith: %w", err)
 	}
@@ -81,7 +203,7 @@ func (c *HTTPPocketSmithClient) GetMe() (*domain.User, error) {
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("ERROR: Failed to fetch user from PocketSmith API (status %d): %s", resp.StatusCode, string(responseBody))
-		return nil, fmt.Errorf("PocketSmith request failed with status %d: %s", resp.StatusCode, string(responseBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	// Unmarshal response
@@ -91,38 +213,40 @@ func (c *HTTPPocketSmithClient) GetMe() (*domain.User, error) {
 	}
 
 	// Store in cache
-	if err := c.cache.SetUserID(user.ID); err != nil {
-		log.Printf("Warning: Failed to cache user ID: %v", err)
+	if err := c.cache.SetUser(&user); err != nil {
+		log.Printf("Warning: Failed to cache user: %v", err)
 	}
 
 	return &user, nil
 }
 
 // GetTransactionAccounts implements PocketSmithClient.GetTransactionAccounts
-func (c *HTTPPocketSmithClient) GetTransactionAccounts(userID int) ([]domain.TransactionAccount, error) {
-	// Try to get from cache first
-	accounts, err := c.cache.GetTransactionAccounts(userID)
-	if err == nil {
-		// Cache hit
-		return accounts, nil
-	}
+func (c *HTTPPocketSmithClient) GetTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	lists := c.GetAccountsAndCategories(ctx, userID)
+	return lists.Accounts, lists.AccountsErr
+}
 
-	// Cache miss - fetch from API
-	log.Printf("Cache miss for transaction accounts (user %d), fetching from PocketSmith API", userID)
+// RefreshTransactionAccounts implements PocketSmithClient.RefreshTransactionAccounts
+func (c *HTTPPocketSmithClient) RefreshTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	lists := c.fetchAndCacheAccountsAndCategories(ctx, userID)
+	return lists.Accounts, lists.AccountsErr
+}
 
+// fetchTransactionAccounts fetches a user's transaction accounts from the API, leaving the cache alone
+func (c *HTTPPocketSmithClient) fetchTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
 	// Create HTTP request
 	url := fmt.Sprintf("%s/users/%d/transaction_accounts", c.baseURL, userID)
-	httpReq, err := http.NewRequest("GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Set headers
 	httpReq.Header.Set("accept", "application/json")
-	httpReq.Header.Set("X-Developer-Key", c.apiKey)
+	c.authorize(httpReq)
 
 	// Send request to PocketSmith API
-	resp, err := spinhttp.Send(httpReq)
+	resp, err := c.send(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request to PocketSmith: %w", err)
 	}
@@ -137,7 +261,7 @@ func (c *HTTPPocketSmithClient) GetTransactionAccounts(userID int) ([]domain.Tra
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("ERROR: Failed to fetch transaction accounts for user %d from PocketSmith API (status %d): %s", userID, resp.StatusCode, string(responseBody))
-		return nil, fmt.Errorf("PocketSmith request failed with status %d: %s", resp.StatusCode, string(responseBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	// Unmarshal response
@@ -147,7 +271,7 @@ func (c *HTTPPocketSmithClient) GetTransactionAccounts(userID int) ([]domain.Tra
 	}
 
 	// Filter out net worth accounts
-	accounts = make([]domain.TransactionAccount, 0, len(allAccounts))
+	accounts := make([]domain.TransactionAccount, 0, len(allAccounts))
 	for _, account := range allAccounts {
 		if !account.IsNetWorth {
 			accounts = append(accounts, account)
@@ -159,39 +283,199 @@ func (c *HTTPPocketSmithClient) GetTransactionAccounts(userID int) ([]domain.Tra
 		return accounts[i].Name < accounts[j].Name
 	})
 
-	// Store in cache (only non-net-worth accounts, sorted)
-	if err := c.cache.SetTransactionAccounts(userID, accounts); err != nil {
-		log.Printf("Warning: Failed to cache transaction accounts: %v", err)
+	// Guard against unbounded lists
+	return capEntities(ctx, accounts, c.options.MaxEntities, "transaction accounts"), nil
+}
+
+// CreateAccount implements PocketSmithClient.CreateAccount
+func (c *HTTPPocketSmithClient) CreateAccount(ctx context.Context, userID int, params *domain.AccountParams) (int, error) {
+	currencyCode := strings.ToLower(params.Currency)
+
+	// Every account belongs to an institution
+	institutionID := params.InstitutionID
+	if institutionID == nil {
+		var institution struct {
+			ID int `json:"id"`
+		}
+		url := fmt.Sprintf("%s/users/%d/institutions", c.baseURL, userID)
+		body := map[string]any{"title": params.Name, "currency_code": currencyCode}
+		if err := c.postJSON(ctx, url, body, &institution); err != nil {
+			return 0, fmt.Errorf("create institution: %w", err)
+		}
+		institutionID = &institution.ID
+	}
+
+	// The account comes back with the transaction account that transactions are posted to
+	var account struct {
+		ID                  int                         `json:"id"`
+		TransactionAccounts []domain.TransactionAccount `json:"transaction_accounts"`
+	}
+	url := fmt.Sprintf("%s/users/%d/accounts", c.baseURL, userID)
+	body := map[string]any{
+		"institution_id": *institutionID,
+		"title":          params.Name,
+		"currency_code":  currencyCode,
+		"type":           params.Type,
+	}
+	if err := c.postJSON(ctx, url, body, &account); err != nil {
+		return 0, fmt.Errorf("create account: %w", err)
+	}
+	if len(account.TransactionAccounts) == 0 {
+		return 0, fmt.Errorf("create account: account %d has no transaction account", account.ID)
 	}
 
-	return accounts, nil
+	// Make the next lookup see the new account
+	if err := c.cache.DeleteAccountsAndCategories(userID); err != nil {
+		log.Printf("Warning: Failed to invalidate cached accounts and categories: %v", err)
+	}
+
+	log.Printf("Created account %d with transaction account %d for user %d: '%s'", account.ID, account.TransactionAccounts[0].ID, userID, params.Name)
+	return account.TransactionAccounts[0].ID, nil
 }
 
-// GetCategories implements PocketSmithClient.GetCategories
-func (c *HTTPPocketSmithClient) GetCategories(userID int) ([]domain.Category, error) {
-	// Try to get from cache first
-	categories, err := c.cache.GetCategories(userID)
+// UpdateCachedAccountBalance implements PocketSmithClient.UpdateCachedAccountBalance
+func (c *HTTPPocketSmithClient) UpdateCachedAccountBalance(ctx context.Context, userID, accountID int, balance float64) {
+	// Patch the cached accounts rather than refetching them; if that isn't possible,
+	// drop them, and the categories cached with them, so the next lookup refetches
+	if err := c.cache.UpdateCachedAccountBalance(userID, accountID, balance); err != nil {
+		log.Printf("Warning: Failed to update balance of account %d in cache, invalidating it: %v", accountID, err)
+		if err := c.cache.DeleteAccountsAndCategories(userID); err != nil {
+			log.Printf("Warning: Failed to invalidate cached accounts and categories: %v", err)
+		}
+	}
+}
+
+// postJSON posts a JSON body to the PocketSmith API and decodes the JSON response into out
+func (c *HTTPPocketSmithClient) postJSON(ctx context.Context, url string, body any, out any) error {
+	// Marshal request body
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("accept", "application/json")
+	httpReq.Header.Set("content-type", "application/json")
+	c.authorize(httpReq)
+
+	// Send request to PocketSmith API
+	resp, err := c.send(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request to PocketSmith: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response from PocketSmith: %w", err)
+	}
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
+	}
+
+	// Unmarshal response
+	if err := json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// GetAccountsAndCategories implements PocketSmithClient.GetAccountsAndCategories
+func (c *HTTPPocketSmithClient) GetAccountsAndCategories(ctx context.Context, userID int) AccountsAndCategories {
+	// Try to get both from cache first, where they hit or miss together
+	accounts, categories, err := c.cache.GetAccountsAndCategories(userID)
+	recordCacheLookup(ctx, err == nil)
 	if err == nil {
 		// Cache hit
-		return categories, nil
+		checkCapped(ctx, len(accounts), c.options.MaxEntities)
+		checkCapped(ctx, len(categories), c.options.MaxEntities)
+		return AccountsAndCategories{Accounts: accounts, Categories: categories}
 	}
 
-	// Cache miss - fetch from API
-	log.Printf("Cache miss for categories (user %d), fetching from PocketSmith API", userID)
+	// Cache miss - fetch both from API concurrently
+	log.Printf("Cache miss for accounts and categories (user %d), fetching from PocketSmith API", userID)
+	return c.fetchAndCacheAccountsAndCategories(ctx, userID)
+}
+
+// RefreshAccountsAndCategories implements PocketSmithClient.RefreshAccountsAndCategories
+func (c *HTTPPocketSmithClient) RefreshAccountsAndCategories(ctx context.Context, userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	lists := c.fetchAndCacheAccountsAndCategories(ctx, userID)
+	var accountsErr, categoriesErr error
+	if lists.AccountsErr != nil {
+		accountsErr = fmt.Errorf("failed to refresh transaction accounts: %w", lists.AccountsErr)
+	}
+	if lists.CategoriesErr != nil {
+		categoriesErr = fmt.Errorf("failed to refresh categories: %w", lists.CategoriesErr)
+	}
+	if err := errors.Join(accountsErr, categoriesErr); err != nil {
+		return nil, nil, err
+	}
+	return lists.Accounts, lists.Categories, nil
+}
+
+// fetchAndCacheAccountsAndCategories fetches both lists from the API concurrently, then
+// caches them together, and only when both fetches succeeded
+func (c *HTTPPocketSmithClient) fetchAndCacheAccountsAndCategories(ctx context.Context, userID int) AccountsAndCategories {
+	// Each goroutine only writes its own fields
+	var lists AccountsAndCategories
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		lists.Accounts, lists.AccountsErr = c.fetchTransactionAccounts(ctx, userID)
+	}()
+	go func() {
+		defer wg.Done()
+		lists.Categories, lists.CategoriesErr = c.fetchCategories(ctx, userID)
+	}()
+	wg.Wait()
+
+	if lists.AccountsErr != nil || lists.CategoriesErr != nil {
+		log.Printf("Warning: Not caching accounts or categories of user %d, since a fetch failed", userID)
+		return lists
+	}
+	if err := c.cache.SetAccountsAndCategories(userID, lists.Accounts, lists.Categories); err != nil {
+		log.Printf("Warning: Failed to cache accounts and categories: %v", err)
+	}
+	return lists
+}
+
+// GetCategories implements PocketSmithClient.GetCategories
+func (c *HTTPPocketSmithClient) GetCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	lists := c.GetAccountsAndCategories(ctx, userID)
+	return lists.Categories, lists.CategoriesErr
+}
+
+// RefreshCategories implements PocketSmithClient.RefreshCategories
+func (c *HTTPPocketSmithClient) RefreshCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	lists := c.fetchAndCacheAccountsAndCategories(ctx, userID)
+	return lists.Categories, lists.CategoriesErr
+}
 
+// fetchCategories fetches a user's categories from the API, leaving the cache alone
+func (c *HTTPPocketSmithClient) fetchCategories(ctx context.Context, userID int) ([]domain.Category, error) {
 	// Create HTTP request
 	url := fmt.Sprintf("%s/users/%d/categories", c.baseURL, userID)
-	httpReq, err := http.NewRequest("GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Set headers
 	httpReq.Header.Set("accept", "application/json")
-	httpReq.Header.Set("X-Developer-Key", c.apiKey)
+	c.authorize(httpReq)
 
 	// Send request to PocketSmith API
-	resp, err := spinhttp.Send(httpReq)
+	resp, err := c.send(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request to PocketSmith: %w", err)
 	}
@@ -206,10 +490,11 @@ func (c *HTTPPocketSmithClient) GetCategories(userID int) ([]domain.Category, er
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("ERROR: Failed to fetch categories for user %d from PocketSmith API (status %d): %s", userID, resp.StatusCode, string(responseBody))
-		return nil, fmt.Errorf("PocketSmith request failed with status %d: %s", resp.StatusCode, string(responseBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	// Unmarshal response
+	var categories []domain.Category
 	if err := json.Unmarshal(responseBody, &categories); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
@@ -219,25 +504,287 @@ func (c *HTTPPocketSmithClient) GetCategories(userID int) ([]domain.Category, er
 		return categories[i].Title < categories[j].Title
 	})
 
-	// Store in cache (sorted)
-	if err := c.cache.SetCategories(userID, categories); err != nil {
-		log.Printf("Warning: Failed to cache categories: %v", err)
+	// Guard against unbounded lists
+	return capEntities(ctx, categories, c.options.MaxEntities, "categories"), nil
+}
+
+// CreateCategory implements PocketSmithClient.CreateCategory
+func (c *HTTPPocketSmithClient) CreateCategory(ctx context.Context, userID int, title string, parentID *int) (*domain.Category, error) {
+	category, err := c.PostCategory(ctx, userID, title, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Patch the cached tree rather than refetching it; if that isn't possible,
+	// drop it so the next lookup refetches
+	if err := c.cache.AddCachedCategory(userID, *category); err != nil {
+		log.Printf("Warning: Failed to add category %d to cache, invalidating it: %v", category.ID, err)
+		if err := c.cache.DeleteAccountsAndCategories(userID); err != nil {
+			log.Printf("Warning: Failed to invalidate cached accounts and categories: %v", err)
+		}
+	}
+
+	return category, nil
+}
+
+// PostCategory implements PocketSmithClient.PostCategory
+func (c *HTTPPocketSmithClient) PostCategory(ctx context.Context, userID int, title string, parentID *int) (*domain.Category, error) {
+	var category domain.Category
+	url := fmt.Sprintf("%s/users/%d/categories", c.baseURL, userID)
+	body := map[string]any{"title": title}
+	if parentID != nil {
+		body["parent_id"] = *parentID
+	}
+	if err := c.postJSON(ctx, url, body, &category); err != nil {
+		return nil, fmt.Errorf("create category: %w", err)
+	}
+	log.Printf("Created category %d for user %d: '%s'", category.ID, userID, category.Title)
+	return &category, nil
+}
+
+// InvalidateCategories implements PocketSmithClient.InvalidateCategories
+func (c *HTTPPocketSmithClient) InvalidateCategories(ctx context.Context, userID int) error {
+	return c.cache.DeleteAccountsAndCategories(userID)
+}
+
+// AcquireCooldown implements PocketSmithClient.AcquireCooldown
+func (c *HTTPPocketSmithClient) AcquireCooldown(ctx context.Context, userID int, name string, period time.Duration) (bool, error) {
+	return c.cache.AcquireCooldown(userID, name, int(period/time.Second))
+}
+
+// ReleaseCooldown implements PocketSmithClient.ReleaseCooldown
+func (c *HTTPPocketSmithClient) ReleaseCooldown(ctx context.Context, userID int, name string) error {
+	return c.cache.ReleaseCooldown(userID, name)
+}
+
+// GetJob implements PocketSmithClient.GetJob
+func (c *HTTPPocketSmithClient) GetJob(ctx context.Context, userID int, jobID string) (*domain.Job, error) {
+	job, err := c.cache.GetJob(userID, jobID)
+	if err != nil && repository.IsCacheMiss(err) {
+		return nil, nil
 	}
+	return job, err
+}
+
+// SaveJob implements PocketSmithClient.SaveJob
+func (c *HTTPPocketSmithClient) SaveJob(ctx context.Context, userID int, job *domain.Job) error {
+	return c.cache.SetJob(userID, job)
+}
+
+// GetCacheTTL implements PocketSmithClient.GetCacheTTL
+func (c *HTTPPocketSmithClient) GetCacheTTL(ctx context.Context, userID int, entity domain.CacheEntity) (time.Duration, error) {
+	ttl, err := c.cache.GetTTL(userID, entity)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ttl) * time.Second, nil
+}
+
+// FlushCache implements PocketSmithClient.FlushCache
+func (c *HTTPPocketSmithClient) FlushCache(ctx context.Context) (int, error) {
+	return c.cache.Flush()
+}
 
-	return categories, nil
+// GetCacheStats implements PocketSmithClient.GetCacheStats
+func (c *HTTPPocketSmithClient) GetCacheStats(ctx context.Context) (domain.CacheStats, error) {
+	return c.cache.GetCacheStats()
 }
 
 // CreateTransaction implements PocketSmithClient.CreateTransaction
-func (c *HTTPPocketSmithClient) CreateTransaction(accountID int, transaction *domain.PocketSmithTransaction) error {
+func (c *HTTPPocketSmithClient) CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
+	id, err := c.postTransaction(ctx, accountID, transaction)
+
+	var ambiguous *ambiguousCreateError
+	if !errors.As(err, &ambiguous) {
+		return id, err
+	}
+
+	// Once the request is cancelled or timed out there is no way to find out, so say so
+	// rather than report a plain failure
+	if ctx.Err() != nil {
+		log.Printf("WARNING: Transaction create in account %d was abandoned in flight (%v) and may or may not have been posted, check PocketSmith before resending (payee: '%s', amount: %s, date: %s)", accountID, err, transaction.Payee, transaction.Amount, transaction.Date)
+		return 0, err
+	}
+
+	// PocketSmith may have received the create even though we lost the response,
+	// so only re-post once we know the transaction isn't there
+	log.Printf("WARNING: Outcome of transaction create in account %d is unknown (%v), checking for an existing transaction before retrying", accountID, err)
+	existingID, checkErr := c.findExistingTransaction(ctx, accountID, transaction)
+	if checkErr != nil {
+		return 0, fmt.Errorf("%w (existence check failed: %v)", err, checkErr)
+	}
+	if existingID != nil {
+		log.Printf("Transaction %d already exists in account %d, not re-posting (payee: '%s', amount: %s, date: %s)", *existingID, accountID, transaction.Payee, transaction.Amount, transaction.Date)
+		return *existingID, nil
+	}
+
+	log.Printf("No matching transaction found in account %d, retrying create", accountID)
+	return c.postTransaction(ctx, accountID, transaction)
+}
+
+// ambiguousCreateError means a create request may or may not have reached PocketSmith
+type ambiguousCreateError struct {
+	err error
+}
+
+func (e *ambiguousCreateError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ambiguousCreateError) Unwrap() error {
+	return e.err
+}
+
+// postTransaction sends a single create request to PocketSmith and returns the created ID
+func (c *HTTPPocketSmithClient) postTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
 	// Marshal request body
 	requestBody, err := json.Marshal(transaction)
 	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
+		return 0, fmt.Errorf("marshal request: %w", err)
 	}
 
 	// Create HTTP request
 	url := fmt.Sprintf("%s/transaction_accounts/%d/transactions", c.baseURL, accountID)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("accept", "application/json")
+	httpReq.Header.Set("content-type", "application/json")
+	c.authorize(httpReq)
+
+	// Refuse to start once the request is cancelled or timed out, so that error is never ambiguous
+	if err := ctx.Err(); err != nil {
+		log.Printf("Transaction create in account %d abandoned before sending (%v), nothing was posted", accountID, err)
+		return 0, fmt.Errorf("send request to PocketSmith: %w", err)
+	}
+
+	// Send request to PocketSmith API. A rate limit, or an error page other than a gateway
+	// failure, means nothing was posted; anything else may have been.
+	resp, err := c.send(httpReq)
+	if (IsRateLimited(err) || IsUnavailable(err)) && !isGatewayFailure(err) {
+		return 0, fmt.Errorf("send request to PocketSmith: %w", err)
+	}
+	if err != nil {
+		return 0, &ambiguousCreateError{err: fmt.Errorf("send request to PocketSmith: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &ambiguousCreateError{err: fmt.Errorf("read response from PocketSmith: %w", err)}
+	}
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
+		if isGatewayFailure(statusErr) {
+			return 0, &ambiguousCreateError{err: statusErr}
+		}
+		return 0, statusErr
+	}
+
+	// The transaction was created, so an unreadable body, even one that isn't JSON, only costs the ID
+	id, err := parseCreatedTransactionID(responseBody)
+	if err != nil {
+		log.Printf("WARNING: Transaction created in account %d but its ID couldn't be read: %v", accountID, err)
+	}
+	return id, nil
+}
+
+// parseCreatedTransactionID extracts the ID from a create response,
+// which holds either the transaction object or an array of them
+func parseCreatedTransactionID(body []byte) (int, error) {
+	var created domain.TransactionRecord
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []domain.TransactionRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return 0, fmt.Errorf("unmarshal response: %w", err)
+		}
+		if len(records) == 0 {
+			return 0, errors.New("empty transaction array in response")
+		}
+		created = records[0]
+	} else if err := json.Unmarshal(trimmed, &created); err != nil {
+		return 0, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if created.ID == 0 {
+		return 0, errors.New("no transaction ID in response")
+	}
+	return created.ID, nil
+}
+
+// findExistingTransaction returns the ID of a transaction in the account with the
+// same date, amount, and payee (case-insensitive), or nil when there is none
+func (c *HTTPPocketSmithClient) findExistingTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (*int, error) {
+	amount, err := strconv.ParseFloat(transaction.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
+
+	// Create HTTP request for the transactions on that date
+	query := url.Values{}
+	query.Set("start_date", transaction.Day())
+	query.Set("end_date", transaction.Day())
+	requestURL := fmt.Sprintf("%s/transaction_accounts/%d/transactions?%s", c.baseURL, accountID, query.Encode())
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("accept", "application/json")
+	c.authorize(httpReq)
+
+	// Send request to PocketSmith API
+	resp, err := c.send(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request to PocketSmith: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response from PocketSmith: %w", err)
+	}
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
+	}
+
+	// Unmarshal response
+	var existing []domain.TransactionRecord
+	if err := json.Unmarshal(responseBody, &existing); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	for _, record := range existing {
+		if record.Date == transaction.Day() &&
+			strings.EqualFold(record.Payee, transaction.Payee) &&
+			math.Abs(record.Amount-amount) < 0.005 {
+			return &record.ID, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateTransaction implements PocketSmithClient.UpdateTransaction
+func (c *HTTPPocketSmithClient) UpdateTransaction(ctx context.Context, transactionID int, transaction *domain.PocketSmithTransaction) error {
+	// Marshal request body
+	requestBody, err := json.Marshal(transaction)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	url := fmt.Sprintf("%s/transactions/%d", c.baseURL, transactionID)
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -245,10 +792,10 @@ func (c *HTTPPocketSmithClient) CreateTransaction(accountID int, transaction *do
 	// Set headers
 	httpReq.Header.Set("accept", "application/json")
 	httpReq.Header.Set("content-type", "application/json")
-	httpReq.Header.Set("X-Developer-Key", c.apiKey)
+	c.authorize(httpReq)
 
 	// Send request to PocketSmith API
-	resp, err := spinhttp.Send(httpReq)
+	resp, err := c.send(httpReq)
 	if err != nil {
 		return fmt.Errorf("send request to PocketSmith: %w", err)
 	}
@@ -261,9 +808,163 @@ func (c *HTTPPocketSmithClient) CreateTransaction(accountID int, transaction *do
 	}
 
 	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("PocketSmith request failed with status %d: %s", resp.StatusCode, string(responseBody))
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	return nil
 }
+
+// transactionsPerPage is the page size requested when listing transactions (PocketSmith's maximum)
+const transactionsPerPage = 100
+
+// ListTransactions implements PocketSmithClient.ListTransactions
+func (c *HTTPPocketSmithClient) ListTransactions(ctx context.Context, accountID int, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	query := url.Values{}
+	query.Set("start_date", startDate)
+	query.Set("end_date", endDate)
+	query.Set("per_page", strconv.Itoa(transactionsPerPage))
+	requestURL := fmt.Sprintf("%s/transaction_accounts/%d/transactions?%s", c.baseURL, accountID, query.Encode())
+
+	// Follow the Link header until there is no next page
+	for requestURL != "" {
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+
+		// Set headers
+		httpReq.Header.Set("accept", "application/json")
+		c.authorize(httpReq)
+
+		// Send request to PocketSmith API
+		resp, err := c.send(httpReq)
+		if err != nil {
+			return fmt.Errorf("send request to PocketSmith: %w", err)
+		}
+
+		// Read response body
+		responseBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read response from PocketSmith: %w", err)
+		}
+
+		// Check response status
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("ERROR: Failed to fetch transactions for account %d from PocketSmith API (status %d): %s", accountID, resp.StatusCode, string(responseBody))
+			return &StatusError{StatusCode: resp.StatusCode, Body: string(responseBody)}
+		}
+
+		// Unmarshal response
+		var page []domain.TransactionRecord
+		if err := json.Unmarshal(responseBody, &page); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+
+		if err := visit(page); err != nil {
+			return err
+		}
+		requestURL = ""
+		if link := nextPageURL(resp.Header.Get("Link")); link != "" {
+			if requestURL, err = c.sameHostURL(httpReq.URL, link); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sameHostURL resolves a next page link against the page it was on, refusing one on another
+// scheme or host than the API's, since following it would send the developer key there
+func (c *HTTPPocketSmithClient) sameHostURL(page *url.URL, link string) (string, error) {
+	next, err := page.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("parse next page link %q: %w", link, err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parse base URL: %w", err)
+	}
+	if !strings.EqualFold(next.Scheme, base.Scheme) || !strings.EqualFold(next.Host, base.Host) {
+		return "", fmt.Errorf("refusing to follow next page link to %s://%s, not the PocketSmith API", next.Scheme, next.Host)
+	}
+	return next.String(), nil
+}
+
+// nextPageURL extracts the rel="next" URL from a Link header, or returns "" on the last page
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// send sends a request to the PocketSmith API.
+// The Spin outbound HTTP call can't be interrupted, so a request whose context
+// is already done is refused before it starts. A 429 response is returned as a *RateLimitedError,
+// and an error response that isn't JSON, such as an HTML error page, as an *UnavailableError.
+func (c *HTTPPocketSmithClient) send(httpReq *http.Request) (*http.Response, error) {
+	if err := httpReq.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transport(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordRateLimit(resp.Header)
+
+	// Nothing was processed, so callers can treat this like any other failed request
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		err := &RateLimitedError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), c.options.Clock.Now())}
+		log.Printf("WARNING: PocketSmith rejected %s %s: %v", httpReq.Method, httpReq.URL.Path, err)
+		return nil, err
+	}
+
+	// Check before any body is parsed, so an outage page isn't reported as malformed JSON
+	if contentType := resp.Header.Get("Content-Type"); isUnavailableResponse(resp.StatusCode, contentType) {
+		resp.Body.Close()
+		err := &UnavailableError{StatusCode: resp.StatusCode}
+		log.Printf("WARNING: PocketSmith answered %s %s with %q instead of JSON: %v", httpReq.Method, httpReq.URL.Path, contentType, err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RateLimit implements PocketSmithClient.RateLimit
+func (c *HTTPPocketSmithClient) RateLimit() domain.RateLimit {
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	return c.rateLimit
+}
+
+// recordRateLimit stores the rate-limit headers of a PocketSmith response, if present
+func (c *HTTPPocketSmithClient) recordRateLimit(header http.Header) {
+	rateLimit := domain.RateLimit{
+		Limit:     header.Get("X-RateLimit-Limit"),
+		Remaining: header.Get("X-RateLimit-Remaining"),
+		Reset:     header.Get("X-RateLimit-Reset"),
+	}
+	if rateLimit == (domain.RateLimit{}) {
+		return
+	}
+
+	c.rateLimitMu.Lock()
+	c.rateLimit = rateLimit
+	c.rateLimitMu.Unlock()
+
+	if remaining, err := strconv.Atoi(rateLimit.Remaining); err == nil && remaining < rateLimitWarningThreshold {
+		log.Printf("WARNING: PocketSmith rate limit nearly exhausted: %d of %s requests remaining (reset: %s)", remaining, rateLimit.Limit, rateLimit.Reset)
+	}
+}
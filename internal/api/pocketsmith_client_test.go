@@ -0,0 +1,600 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/repository"
+)
+
+// fakeResponse is a canned PocketSmith answer
+type fakeResponse struct {
+	status int
+	body   string
+	header http.Header
+	// err, when set, is returned instead of the response, as when the connection drops
+	err error
+}
+
+// fakeTransport stands in for PocketSmith, answering each request by its method and path
+// (e.g. "GET /v2/users/42/categories"), or by its path and query when one is routed, and
+// recording the requests it got
+type fakeTransport struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	// queued answers a route's requests in turn before it falls back to responses
+	queued   map[string][]fakeResponse
+	requests []string
+	// before, when set, is called with each request before it's answered, e.g. to hold it
+	before func(req *http.Request)
+}
+
+func (f *fakeTransport) send(req *http.Request) (*http.Response, error) {
+	if f.before != nil {
+		f.before(req)
+	}
+
+	route := req.Method + " " + req.URL.Path
+	f.mu.Lock()
+	f.requests = append(f.requests, route)
+	response, ok := f.responses[route+"?"+req.URL.RawQuery]
+	if !ok {
+		response, ok = f.responses[route]
+	}
+	if queue := f.queued[route]; len(queue) > 0 {
+		response, ok = queue[0], true
+		f.queued[route] = queue[1:]
+	}
+	f.mu.Unlock()
+	if !ok {
+		response = fakeResponse{status: http.StatusNotFound, body: `{"error": "not found"}`}
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	for name, values := range response.header {
+		header[name] = values
+	}
+	return &http.Response{
+		StatusCode: response.status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(response.body)),
+		Request:    req,
+	}, nil
+}
+
+// newTestClient returns a client sending its requests to transport
+func newTestClient(cache repository.CacheRepository, transport *fakeTransport, options Options) *HTTPPocketSmithClient {
+	c := NewHTTPPocketSmithClient("test-key", cache, options).(*HTTPPocketSmithClient)
+	c.transport = transport.send
+	return c
+}
+
+// memoryCache keeps accounts and categories in memory like the real backends do, in one
+// entry, along with user when set; everything else misses like a disabled cache
+type memoryCache struct {
+	repository.CacheRepository
+	mu         sync.Mutex
+	user       *domain.User
+	cached     bool
+	accounts   []domain.TransactionAccount
+	categories []domain.Category
+	sets       int
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{CacheRepository: repository.NewNoopCacheRepository()}
+}
+
+func (m *memoryCache) GetUser() (*domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.user == nil {
+		return nil, errors.New("cache miss: user:me")
+	}
+	return m.user, nil
+}
+
+func (m *memoryCache) GetAccountsAndCategories(userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.cached {
+		return nil, nil, errors.New("cache miss: user:42:entities")
+	}
+	return m.accounts, m.categories, nil
+}
+
+func (m *memoryCache) SetAccountsAndCategories(userID int, accounts []domain.TransactionAccount, categories []domain.Category) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cached, m.accounts, m.categories = true, accounts, categories
+	m.sets++
+	return nil
+}
+
+func (m *memoryCache) DeleteAccountsAndCategories(userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cached, m.accounts, m.categories = false, nil, nil
+	return nil
+}
+
+// UpdateCachedAccountBalance patches the balance of a cached account, failing like the real
+// backends on a miss or an account that isn't cached
+func (m *memoryCache) UpdateCachedAccountBalance(userID, accountID int, balance float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.cached {
+		return errors.New("cache miss: user:42:entities")
+	}
+	for i := range m.accounts {
+		if m.accounts[i].ID == accountID {
+			m.accounts[i].CurrentBalance = balance
+			return nil
+		}
+	}
+	return fmt.Errorf("account %d not cached", accountID)
+}
+
+const (
+	accountsRoute   = "GET /v2/users/42/transaction_accounts"
+	categoriesRoute = "GET /v2/users/42/categories"
+)
+
+func TestAccountsAndCategoriesCaching(t *testing.T) {
+	accountsOK := fakeResponse{status: http.StatusOK, body: `[{"id": 1, "name": "Everyday"}]`}
+	categoriesOK := fakeResponse{status: http.StatusOK, body: `[{"id": 7, "title": "Groceries"}]`}
+	failed := fakeResponse{status: http.StatusInternalServerError, body: `{"error": "boom"}`}
+
+	tests := []struct {
+		name         string
+		cached       bool
+		accounts     fakeResponse
+		categories   fakeResponse
+		get          func(ctx context.Context, c *HTTPPocketSmithClient) error
+		wantRequests int
+		wantSets     int
+		wantErr      bool
+	}{
+		{
+			name:     "hit fetches nothing",
+			cached:   true,
+			accounts: accountsOK, categories: categoriesOK,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				return c.GetAccountsAndCategories(ctx, 42).Err()
+			},
+			wantRequests: 0,
+		},
+		{
+			name:     "miss fetches both and caches them together",
+			accounts: accountsOK, categories: categoriesOK,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				return c.GetAccountsAndCategories(ctx, 42).Err()
+			},
+			wantRequests: 2, wantSets: 1,
+		},
+		{
+			name:     "categories miss refetches the accounts too",
+			accounts: accountsOK, categories: categoriesOK,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				_, err := c.GetCategories(ctx, 42)
+				return err
+			},
+			wantRequests: 2, wantSets: 1,
+		},
+		{
+			name:     "accounts miss refetches the categories too",
+			accounts: accountsOK, categories: categoriesOK,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				_, err := c.GetTransactionAccounts(ctx, 42)
+				return err
+			},
+			wantRequests: 2, wantSets: 1,
+		},
+		{
+			name:     "failed categories fetch caches neither",
+			accounts: accountsOK, categories: failed,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				return c.GetAccountsAndCategories(ctx, 42).Err()
+			},
+			wantRequests: 2, wantErr: true,
+		},
+		{
+			name:     "accounts still returned when only the categories fail",
+			accounts: accountsOK, categories: failed,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				accounts, err := c.GetTransactionAccounts(ctx, 42)
+				if err == nil && len(accounts) != 1 {
+					return errors.New("accounts missing")
+				}
+				return err
+			},
+			wantRequests: 2,
+		},
+		{
+			name:     "failed accounts refresh caches neither",
+			accounts: failed, categories: categoriesOK,
+			get: func(ctx context.Context, c *HTTPPocketSmithClient) error {
+				_, _, err := c.RefreshAccountsAndCategories(ctx, 42)
+				return err
+			},
+			wantRequests: 2, wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMemoryCache()
+			if tt.cached {
+				cache.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, []domain.Category{{ID: 7}})
+				cache.sets = 0
+			}
+			transport := &fakeTransport{responses: map[string]fakeResponse{
+				accountsRoute:   tt.accounts,
+				categoriesRoute: tt.categories,
+			}}
+			c := newTestClient(cache, transport, Options{})
+
+			err := tt.get(context.Background(), c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(transport.requests); got != tt.wantRequests {
+				t.Errorf("requests = %v, want %d", transport.requests, tt.wantRequests)
+			}
+			if cache.sets != tt.wantSets {
+				t.Errorf("cached %d times, want %d", cache.sets, tt.wantSets)
+			}
+			if tt.wantSets > 0 && (len(cache.accounts) != 1 || len(cache.categories) != 1) {
+				t.Errorf("cached %v and %v, want both lists", cache.accounts, cache.categories)
+			}
+		})
+	}
+}
+
+func TestAccountsAndCategoriesFetchedConcurrently(t *testing.T) {
+	// Each fetch is held until the other has been sent too, which only happens when
+	// they're in flight at the same time
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	both := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(both)
+	}()
+	var mu sync.Mutex
+	var sequential []string
+	transport := &fakeTransport{
+		responses: map[string]fakeResponse{
+			accountsRoute:   {status: http.StatusOK, body: `[]`},
+			categoriesRoute: {status: http.StatusOK, body: `[]`},
+		},
+		before: func(req *http.Request) {
+			arrived.Done()
+			select {
+			case <-both:
+			case <-time.After(2 * time.Second):
+				mu.Lock()
+				sequential = append(sequential, req.URL.Path)
+				mu.Unlock()
+			}
+		},
+	}
+	c := newTestClient(newMemoryCache(), transport, Options{})
+
+	if err := c.GetAccountsAndCategories(context.Background(), 42).Err(); err != nil {
+		t.Fatalf("GetAccountsAndCategories() error = %v", err)
+	}
+	if len(sequential) > 0 {
+		t.Errorf("%v waited for the other fetch, want both in flight at once", sequential)
+	}
+}
+
+func TestListTransactionsFollowsNextPage(t *testing.T) {
+	const (
+		route    = "GET /v2/transaction_accounts/5/transactions"
+		firstURL = "?end_date=2025-01-31&per_page=100&start_date=2025-01-01"
+	)
+	tests := []struct {
+		name      string
+		link      string
+		wantPages int
+		wantErr   bool
+	}{
+		{name: "absolute link on the API host", link: `<https://api.pocketsmith.com/v2/transaction_accounts/5/transactions?page=2>; rel="next"`, wantPages: 2},
+		{name: "relative link", link: `</v2/transaction_accounts/5/transactions?page=2>; rel="next"`, wantPages: 2},
+		{name: "last page", link: `<https://api.pocketsmith.com/v2/transaction_accounts/5/transactions?page=1>; rel="first"`, wantPages: 1},
+		{name: "other host", link: `<https://attacker.example/v2/transaction_accounts/5/transactions?page=2>; rel="next"`, wantPages: 1, wantErr: true},
+		{name: "plain HTTP", link: `<http://api.pocketsmith.com/v2/transaction_accounts/5/transactions?page=2>; rel="next"`, wantPages: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hosts []string
+			transport := &fakeTransport{
+				responses: map[string]fakeResponse{
+					route + firstURL:  {status: http.StatusOK, body: `[{"id": 1}]`, header: http.Header{"Link": []string{tt.link}}},
+					route + "?page=2": {status: http.StatusOK, body: `[{"id": 2}]`},
+				},
+				before: func(req *http.Request) {
+					hosts = append(hosts, req.URL.Scheme+"://"+req.URL.Host)
+				},
+			}
+			c := newTestClient(repository.NewNoopCacheRepository(), transport, Options{})
+
+			pages := 0
+			err := c.ListTransactions(context.Background(), 5, "2025-01-01", "2025-01-31", func([]domain.TransactionRecord) error {
+				pages++
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListTransactions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("visited %d pages, want %d", pages, tt.wantPages)
+			}
+			for _, host := range hosts {
+				if host != "https://api.pocketsmith.com" {
+					t.Errorf("sent a request to %s, want only the PocketSmith API", host)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateTransactionLostResponse(t *testing.T) {
+	const (
+		postRoute = "POST /v2/transaction_accounts/5/transactions"
+		listRoute = "GET /v2/transaction_accounts/5/transactions"
+	)
+	created := fakeResponse{status: http.StatusCreated, body: `{"id": 101}`}
+	lost := fakeResponse{err: errors.New("connection reset by peer")}
+	existing := fakeResponse{status: http.StatusOK, body: `[{"id": 100, "payee": "corner store", "amount": -12.5, "date": "2025-01-13"}]`}
+	different := fakeResponse{status: http.StatusOK, body: `[{"id": 99, "payee": "Corner Store", "amount": -12, "date": "2025-01-13"}]`}
+
+	tests := []struct {
+		name       string
+		posts      []fakeResponse
+		list       fakeResponse
+		cancel     bool
+		wantID     int
+		wantErr    bool
+		wantPosts  int
+		wantChecks int
+	}{
+		{name: "created", posts: []fakeResponse{created}, wantID: 101, wantPosts: 1},
+		{name: "response lost and the transaction exists", posts: []fakeResponse{lost}, list: existing, wantID: 100, wantPosts: 1, wantChecks: 1},
+		{name: "gateway timeout and the transaction exists", posts: []fakeResponse{{status: http.StatusGatewayTimeout, body: `{"error": "timeout"}`}}, list: existing, wantID: 100, wantPosts: 1, wantChecks: 1},
+		{name: "response lost and no matching transaction", posts: []fakeResponse{lost, created}, list: different, wantID: 101, wantPosts: 2, wantChecks: 1},
+		{name: "response lost and the check fails", posts: []fakeResponse{lost}, list: fakeResponse{status: http.StatusInternalServerError, body: `{"error": "boom"}`}, wantErr: true, wantPosts: 1, wantChecks: 1},
+		{name: "response lost after the request ended", posts: []fakeResponse{lost}, list: existing, cancel: true, wantErr: true, wantPosts: 1},
+		{name: "rejected", posts: []fakeResponse{{status: http.StatusUnprocessableEntity, body: `{"error": "invalid"}`}}, wantErr: true, wantPosts: 1},
+		{name: "rate limited", posts: []fakeResponse{{status: http.StatusTooManyRequests, body: `{"error": "slow down"}`}}, wantErr: true, wantPosts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			transport := &fakeTransport{
+				responses: map[string]fakeResponse{listRoute: tt.list},
+				queued:    map[string][]fakeResponse{postRoute: tt.posts},
+			}
+			if tt.cancel {
+				transport.before = func(req *http.Request) {
+					if req.Method == http.MethodPost {
+						cancel()
+					}
+				}
+			}
+			c := newTestClient(repository.NewNoopCacheRepository(), transport, Options{})
+
+			id, err := c.CreateTransaction(ctx, 5, &domain.PocketSmithTransaction{
+				Payee:  "Corner Store",
+				Amount: "-12.50",
+				Date:   "2025-01-13T08:15:00+13:00",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateTransaction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Errorf("CreateTransaction() = %d, want %d", id, tt.wantID)
+			}
+			posts, checks := 0, 0
+			for _, request := range transport.requests {
+				switch request {
+				case postRoute:
+					posts++
+				case listRoute:
+					checks++
+				}
+			}
+			if posts != tt.wantPosts || checks != tt.wantChecks {
+				t.Errorf("sent %d creates and %d checks, want %d and %d", posts, checks, tt.wantPosts, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestRateLimitRecorded(t *testing.T) {
+	limited := func(remaining string) http.Header {
+		return http.Header{"X-Ratelimit-Limit": {"100"}, "X-Ratelimit-Remaining": {remaining}, "X-Ratelimit-Reset": {"1736755200"}}
+	}
+	// Each response is answered in turn by the same client, which keeps the latest values
+	tests := []struct {
+		name     string
+		response fakeResponse
+		want     domain.RateLimit
+	}{
+		{name: "headers recorded", response: fakeResponse{status: http.StatusOK, body: `{"id": 42}`, header: limited("57")},
+			want: domain.RateLimit{Limit: "100", Remaining: "57", Reset: "1736755200"}},
+		{name: "no headers keeps the previous values", response: fakeResponse{status: http.StatusOK, body: `{"id": 42}`},
+			want: domain.RateLimit{Limit: "100", Remaining: "57", Reset: "1736755200"}},
+		{name: "failed response still recorded", response: fakeResponse{status: http.StatusTooManyRequests, body: `{"error": "slow down"}`, header: limited("0")},
+			want: domain.RateLimit{Limit: "100", Remaining: "0", Reset: "1736755200"}},
+	}
+	transport := &fakeTransport{queued: map[string][]fakeResponse{}}
+	c := newTestClient(newMemoryCache(), transport, Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport.queued["GET /v2/me"] = []fakeResponse{tt.response}
+
+			c.RefreshUser(context.Background())
+
+			if got := c.RateLimit(); got != tt.want {
+				t.Errorf("RateLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCachedAccountBalance(t *testing.T) {
+	tests := []struct {
+		name         string
+		cached       bool
+		accountID    int
+		wantCached   bool
+		wantAccounts []domain.TransactionAccount
+	}{
+		{
+			name:         "patched in place",
+			cached:       true,
+			accountID:    2,
+			wantCached:   true,
+			wantAccounts: []domain.TransactionAccount{{ID: 1, CurrentBalance: 10}, {ID: 2, CurrentBalance: 75.5}},
+		},
+		{name: "account not cached", cached: true, accountID: 3},
+		{name: "nothing cached", accountID: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMemoryCache()
+			if tt.cached {
+				cache.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1, CurrentBalance: 10}, {ID: 2, CurrentBalance: 20}}, []domain.Category{{ID: 7}})
+			}
+			transport := &fakeTransport{}
+			c := newTestClient(cache, transport, Options{})
+
+			c.UpdateCachedAccountBalance(context.Background(), 42, tt.accountID, 75.5)
+
+			// Patching never refetches; an account that can't be patched drops the entry
+			// so the next lookup does
+			if len(transport.requests) > 0 {
+				t.Errorf("requests = %v, want none", transport.requests)
+			}
+			if cache.cached != tt.wantCached {
+				t.Fatalf("accounts still cached = %v, want %v", cache.cached, tt.wantCached)
+			}
+			if !reflect.DeepEqual(cache.accounts, tt.wantAccounts) {
+				t.Errorf("cached accounts = %v, want %v", cache.accounts, tt.wantAccounts)
+			}
+		})
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	const (
+		institutionsRoute = "POST /v2/users/42/institutions"
+		accountsPostRoute = "POST /v2/users/42/accounts"
+	)
+	institution := fakeResponse{status: http.StatusCreated, body: `{"id": 5}`}
+	account := fakeResponse{status: http.StatusCreated, body: `{"id": 60, "transaction_accounts": [{"id": 61, "name": "Savings"}]}`}
+	existingInstitution := 5
+	tests := []struct {
+		name          string
+		institutionID *int
+		responses     map[string]fakeResponse
+		wantID        int
+		wantErr       bool
+		wantRequests  []string
+	}{
+		{
+			name:         "with a new institution",
+			responses:    map[string]fakeResponse{institutionsRoute: institution, accountsPostRoute: account},
+			wantID:       61,
+			wantRequests: []string{institutionsRoute, accountsPostRoute},
+		},
+		{
+			name:          "in an existing institution",
+			institutionID: &existingInstitution,
+			responses:     map[string]fakeResponse{accountsPostRoute: account},
+			wantID:        61,
+			wantRequests:  []string{accountsPostRoute},
+		},
+		{
+			name:         "institution refused",
+			responses:    map[string]fakeResponse{institutionsRoute: {status: http.StatusUnprocessableEntity, body: `{"error": "invalid"}`}},
+			wantErr:      true,
+			wantRequests: []string{institutionsRoute},
+		},
+		{
+			name: "account without a transaction account",
+			responses: map[string]fakeResponse{
+				institutionsRoute: institution,
+				accountsPostRoute: {status: http.StatusCreated, body: `{"id": 60, "transaction_accounts": []}`},
+			},
+			wantErr:      true,
+			wantRequests: []string{institutionsRoute, accountsPostRoute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMemoryCache()
+			cache.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, []domain.Category{{ID: 7}})
+			transport := &fakeTransport{responses: tt.responses}
+			c := newTestClient(cache, transport, Options{})
+
+			id, err := c.CreateAccount(context.Background(), 42, &domain.AccountParams{
+				Name: "Savings", Currency: "AUD", Type: "bank", InstitutionID: tt.institutionID,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateAccount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Errorf("CreateAccount() = %d, want %d", id, tt.wantID)
+			}
+			if !reflect.DeepEqual(transport.requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", transport.requests, tt.wantRequests)
+			}
+			// Only a created account drops the cached accounts
+			if cache.cached != tt.wantErr {
+				t.Errorf("accounts still cached = %v, want %v", cache.cached, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateTransactionIsTransferPayload(t *testing.T) {
+	for _, isTransfer := range []bool{false, true} {
+		var payload map[string]any
+		transport := &fakeTransport{
+			responses: map[string]fakeResponse{"POST /v2/transaction_accounts/5/transactions": {status: http.StatusCreated, body: `{"id": 101}`}},
+			before: func(req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				json.Unmarshal(body, &payload)
+			},
+		}
+		c := newTestClient(newMemoryCache(), transport, Options{})
+
+		_, err := c.CreateTransaction(context.Background(), 5, &domain.PocketSmithTransaction{
+			Payee: "Card Payment", Amount: "-500.00", Date: "2025-01-13", IsTransfer: isTransfer,
+		})
+		if err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+		if got, ok := payload["is_transfer"]; !ok || got != isTransfer {
+			t.Errorf("payload is_transfer = %v (sent %v), want %v", got, ok, isTransfer)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited means PocketSmith rejected a request with 429 Too Many Requests
+var ErrRateLimited = errors.New("PocketSmith rate limit exceeded")
+
+// RateLimitedError is returned for a 429 response, with how long PocketSmith asked us to wait
+type RateLimitedError struct {
+	// RetryAfter is taken from PocketSmith's Retry-After header (0 when it sent none)
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v, back off and retry after %v", ErrRateLimited, e.RetryAfter)
+	}
+	return fmt.Sprintf("%v, back off and retry later", ErrRateLimited)
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// IsRateLimited checks if an error comes from a PocketSmith 429 response
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// RetryAfter returns how long PocketSmith asked us to wait before retrying after err,
+// or 0 when err isn't a rate-limit error or PocketSmith didn't say
+func RetryAfter(err error) time.Duration {
+	var rateLimited *RateLimitedError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses a Retry-After header holding either delay seconds or an
+// HTTP date, returning 0 when it is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now).Truncate(time.Second), 0)
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 13, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: " 30 ", want: 30 * time.Second},
+		{value: "-5", want: 0},
+		{value: "Mon, 13 Jan 2025 12:01:30 GMT", want: 90 * time.Second},
+		{value: "Mon, 13 Jan 2025 11:59:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitedResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         http.Header
+		wantRetryAfter time.Duration
+	}{
+		{name: "with Retry-After", header: http.Header{"Retry-After": {"30"}}, wantRetryAfter: 30 * time.Second},
+		{name: "without Retry-After"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{responses: map[string]fakeResponse{
+				"GET /v2/me": {status: http.StatusTooManyRequests, body: `{"error": "slow down"}`, header: tt.header},
+			}}
+			c := newTestClient(newMemoryCache(), transport, Options{})
+
+			_, err := c.RefreshUser(context.Background())
+			wrapped := fmt.Errorf("failed to get user info: %w", err)
+			if !IsRateLimited(wrapped) {
+				t.Fatalf("RefreshUser() error = %v, want a rate-limit error", err)
+			}
+			if got := RetryAfter(wrapped); got != tt.wantRetryAfter {
+				t.Errorf("RetryAfter() = %v, want %v", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
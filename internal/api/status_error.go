@@ -0,0 +1,27 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StatusError is returned when PocketSmith answers a request with an unexpected status
+type StatusError struct {
+	StatusCode int
+	// Body is the response body, usually PocketSmith's JSON error
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("PocketSmith request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// StatusCode returns the PocketSmith response status behind err, or 0 when err didn't come
+// from an unexpected status
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
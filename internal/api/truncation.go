@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+)
+
+// truncationKey is the context key under which list truncation is flagged
+type truncationKey struct{}
+
+// WithTruncation returns a context that flags when the client returns a capped list
+func WithTruncation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, truncationKey{}, new(atomic.Bool))
+}
+
+// Truncated reports whether any list returned with ctx reached the entity cap
+// and may therefore be incomplete
+func Truncated(ctx context.Context) bool {
+	truncated, _ := ctx.Value(truncationKey{}).(*atomic.Bool)
+	return truncated != nil && truncated.Load()
+}
+
+// markTruncated flags ctx as having returned a capped list, if it tracks truncation
+func markTruncated(ctx context.Context) {
+	if truncated, _ := ctx.Value(truncationKey{}).(*atomic.Bool); truncated != nil {
+		truncated.Store(true)
+	}
+}
+
+// capEntities cuts a freshly fetched list down to maxEntities (0 disables the cap),
+// logging a warning when entries are dropped
+func capEntities[T any](ctx context.Context, entities []T, maxEntities int, kind string) []T {
+	if maxEntities <= 0 || len(entities) <= maxEntities {
+		return entities
+	}
+	log.Printf("Warning: PocketSmith returned %d %s, keeping only the first %d", len(entities), kind, maxEntities)
+	markTruncated(ctx)
+	return entities[:maxEntities]
+}
+
+// checkCapped flags ctx when a cached list is at the cap, since it was likely cut
+func checkCapped(ctx context.Context, count, maxEntities int) {
+	if maxEntities > 0 && count >= maxEntities {
+		markTruncated(ctx)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestTruncation(t *testing.T) {
+	const (
+		threeAccounts   = `[{"id": 1}, {"id": 2}, {"id": 3}]`
+		twoCategories   = `[{"id": 7}, {"id": 8}]`
+		threeCategories = `[{"id": 7}, {"id": 8}, {"id": 9}]`
+	)
+	tests := []struct {
+		name           string
+		maxEntities    int
+		accounts       string
+		categories     string
+		cached         int
+		wantAccounts   int
+		wantCategories int
+		wantTruncated  bool
+	}{
+		{name: "accounts over the cap", maxEntities: 2, accounts: threeAccounts, categories: twoCategories, wantAccounts: 2, wantCategories: 2, wantTruncated: true},
+		{name: "categories over the cap", maxEntities: 2, accounts: `[{"id": 1}]`, categories: threeCategories, wantAccounts: 1, wantCategories: 2, wantTruncated: true},
+		{name: "under the cap", maxEntities: 5, accounts: threeAccounts, categories: threeCategories, wantAccounts: 3, wantCategories: 3},
+		{name: "cap disabled", accounts: threeAccounts, categories: threeCategories, wantAccounts: 3, wantCategories: 3},
+		{name: "cached list at the cap", maxEntities: 2, cached: 2, wantAccounts: 2, wantCategories: 1, wantTruncated: true},
+		{name: "cached list under the cap", maxEntities: 2, cached: 1, wantAccounts: 1, wantCategories: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMemoryCache()
+			if tt.cached > 0 {
+				accounts := make([]domain.TransactionAccount, tt.cached)
+				cache.SetAccountsAndCategories(42, accounts, []domain.Category{{ID: 7}})
+			}
+			transport := &fakeTransport{responses: map[string]fakeResponse{
+				accountsRoute:   {status: http.StatusOK, body: tt.accounts},
+				categoriesRoute: {status: http.StatusOK, body: tt.categories},
+			}}
+			c := newTestClient(cache, transport, Options{MaxEntities: tt.maxEntities})
+			ctx := WithTruncation(context.Background())
+
+			lists := c.GetAccountsAndCategories(ctx, 42)
+			if err := lists.Err(); err != nil {
+				t.Fatalf("GetAccountsAndCategories() error = %v", err)
+			}
+			if len(lists.Accounts) != tt.wantAccounts || len(lists.Categories) != tt.wantCategories {
+				t.Errorf("got %d accounts and %d categories, want %d and %d", len(lists.Accounts), len(lists.Categories), tt.wantAccounts, tt.wantCategories)
+			}
+			if got := Truncated(ctx); got != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", got, tt.wantTruncated)
+			}
+			if tt.cached == 0 && tt.wantTruncated && len(cache.accounts) > tt.maxEntities {
+				t.Errorf("cached %d accounts, want at most %d", len(cache.accounts), tt.maxEntities)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrUnavailable means PocketSmith answered with an error status and something other than JSON,
+// typically the HTML error page of a proxy in front of it during an outage
+var ErrUnavailable = errors.New("PocketSmith unavailable")
+
+// UnavailableError is returned for an error response that isn't JSON, keeping only its status,
+// since the page itself says nothing useful
+type UnavailableError struct {
+	StatusCode int
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%v (status %d)", ErrUnavailable, e.StatusCode)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
+
+// IsUnavailable checks if an error comes from a PocketSmith error response that wasn't JSON
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
+
+// isUnavailableResponse reports whether a response is an error page rather than an answer from
+// PocketSmith. A 2xx never is, whatever its body: the request was handled, so it must not be
+// reported as retryable.
+func isUnavailableResponse(statusCode int, contentType string) bool {
+	return (statusCode < 200 || statusCode > 299) && !isJSONResponse(statusCode, contentType)
+}
+
+// isGatewayFailure reports whether a create failed in a way a gateway in front of PocketSmith
+// may have passed it on first: an error page with a 5xx, or a 502 Bad Gateway or 504 Gateway
+// Timeout in any form. PocketSmith may then have committed the create before the gateway gave up.
+func isGatewayFailure(err error) bool {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.StatusCode >= 500
+	}
+	status := StatusCode(err)
+	return status == http.StatusBadGateway || status == http.StatusGatewayTimeout
+}
+
+// isJSONResponse reports whether a response is JSON by its Content-Type, e.g. "application/json;
+// charset=utf-8". Without a Content-Type only statuses below 500 count, since bodiless responses
+// may have none, while PocketSmith's own errors always have one.
+func isJSONResponse(statusCode int, contentType string) bool {
+	if strings.TrimSpace(contentType) == "" {
+		return statusCode < 500
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
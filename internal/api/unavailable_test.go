@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsUnavailableResponse(t *testing.T) {
+	tests := []struct {
+		statusCode  int
+		contentType string
+		want        bool
+	}{
+		{statusCode: 200, contentType: "application/json; charset=utf-8", want: false},
+		{statusCode: 201, contentType: "text/html", want: false},
+		{statusCode: 204, contentType: "", want: false},
+		{statusCode: 404, contentType: "application/problem+json", want: false},
+		{statusCode: 404, contentType: "", want: false},
+		{statusCode: 403, contentType: "text/html", want: true},
+		{statusCode: 502, contentType: "text/html", want: true},
+		{statusCode: 503, contentType: "", want: true},
+	}
+	for _, tt := range tests {
+		if got := isUnavailableResponse(tt.statusCode, tt.contentType); got != tt.want {
+			t.Errorf("isUnavailableResponse(%d, %q) = %v, want %v", tt.statusCode, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestIsGatewayFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "gateway error page", err: fmt.Errorf("send: %w", &UnavailableError{StatusCode: 502}), want: true},
+		{name: "gateway timeout page", err: &UnavailableError{StatusCode: 504}, want: true},
+		{name: "firewall page", err: &UnavailableError{StatusCode: 403}, want: false},
+		{name: "gateway timeout as JSON", err: &StatusError{StatusCode: 504}, want: true},
+		{name: "PocketSmith error", err: &StatusError{StatusCode: 500}, want: false},
+		{name: "rejected create", err: &StatusError{StatusCode: 422}, want: false},
+		{name: "rate limited", err: &RateLimitedError{}, want: false},
+	}
+	for _, tt := range tests {
+		if got := isGatewayFailure(tt.err); got != tt.want {
+			t.Errorf("%s: isGatewayFailure() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLErrorPage(t *testing.T) {
+	htmlPage := `<html><head><title>503 Service Unavailable</title></head><body>Down for maintenance</body></html>`
+	tests := []struct {
+		name            string
+		response        fakeResponse
+		wantUnavailable bool
+		wantStatus      int
+	}{
+		{name: "HTML 503", response: fakeResponse{status: http.StatusServiceUnavailable, body: htmlPage, header: http.Header{"Content-Type": {"text/html; charset=utf-8"}}},
+			wantUnavailable: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "HTML 502", response: fakeResponse{status: http.StatusBadGateway, body: htmlPage, header: http.Header{"Content-Type": {"text/html"}}},
+			wantUnavailable: true, wantStatus: http.StatusBadGateway},
+		{name: "JSON 503", response: fakeResponse{status: http.StatusServiceUnavailable, body: `{"error": "maintenance"}`},
+			wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{responses: map[string]fakeResponse{"GET /v2/me": tt.response}}
+			c := newTestClient(newMemoryCache(), transport, Options{})
+
+			_, err := c.RefreshUser(context.Background())
+			if err == nil {
+				t.Fatal("RefreshUser() error = nil")
+			}
+			if IsUnavailable(err) != tt.wantUnavailable {
+				t.Fatalf("RefreshUser() error = %v, want unavailable %v", err, tt.wantUnavailable)
+			}
+			var unavailable *UnavailableError
+			if tt.wantUnavailable {
+				// The status is kept and the page neither parsed nor quoted
+				if !errors.As(err, &unavailable) || unavailable.StatusCode != tt.wantStatus {
+					t.Errorf("RefreshUser() error = %v, want an unavailable error with status %d", err, tt.wantStatus)
+				}
+				if message := err.Error(); strings.Contains(message, "html") || strings.Contains(message, "invalid character") {
+					t.Errorf("RefreshUser() error = %q, want no page or parse error in it", message)
+				}
+				return
+			}
+			if got := StatusCode(err); got != tt.wantStatus {
+				t.Errorf("StatusCode() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+)
+
+// Build metadata, set at build time via -ldflags "-X github.com/pocketsmith-proxy/internal/buildinfo.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// spinSDKModule is the module path of the Spin Go SDK
+const spinSDKModule = "github.com/spinframework/spin-go-sdk/v2"
+
+// Info describes the running build
+type Info struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	BuildTime      string `json:"build_time"`
+	SpinSDKVersion string `json:"spin_sdk_version,omitempty"`
+}
+
+// Get returns the running build's metadata
+func Get() Info {
+	return Info{
+		Version:        Version,
+		Commit:         Commit,
+		BuildTime:      BuildTime,
+		SpinSDKVersion: spinSDKVersion(),
+	}
+}
+
+// spinSDKVersion returns the Spin SDK version from the embedded module info, if available
+func spinSDKVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == spinSDKModule {
+			return dep.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits, so logic depending on either can be driven by a Fake
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// Real is the wall clock
+type Real struct{}
+
+// Now implements Clock.Now
+func (Real) Now() time.Time { return time.Now() }
+
+// Sleep implements Clock.Sleep
+func (Real) Sleep(d time.Duration) { time.Sleep(d) }
+
+// OrReal returns c, or the wall clock when c is nil
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Fake is a clock that only moves when told to. Sleeping advances it by the duration
+// instead of blocking, and is recorded so a backoff sequence can be checked.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewFake creates a fake clock set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now implements Clock.Now
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep implements Clock.Sleep, advancing the clock by d without waiting
+func (f *Fake) Sleep(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sleeps = append(f.sleeps, d)
+	if d > 0 {
+		f.now = f.now.Add(d)
+	}
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Sleeps returns the durations passed to Sleep so far, in order
+func (f *Fake) Sleeps() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.sleeps...)
+}
@@ -0,0 +1,35 @@
+package clock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrReal(t *testing.T) {
+	if _, ok := OrReal(nil).(Real); !ok {
+		t.Errorf("OrReal(nil) = %T, want Real", OrReal(nil))
+	}
+	fake := NewFake(time.Unix(0, 0))
+	if got := OrReal(fake); got != fake {
+		t.Errorf("OrReal(fake) = %v, want the fake itself", got)
+	}
+}
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 13, 12, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	fake.Advance(time.Hour)
+	fake.Sleep(20 * time.Millisecond)
+	fake.Sleep(0)
+	fake.Sleep(40 * time.Millisecond)
+
+	if want := start.Add(time.Hour + 60*time.Millisecond); !fake.Now().Equal(want) {
+		t.Errorf("Now() = %v, want %v", fake.Now(), want)
+	}
+	wantSleeps := []time.Duration{20 * time.Millisecond, 0, 40 * time.Millisecond}
+	if got := fake.Sleeps(); !reflect.DeepEqual(got, wantSleeps) {
+		t.Errorf("Sleeps() = %v, want %v", got, wantSleeps)
+	}
+}
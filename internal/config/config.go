@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fermyon/spin/sdk/go/v2/variables"
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/handler"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// Config holds the proxy configuration, parsed from Spin variables.
+// Each field is tagged with its variable name, and credentials with secret:"true"
+// so Redacted never shows them.
+type Config struct {
+	// Client and PocketSmith credentials
+	ClientAuthKey         string                 `json:"client_auth_key" secret:"true"`
+	ClientAuthKeySHA256   []byte                 `json:"client_auth_key_sha256" secret:"true"`
+	AuthHeaderMode        handler.AuthHeaderMode `json:"auth_header_mode"`
+	PocketSmithAPIKey     string                 `json:"pocketsmith_api_key" secret:"true"`
+	PocketSmithAuthMode   api.AuthMode           `json:"pocketsmith_auth_mode"`
+	PocketSmithAPIVersion string                 `json:"pocketsmith_api_version"`
+	Tenants               []Tenant               `json:"tenants" secret:"true"`
+
+	// Optional behaviors enabled together, each overridden by its own variable when set
+	Features Features `json:"features"`
+
+	// Cache
+	CacheEnabled       bool    `json:"cache_enabled"`
+	CacheBackend       string  `json:"cache_backend"`
+	RedisAddress       string  `json:"redis_address" secret:"password"`
+	CacheNamespace     string  `json:"cache_namespace"`
+	CacheTTLJitter     float64 `json:"cache_ttl_jitter"`
+	MaxEntities        int     `json:"max_entities"`
+	RedisRetryAttempts int     `json:"redis_retry_attempts"`
+
+	// Request handling
+	RequestTimeout       time.Duration              `json:"request_timeout_ms"`
+	SlowRequestThreshold time.Duration              `json:"slow_request_threshold_ms"`
+	StrictParams         bool                       `json:"strict_params"`
+	ParamAliases         map[string]string          `json:"param_aliases"`
+	TitleCaseMerchant    bool                       `json:"title_case_merchant"`
+	CurrencySymbols      []string                   `json:"currency_symbols"`
+	OnSymbolMismatch     handler.SymbolMismatchMode `json:"on_symbol_mismatch"`
+	AllowedCurrencies    []string                   `json:"allowed_currencies"`
+	MaxDateAgeDays       int                        `json:"max_date_age_days"`
+	MaxDateAheadDays     int                        `json:"max_date_ahead_days"`
+	DebugRoutes          bool                       `json:"debug_routes"`
+	StrictPaths          bool                       `json:"strict_paths"`
+	ServiceName          string                     `json:"service_name"`
+
+	// Transaction creation
+	RollupToParent      bool                          `json:"rollup_to_parent"`
+	RoundAmount         bool                          `json:"round_amount"`
+	MaxAmountDecimals   int                           `json:"max_amount_decimals"`
+	MerchantRules       []service.MerchantRule        `json:"merchant_rules"`
+	UpsertMatchFields   []string                      `json:"upsert_match_fields"`
+	BatchConcurrency    int                           `json:"batch_concurrency"`
+	DedupWindowSeconds  int                           `json:"dedup_window_seconds"`
+	DedupMode           service.DedupMode             `json:"dedup_mode"`
+	DefaultLabels       []string                      `json:"default_labels"`
+	DefaultSource       string                        `json:"default_source"`
+	AdjustmentPayee     string                        `json:"balance_adjustment_payee"`
+	AdjustmentCategory  string                        `json:"balance_adjustment_category"`
+	CategorySelfHeal    bool                          `json:"category_self_heal"`
+	UnknownCategory     service.UnknownCategoryPolicy `json:"on_unknown_category"`
+	EnforceCategorySign bool                          `json:"enforce_category_sign"`
+	SourceTarget        service.SourceTarget          `json:"source_target"`
+	MerchantTarget      service.MerchantTarget        `json:"merchant_target"`
+	TimeZone            *time.Location                `json:"time_zone"`
+}
+
+// Load reads and validates every Spin variable.
+// All problems are collected rather than stopping at the first one.
+func Load() (*Config, error) {
+	return load(variables.Get)
+}
+
+// load reads and validates every variable through get, so tests can supply their own
+func load(get func(name string) (string, error)) (*Config, error) {
+	l := &loader{get: get}
+	features := ParseFeatures(l.string("features"))
+	cfg := &Config{
+		Features:             features,
+		ClientAuthKey:        l.string("client_auth_key"),
+		PocketSmithAPIKey:    l.string("pocketsmith_api_key"),
+		CacheBackend:         l.string("cache_backend"),
+		RedisAddress:         l.string("redis_address"),
+		CacheNamespace:       l.string("cache_namespace"),
+		CacheTTLJitter:       l.float("cache_ttl_jitter"),
+		MaxEntities:          l.int("max_entities"),
+		RedisRetryAttempts:   l.int("redis_retry_attempts"),
+		RequestTimeout:       time.Duration(l.int("request_timeout_ms")) * time.Millisecond,
+		SlowRequestThreshold: time.Duration(l.int("slow_request_threshold_ms")) * time.Millisecond,
+		StrictParams:         l.flag("strict_params", features),
+		TitleCaseMerchant:    l.flag("title_case_merchant", features),
+		MaxDateAgeDays:       l.int("max_date_age_days"),
+		MaxDateAheadDays:     l.int("max_date_ahead_days"),
+		MaxAmountDecimals:    l.int("max_amount_decimals"),
+		DebugRoutes:          l.flag("debug_routes", features),
+		StrictPaths:          l.flag("strict_paths", features),
+		ServiceName:          l.string("service_name"),
+		RollupToParent:       l.flag("rollup_to_parent", features),
+		RoundAmount:          l.flag("round_amount", features),
+		DefaultSource:        l.string("default_source"),
+		AdjustmentPayee:      l.string("balance_adjustment_payee"),
+		AdjustmentCategory:   l.string("balance_adjustment_category"),
+		CategorySelfHeal:     l.flag("category_self_heal", features),
+		EnforceCategorySign:  l.flag("enforce_category_sign", features),
+		BatchConcurrency:     l.int("batch_concurrency"),
+		DedupWindowSeconds:   l.int("dedup_window_seconds"),
+	}
+
+	l.parse("tenants", func(value string) (err error) {
+		cfg.Tenants, err = parseTenants(value)
+		return err
+	})
+	l.parse("client_auth_key_sha256", func(value string) (err error) {
+		cfg.ClientAuthKeySHA256, err = handler.ParseAuthKeyDigest(value)
+		return err
+	})
+	l.parse("auth_header_mode", func(value string) (err error) {
+		cfg.AuthHeaderMode, err = handler.ParseAuthHeaderMode(value)
+		return err
+	})
+	l.parse("pocketsmith_auth_mode", func(value string) (err error) {
+		cfg.PocketSmithAuthMode, err = api.ParseAuthMode(value)
+		return err
+	})
+	l.parse("pocketsmith_api_version", func(value string) (err error) {
+		cfg.PocketSmithAPIVersion, err = api.ParseAPIVersion(value)
+		return err
+	})
+	l.parse("cache_enabled", func(value string) (err error) {
+		// Empty keeps the cache on
+		cfg.CacheEnabled = true
+		if value != "" {
+			cfg.CacheEnabled, err = strconv.ParseBool(value)
+		}
+		return err
+	})
+	l.parse("currency_symbols", func(value string) (err error) {
+		cfg.CurrencySymbols, err = handler.ParseCurrencySymbols(value)
+		return err
+	})
+	l.parse("on_symbol_mismatch", func(value string) (err error) {
+		cfg.OnSymbolMismatch, err = handler.ParseSymbolMismatchMode(value)
+		return err
+	})
+	l.parse("param_aliases", func(value string) (err error) {
+		cfg.ParamAliases, err = handler.ParseParamAliases(value)
+		return err
+	})
+	l.parse("allowed_currencies", func(value string) (err error) {
+		cfg.AllowedCurrencies, err = handler.ParseAllowedCurrencies(value)
+		return err
+	})
+	l.parse("on_unknown_category", func(value string) (err error) {
+		cfg.UnknownCategory, err = service.ParseUnknownCategoryPolicy(value)
+		return err
+	})
+	l.parse("merchant_rules", func(value string) (err error) {
+		cfg.MerchantRules, err = service.ParseMerchantRules(value)
+		return err
+	})
+	l.parse("default_labels", func(value string) error {
+		cfg.DefaultLabels = service.ParseLabels(value)
+		return nil
+	})
+	l.parse("time_zone", func(value string) (err error) {
+		cfg.TimeZone, err = service.ParseTimeZone(value)
+		return err
+	})
+	l.parse("source_target", func(value string) (err error) {
+		cfg.SourceTarget, err = service.ParseSourceTarget(value)
+		return err
+	})
+	l.parse("dedup_mode", func(value string) (err error) {
+		cfg.DedupMode, err = service.ParseDedupMode(value)
+		return err
+	})
+	l.parse("merchant_target", func(value string) (err error) {
+		cfg.MerchantTarget, err = service.ParseMerchantTarget(value)
+		return err
+	})
+	l.parse("upsert_match_fields", func(value string) (err error) {
+		cfg.UpsertMatchFields, err = service.ParseUpsertMatchFields(value)
+		return err
+	})
+
+	// Range checks
+	if cfg.ClientAuthKey == "" && cfg.ClientAuthKeySHA256 == nil {
+		l.fail("client_auth_key", errors.New("required unless client_auth_key_sha256 is set"))
+	}
+	switch cfg.CacheBackend {
+	case "", "redis", "kv":
+	default:
+		l.fail("cache_backend", fmt.Errorf("unknown cache backend %q, expected redis or kv", cfg.CacheBackend))
+	}
+	if cfg.CacheTTLJitter < 0 || cfg.CacheTTLJitter >= 1 {
+		l.fail("cache_ttl_jitter", errors.New("must be at least 0 and less than 1"))
+	}
+	l.nonNegative("max_entities", cfg.MaxEntities)
+	l.nonNegative("redis_retry_attempts", cfg.RedisRetryAttempts)
+	l.nonNegative("request_timeout_ms", int(cfg.RequestTimeout/time.Millisecond))
+	// Tenants bring their own PocketSmith keys, otherwise the top-level one is needed
+	if cfg.PocketSmithAPIKey == "" && len(cfg.Tenants) == 0 {
+		l.fail("pocketsmith_api_key", errors.New("required unless tenants are configured"))
+	}
+	l.nonNegative("slow_request_threshold_ms", int(cfg.SlowRequestThreshold/time.Millisecond))
+	l.nonNegative("max_date_age_days", cfg.MaxDateAgeDays)
+	l.nonNegative("max_date_ahead_days", cfg.MaxDateAheadDays)
+	l.nonNegative("max_amount_decimals", cfg.MaxAmountDecimals)
+	l.nonNegative("batch_concurrency", cfg.BatchConcurrency)
+	l.nonNegative("dedup_window_seconds", cfg.DedupWindowSeconds)
+	if strings.Contains(cfg.DefaultSource, ",") {
+		l.fail("default_source", errors.New("must not contain commas"))
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loader reads Spin variables, collecting every error it runs into
+type loader struct {
+	get  func(name string) (string, error)
+	errs []error
+}
+
+// fail records an error for a variable
+func (l *loader) fail(name string, err error) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %w", name, err))
+}
+
+// string reads a variable as is
+func (l *loader) string(name string) string {
+	value, err := l.get(name)
+	if err != nil {
+		l.fail(name, err)
+	}
+	return value
+}
+
+// int reads an optional integer variable (empty means zero)
+func (l *loader) int(name string) int {
+	var parsed int
+	l.parse(name, func(value string) (err error) {
+		if value != "" {
+			parsed, err = strconv.Atoi(value)
+		}
+		return err
+	})
+	return parsed
+}
+
+// float reads an optional float variable (empty means zero)
+func (l *loader) float(name string) float64 {
+	var parsed float64
+	l.parse(name, func(value string) (err error) {
+		if value != "" {
+			parsed, err = strconv.ParseFloat(value, 64)
+		}
+		return err
+	})
+	return parsed
+}
+
+// parse reads a variable and hands it to parse, recording either error
+func (l *loader) parse(name string, parse func(value string) error) {
+	value, err := l.get(name)
+	if err != nil {
+		l.fail(name, err)
+		return
+	}
+	if err := parse(value); err != nil {
+		l.fail(name, err)
+	}
+}
+
+// nonNegative records an error when a numeric variable is negative
+func (l *loader) nonNegative(name string, value int) {
+	if value < 0 {
+		l.fail(name, errors.New("must not be negative"))
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// knownFeatures are the optional behaviors the features variable can enable, each named
+// after its own variable
+var knownFeatures = []string{
+	"title_case_merchant",
+	"strict_params",
+	"strict_paths",
+	"debug_routes",
+	"rollup_to_parent",
+	"round_amount",
+	"category_self_heal",
+	"enforce_category_sign",
+}
+
+// Features is the set of optional behaviors enabled by the features variable
+type Features struct {
+	enabled map[string]bool
+}
+
+// ParseFeatures parses a comma-separated list of feature names, case-insensitively.
+// Unknown names are ignored with a warning rather than failing the configuration, so a
+// list shared by deployments of different versions still loads.
+func ParseFeatures(value string) Features {
+	features := Features{enabled: make(map[string]bool)}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !isKnownFeature(name) {
+			log.Printf("Warning: Ignoring unknown feature %q, expected one of: %s", name, strings.Join(knownFeatures, ", "))
+			continue
+		}
+		features.enabled[name] = true
+	}
+	return features
+}
+
+// IsEnabled reports whether the named feature is in the set
+func (f Features) IsEnabled(name string) bool {
+	return f.enabled[name]
+}
+
+// MarshalText shows the enabled features as they are configured, sorted
+func (f Features) MarshalText() ([]byte, error) {
+	names := make([]string, 0, len(f.enabled))
+	for name := range f.enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return []byte(strings.Join(names, ",")), nil
+}
+
+// isKnownFeature reports whether name is one of knownFeatures
+func isKnownFeature(name string) bool {
+	for _, known := range knownFeatures {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// flag reads an optional boolean variable that features can also enable. An explicit true
+// or false takes precedence, and empty defers to the features.
+func (l *loader) flag(name string, features Features) bool {
+	parsed := features.IsEnabled(name)
+	l.parse(name, func(value string) (err error) {
+		if value != "" {
+			parsed, err = strconv.ParseBool(value)
+		}
+		return err
+	})
+	return parsed
+}
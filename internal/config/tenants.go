@@ -0,0 +1,84 @@
+package config
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tenantNamePattern restricts tenant names to characters safe in a cache key
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Tenant is one PocketSmith account served by the proxy, selected by its own client key
+type Tenant struct {
+	Name              string
+	ClientAuthKey     string `json:"client_auth_key"`
+	PocketSmithAPIKey string `json:"pocketsmith_api_key"`
+}
+
+// parseTenants parses a JSON object mapping tenant names to their keys, e.g.
+// {"personal": {"client_auth_key": "...", "pocketsmith_api_key": "..."}} (empty means none).
+// Tenants are returned sorted by name, and every client key must be unique.
+func parseTenants(value string) ([]Tenant, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var byName map[string]Tenant
+	if err := json.Unmarshal([]byte(value), &byName); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of tenant names to keys: %w", err)
+	}
+
+	tenants := make([]Tenant, 0, len(byName))
+	clientKeys := make(map[string]string, len(byName))
+	for name, tenant := range byName {
+		switch {
+		case !tenantNamePattern.MatchString(name):
+			return nil, fmt.Errorf("invalid tenant name %q: expected letters, digits, - or _", name)
+		case tenant.ClientAuthKey == "":
+			return nil, fmt.Errorf("tenant %s: client_auth_key is required", name)
+		case tenant.PocketSmithAPIKey == "":
+			return nil, fmt.Errorf("tenant %s: pocketsmith_api_key is required", name)
+		}
+		if other, ok := clientKeys[tenant.ClientAuthKey]; ok {
+			return nil, fmt.Errorf("tenants %s and %s share a client_auth_key", other, name)
+		}
+		clientKeys[tenant.ClientAuthKey] = name
+
+		tenant.Name = name
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].Name < tenants[j].Name
+	})
+	return tenants, nil
+}
+
+// ForTenant returns the configuration for the tenant whose client key is token: its keys
+// replace the top-level ones, and its cache entries live in their own namespace under
+// cache_namespace. It reports false when no tenant has the key.
+func (c *Config) ForTenant(token string) (*Config, bool) {
+	// Compare against every tenant in constant time, so timing doesn't reveal a match
+	var match *Tenant
+	for i := range c.Tenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.Tenants[i].ClientAuthKey)) == 1 {
+			match = &c.Tenants[i]
+		}
+	}
+	if token == "" || match == nil {
+		return nil, false
+	}
+
+	tenantCfg := *c
+	tenantCfg.ClientAuthKey = match.ClientAuthKey
+	tenantCfg.ClientAuthKeySHA256 = nil
+	tenantCfg.PocketSmithAPIKey = match.PocketSmithAPIKey
+	tenantCfg.CacheNamespace = match.Name
+	if c.CacheNamespace != "" {
+		tenantCfg.CacheNamespace = c.CacheNamespace + ":" + match.Name
+	}
+	return &tenantCfg, true
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// maxAmountDecimals bounds the decimal expansion of a number in exponent notation
+const maxAmountDecimals = 30
+
+// Amount is a monetary amount param, sent as a JSON string or number.
+// Numbers keep their exact decimal digits as written, never passing through a float.
+type Amount string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*a = Amount(s)
+		return nil
+	}
+
+	decimal, err := decimalString(string(data))
+	if err != nil {
+		return err
+	}
+	*a = Amount(decimal)
+	return nil
+}
+
+// decimalString converts a JSON number literal to plain decimal notation,
+// expanding exponents such as 1.5e3 exactly
+func decimalString(literal string) (string, error) {
+	if !json.Valid([]byte(literal)) || strings.ContainsAny(literal, "\"[{tfn") {
+		return "", fmt.Errorf("amount must be a string or number, got %s", literal)
+	}
+	if !strings.ContainsAny(literal, "eE") {
+		return literal, nil
+	}
+
+	// A decimal literal is a fraction over a power of ten, so it has a finite expansion
+	value, ok := new(big.Rat).SetString(literal)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %s", literal)
+	}
+	for decimals := 0; decimals <= maxAmountDecimals; decimals++ {
+		scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+		if scaled.IsInt() {
+			return value.FloatString(decimals), nil
+		}
+	}
+	return "", fmt.Errorf("amount %s has more than %d decimal places", literal, maxAmountDecimals)
+}
+
+// DecimalPlaces returns the number of fractional digits of a plain decimal amount,
+// ignoring trailing zeros
+func DecimalPlaces(amount string) int {
+	_, fraction, _ := strings.Cut(amount, ".")
+	return len(strings.TrimRight(fraction, "0"))
+}
+
+// RoundDecimal rounds a plain decimal amount to places fractional digits, halves away
+// from zero, working on its decimal digits so e.g. 2.675 becomes 2.68 rather than the
+// 2.67 its nearest float rounds to. It reports false, returning the amount unchanged,
+// when the amount isn't a number.
+func RoundDecimal(amount string, places int) (string, bool) {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return amount, false
+	}
+	return value.FloatString(places), true
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAmountUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{name: "string kept as sent", input: `"-5,50"`, want: "-5,50"},
+		{name: "string with symbol", input: `"$12.50"`, want: "$12.50"},
+		{name: "number keeps its digits", input: `-5.50`, want: "-5.50"},
+		{name: "number not rounded through a float", input: `0.1`, want: "0.1"},
+		{name: "exponent expanded exactly", input: `1.5e3`, want: "1500"},
+		{name: "negative exponent", input: `-125e-2`, want: "-1.25"},
+		{name: "null leaves the amount empty", input: `null`, want: ""},
+		{name: "boolean", input: `true`, wantErr: true},
+		{name: "object", input: `{"value": 1}`, wantErr: true},
+		{name: "array", input: `[1]`, wantErr: true},
+		{name: "too many decimals", input: `1e-31`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Unmarshal(%s) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	tests := map[string]int{"12": 0, "12.": 0, "12.50": 1, "-0.125": 3, "1.000": 0, ".05": 2}
+	for amount, want := range tests {
+		if got := DecimalPlaces(amount); got != want {
+			t.Errorf("DecimalPlaces(%q) = %d, want %d", amount, got, want)
+		}
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	tests := []struct {
+		amount string
+		places int
+		want   string
+		wantOK bool
+	}{
+		{amount: "2.675", places: 2, want: "2.68", wantOK: true},
+		{amount: "-2.675", places: 2, want: "-2.68", wantOK: true},
+		{amount: "1.005", places: 2, want: "1.01", wantOK: true},
+		{amount: "12.5", places: 0, want: "13", wantOK: true},
+		{amount: "12.5", places: 3, want: "12.500", wantOK: true},
+		{amount: "123456789012345678.125", places: 2, want: "123456789012345678.13", wantOK: true},
+		{amount: "abc", places: 2, want: "abc"},
+	}
+	for _, tt := range tests {
+		got, ok := RoundDecimal(tt.amount, tt.places)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RoundDecimal(%q, %d) = %q, %v, want %q, %v", tt.amount, tt.places, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+// JobStatus is the progress of an asynchronous batch job
+type JobStatus string
+
+const (
+	// JobPending means no item has been processed yet
+	JobPending JobStatus = "pending"
+	// JobRunning means some items have been processed and others are still waiting
+	JobRunning JobStatus = "running"
+	// JobCompleted means every item has a result
+	JobCompleted JobStatus = "completed"
+)
+
+// Job is a batch of transactions created asynchronously, a chunk at a time
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+	// Total is the number of items submitted, Processed how many of them have a result
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	// CreatedAt and UpdatedAt are RFC 3339 timestamps
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// Results holds each item's result at its index, with a zero status until it's processed
+	Results []BatchItemResult `json:"results"`
+	// Pending are the validated items still to be created, in submission order
+	Pending []JobItem `json:"pending,omitempty"`
+}
+
+// JobItem is a validated transaction waiting in a job, with its index in the submission
+type JobItem struct {
+	Index       int          `json:"index"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// BatchItemResult reports the outcome of one batch item, at its position in the request
+type BatchItemResult struct {
+	Index  int               `json:"index"`
+	Status int               `json:"status"`
+	ID     int               `json:"id,omitempty"`
+	Result string            `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Code   string            `json:"code,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
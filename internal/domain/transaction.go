@@ -1,66 +1,272 @@
 package domain
 
-// Transaction represents a financial transaction
+import "encoding/json"
+
+// Transaction represents a financial transaction, validated and normalized from its params
 type Transaction struct {
-	Account  string
-	Category string
-	Merchant string
-	Amount   string
-	Date     string
+	Account  string `json:"account"`
+	Category string `json:"category,omitempty"`
+	Merchant string `json:"merchant"`
+	// Payee is the PocketSmith payee when it differs from the merchant ("" uses the merchant)
+	Payee  string `json:"payee,omitempty"`
+	Amount string `json:"value"`
+	// Date is the calendar day, YYYY-MM-DD
+	Date string `json:"date"`
+	// DateTime is the ISO 8601 date and time when one was sent instead of a plain date,
+	// with the sender's UTC offset when it had one ("" for date-only)
+	DateTime string `json:"date_time,omitempty"`
+	// Optional ISO 4217 code and amount for a transaction made in a foreign currency
+	Currency      string `json:"currency,omitempty"`
+	ForeignAmount string `json:"foreign_amount,omitempty"`
+	// AccountCurrency is the ISO 4217 code of the account to post to, picking it among
+	// several sharing its name
+	AccountCurrency string `json:"account_currency,omitempty"`
+	// CategoryID selects the category directly, taking precedence over Category
+	CategoryID *int `json:"category_id,omitempty"`
+	// Labels are attached to the created transaction
+	Labels []string `json:"labels,omitempty"`
+	// Upsert updates a matching existing transaction instead of creating a duplicate
+	Upsert bool `json:"upsert"`
+	// NeedsReview flags the transaction for review; nil leaves PocketSmith's default
+	NeedsReview *bool `json:"needs_review,omitempty"`
+	// IsTransfer marks a single-leg entry, e.g. a manually recorded card payment, as a transfer
+	IsTransfer bool `json:"is_transfer"`
+	// Source identifies where the transaction came from, e.g. "ios-shortcut"
+	Source string `json:"source,omitempty"`
+}
+
+// TransactionEntities are the account and category a transaction would be posted to
+type TransactionEntities struct {
+	AccountID  int    `json:"account_id,omitempty"`
+	Account    string `json:"account,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+	CategoryID int    `json:"category_id,omitempty"`
+	Category   string `json:"category,omitempty"`
+}
+
+// AddedTransaction is a created (or, with upsert, updated) transaction and the account and
+// category it was posted to
+type AddedTransaction struct {
+	// ID is the PocketSmith transaction ID (0 when PocketSmith's response doesn't say)
+	ID      int
+	Account ResolvedEntity
+	// Category is nil for an uncategorized transaction
+	Category *ResolvedEntity
+	// Duplicate means nothing was created, since an identical transaction was submitted
+	// within the dedup window (ID is then 0)
+	Duplicate bool
+}
+
+// ResolvedEntity identifies the account or category a transaction's params resolved to,
+// named as in PocketSmith
+type ResolvedEntity struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 // PocketSmithTransaction represents a transaction in PocketSmith API format
 type PocketSmithTransaction struct {
-	Payee      string `json:"payee"`
-	Amount     string `json:"amount"`
-	Date       string `json:"date"`
-	IsTransfer bool   `json:"is_transfer"`
-	CategoryID *int   `json:"category_id,omitempty"`
+	Payee               string `json:"payee"`
+	Amount              string `json:"amount"`
+	Date                string `json:"date"`
+	IsTransfer          bool   `json:"is_transfer"`
+	CategoryID          *int   `json:"category_id,omitempty"`
+	ForeignAmount       string `json:"foreign_amount,omitempty"`
+	ForeignCurrencyCode string `json:"foreign_currency_code,omitempty"`
+	// Labels is a comma-separated list of labels
+	Labels string `json:"labels,omitempty"`
+	// NeedsReview is a pointer so an explicit false is still sent
+	NeedsReview *bool  `json:"needs_review,omitempty"`
+	Note        string `json:"note,omitempty"`
+}
+
+// Day returns the calendar day of the transaction's date, which may carry a time of day
+func (t *PocketSmithTransaction) Day() string {
+	if len(t.Date) > len("2006-01-02") {
+		return t.Date[:len("2006-01-02")]
+	}
+	return t.Date
+}
+
+// TransactionRecord represents a transaction as returned by the PocketSmith API
+type TransactionRecord struct {
+	ID     int     `json:"id"`
+	Payee  string  `json:"payee"`
+	Amount float64 `json:"amount"`
+	Date   string  `json:"date"`
+	Note   string  `json:"note,omitempty"`
+	// Category is nil for uncategorized transactions
+	Category *TransactionCategory `json:"category,omitempty"`
+}
+
+// TransactionCategory identifies the category of a listed transaction
+type TransactionCategory struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// Summary totals transactions by category, separately for each currency
+type Summary struct {
+	StartDate  string            `json:"start_date"`
+	EndDate    string            `json:"end_date"`
+	Currencies []CurrencySummary `json:"currencies"`
+}
+
+// CurrencySummary totals the transactions of accounts in one currency
+type CurrencySummary struct {
+	Currency   string            `json:"currency"`
+	Total      float64           `json:"total"`
+	Categories []CategorySummary `json:"categories"`
+}
+
+// CategorySummary totals the transactions in one category
+type CategorySummary struct {
+	// CategoryID is omitted for uncategorized transactions
+	CategoryID *int    `json:"category_id,omitempty"`
+	Category   string  `json:"category"`
+	Total      float64 `json:"total"`
+	Count      int     `json:"count"`
 }
 
 // RPCRequest represents a JSON-RPC request
 type RPCRequest struct {
-	Method string         `json:"method"`
-	Params map[string]any `json:"params"`
+	// ID is echoed in the response as sent, a string or number (nil when omitted)
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params map[string]any  `json:"params"`
 }
 
 // TransactionParams represents the parameters for adding a transaction
 type TransactionParams struct {
-	Account  string `json:"account"`
-	Category string `json:"category"`
-	Merchant string `json:"merchant"`
-	Value    string `json:"value"`
-	Date     string `json:"date"`
+	Account         string `json:"account"`
+	Category        string `json:"category"`
+	Merchant        string `json:"merchant"`
+	Payee           string `json:"payee"`
+	Value           Amount `json:"value"`
+	Date            string `json:"date"`
+	Currency        string `json:"currency"`
+	ForeignAmount   Amount `json:"foreign_amount"`
+	AccountCurrency string `json:"account_currency"`
+	CategoryID      *int   `json:"category_id"`
+	Upsert          bool   `json:"upsert"`
+	NeedsReview     *bool  `json:"needs_review"`
+	IsTransfer      bool   `json:"is_transfer"`
+	Source          string `json:"source"`
 }
 
 // User represents a PocketSmith user
 type User struct {
-	ID int `json:"id"`
+	ID               int    `json:"id"`
+	Login            string `json:"login,omitempty"`
+	Name             string `json:"name,omitempty"`
+	BaseCurrencyCode string `json:"base_currency_code,omitempty"`
+	TimeZone         string `json:"time_zone,omitempty"`
 }
 
 // TransactionAccount represents a PocketSmith transaction account
 type TransactionAccount struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	CurrencyCode string `json:"currency_code"`
-	IsNetWorth   bool   `json:"is_net_worth"`
+	ID                 int          `json:"id"`
+	Name               string       `json:"name"`
+	Type               string       `json:"type"`
+	CurrencyCode       string       `json:"currency_code"`
+	IsNetWorth         bool         `json:"is_net_worth"`
+	CurrentBalance     float64      `json:"current_balance"`
+	CurrentBalanceDate string       `json:"current_balance_date,omitempty"`
+	Institution        *Institution `json:"institution,omitempty"`
+}
+
+// Institution represents the PocketSmith institution an account belongs to
+type Institution struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
 }
 
 // Category represents a PocketSmith category
 type Category struct {
-	ID       int    `json:"id"`
-	Title    string `json:"title"`
-	ParentID *int   `json:"parent_id"`
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	ParentID   *int   `json:"parent_id"`
+	Colour     string `json:"colour"`
+	IsTransfer bool   `json:"is_transfer"`
+	// IsIncome marks categories for money coming in; the others are for expenses
+	IsIncome bool       `json:"is_income"`
+	Children []Category `json:"children,omitempty"`
+}
+
+// CategoryDetail is a category with the titles of its ancestors
+type CategoryDetail struct {
+	Category
+	// Path lists the titles from the top-level ancestor down to this category
+	Path []string `json:"path"`
+}
+
+// CategoryMatch represents a category search result
+type CategoryMatch struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Path lists the titles from the top-level ancestor down to this category
+	Path []string `json:"path"`
 }
 
 // AccountInfo represents account information for the client
 type AccountInfo struct {
 	Name     string `json:"name"`
 	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+// AccountParams represents the parameters for creating a transaction account
+type AccountParams struct {
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+	// InstitutionID places the account in an existing institution; a new one named
+	// after the account is created when omitted
+	InstitutionID *int `json:"institution_id"`
+}
+
+// BalanceAdjustment is the outcome of setting an account's balance to a target
+type BalanceAdjustment struct {
+	// TransactionID is the adjustment transaction's PocketSmith ID, 0 when the account was
+	// already balanced or PocketSmith's response doesn't say
+	TransactionID   int     `json:"transaction_id,omitempty"`
+	Currency        string  `json:"currency"`
+	PreviousBalance float64 `json:"previous_balance"`
+	Balance         float64 `json:"balance"`
+	// Delta is the adjustment transaction's amount, 0 when the account was already balanced
+	Delta float64 `json:"delta"`
 }
 
 // ShortcutEntities represents combined accounts and categories data
 type ShortcutEntities struct {
 	Accounts   []AccountInfo `json:"accounts"`
 	Categories []string      `json:"categories"`
+	// Warnings describes which part failed to load when the data is partial
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RateLimit holds the latest rate-limit header values reported by PocketSmith
+type RateLimit struct {
+	Limit     string
+	Remaining string
+	Reset     string
 }
+
+// CacheEntity identifies a per-user entity kept in the cache
+type CacheEntity string
+
+const (
+	// CacheEntityAccounts is the user's transaction accounts
+	CacheEntityAccounts CacheEntity = "accounts"
+	// CacheEntityCategories is the user's categories
+	CacheEntityCategories CacheEntity = "categories"
+)
+
+// CacheCounter is the cumulative number of cache lookups of one kind of entity
+type CacheCounter struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// CacheStats maps each kind of cached entry ("user", "entities") to its lookups
+type CacheStats map[string]CacheCounter
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// amountTypes are the transaction orientations accepted by the amount preview
+var amountTypes = []string{"expense", "income"}
+
+// amountPreviewParams is the body of POST /api/v1/amounts/normalize
+type amountPreviewParams struct {
+	Value    domain.Amount `json:"value"`
+	Currency string        `json:"currency"`
+	// Type is the transaction's orientation, "expense" or "income", checked against the sign
+	Type string `json:"type"`
+}
+
+// amountPreview is the response of POST /api/v1/amounts/normalize
+type amountPreview struct {
+	// Value is the amount as sent
+	Value string `json:"value"`
+	// Normalized is the amount as it would be posted to PocketSmith
+	Normalized string `json:"normalized"`
+	// Sign is "negative", "positive", or "zero"
+	Sign string `json:"sign"`
+	// DecimalSeparator is the separator found in the amount, "." or "," (empty without one)
+	DecimalSeparator string `json:"decimal_separator"`
+	// Symbols are the currency symbols stripped from the amount
+	Symbols []string `json:"symbols"`
+	// Warnings describe changes and conflicts that don't fail the amount
+	Warnings []string `json:"warnings"`
+}
+
+// handleNormalizeAmount handles POST /api/v1/amounts/normalize, running an amount through
+// the same normalization as a transaction's value and reporting each step. An amount
+// transactions.add would reject gets the same 422.
+func (h *HTTPHandler) handleNormalizeAmount(w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	var params amountPreviewParams
+	body, reqErr := h.readJSONObject(r)
+	if reqErr == nil {
+		reqErr = h.decodeParams(body, &params)
+	}
+	var preview *amountPreview
+	if reqErr == nil {
+		preview, reqErr = h.previewAmount(params)
+	}
+	if reqErr != nil {
+		statusCode = reqErr.statusCode
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(preview)
+	h.logRequest(method, path, statusCode)
+}
+
+// previewAmount normalizes an amount like validateTransactionParams does for a value in
+// currency, then rounds it like the service would with round_amount.
+// All problems are collected rather than stopping at the first one.
+func (h *HTTPHandler) previewAmount(params amountPreviewParams) (*amountPreview, *requestError) {
+	fieldErrors := make(map[string]string)
+	preview := &amountPreview{Value: string(params.Value), Warnings: []string{}}
+
+	stripped, symbols := cutCurrencySymbols(preview.Value, h.options.CurrencySymbols)
+	preview.Symbols = append([]string{}, symbols...)
+	switch {
+	case strings.Contains(stripped, ","):
+		preview.DecimalSeparator = ","
+	case strings.Contains(stripped, "."):
+		preview.DecimalSeparator = "."
+	}
+
+	amount, reason := normalizeAmount(preview.Value, h.options.CurrencySymbols)
+	if reason == "" {
+		var limited string
+		limited, reason = h.checkAmountDecimals(amount)
+		if reason == "" && limited != amount {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("rounded to %d decimal places", h.options.MaxAmountDecimals))
+		}
+		amount = limited
+	}
+	if reason != "" {
+		fieldErrors["value"] = reason
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(params.Currency))
+	if currency != "" {
+		if reason := h.checkCurrency(currency); reason != "" {
+			fieldErrors["currency"] = reason
+		} else if conflict := h.symbolMismatch(preview.Value, currency); conflict != "" {
+			if h.options.OnSymbolMismatch == SymbolMismatchReject && fieldErrors["value"] == "" {
+				fieldErrors["value"] = conflict
+			}
+			preview.Warnings = append(preview.Warnings, conflict)
+		}
+	}
+
+	orientation := strings.ToLower(strings.TrimSpace(params.Type))
+	if orientation != "" && !slices.Contains(amountTypes, orientation) {
+		fieldErrors["type"] = "unknown type, expected one of: " + strings.Join(amountTypes, ", ")
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
+	}
+
+	// The service rounds amounts to their currency's precision only when posting them
+	if h.options.RoundAmount && currency != "" {
+		rounded := service.RoundAmount(amount, currency)
+		if !sameAmount(rounded, amount) {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("rounded to the precision of %s", currency))
+		}
+		amount = rounded
+	}
+	preview.Normalized = amount
+
+	value, _ := strconv.ParseFloat(amount, 64)
+	switch {
+	case value < 0:
+		preview.Sign = "negative"
+	case value > 0:
+		preview.Sign = "positive"
+	default:
+		preview.Sign = "zero"
+	}
+	if orientation == "expense" && value > 0 || orientation == "income" && value < 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s amounts are usually %s, but this one is %s", orientation, oppositeSign(preview.Sign), preview.Sign))
+	}
+
+	return preview, nil
+}
+
+// sameAmount reports whether two normalized amounts are the same number, e.g. "12.5" and "12.50"
+func sameAmount(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
+
+// oppositeSign returns "negative" for "positive" and the other way around
+func oppositeSign(sign string) string {
+	if sign == "positive" {
+		return "negative"
+	}
+	return "positive"
+}
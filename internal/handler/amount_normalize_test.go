@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleNormalizeAmount(t *testing.T) {
+	symbols, _ := ParseCurrencySymbols("")
+	tests := []struct {
+		name       string
+		options    Options
+		body       map[string]string
+		want       *amountPreview
+		wantFields map[string]string
+	}{
+		{
+			name: "decimal comma",
+			body: map[string]string{"value": "-5,50", "currency": "EUR", "type": "expense"},
+			want: &amountPreview{Value: "-5,50", Normalized: "-5.50", Sign: "negative", DecimalSeparator: ",", Symbols: []string{}, Warnings: []string{}},
+		},
+		{
+			name: "symbol after the sign",
+			body: map[string]string{"value": "-$5.50", "currency": "USD"},
+			want: &amountPreview{Value: "-$5.50", Normalized: "-5.50", Sign: "negative", DecimalSeparator: ".", Symbols: []string{"$"}, Warnings: []string{}},
+		},
+		{
+			name: "multi-character symbol and trailing code",
+			body: map[string]string{"value": "10 kr"},
+			want: &amountPreview{Value: "10 kr", Normalized: "10", Sign: "positive", Symbols: []string{"kr"}, Warnings: []string{}},
+		},
+		{
+			name: "symbol conflict warned",
+			body: map[string]string{"value": "$12.50", "currency": "EUR"},
+			want: &amountPreview{Value: "$12.50", Normalized: "12.50", Sign: "positive", DecimalSeparator: ".", Symbols: []string{"$"},
+				Warnings: []string{"currency symbol $ doesn't match currency EUR"}},
+		},
+		{
+			name:       "symbol conflict rejected",
+			options:    Options{OnSymbolMismatch: SymbolMismatchReject},
+			body:       map[string]string{"value": "R$ 10", "currency": "USD"},
+			wantFields: map[string]string{"value": "currency symbol R$ doesn't match currency USD"},
+		},
+		{
+			name:    "rounded to the decimal limit",
+			options: Options{MaxAmountDecimals: 2, RoundAmount: true},
+			body:    map[string]string{"value": "1.236"},
+			want: &amountPreview{Value: "1.236", Normalized: "1.24", Sign: "positive", DecimalSeparator: ".", Symbols: []string{},
+				Warnings: []string{"rounded to 2 decimal places"}},
+		},
+		{
+			name:    "rounded to the currency precision",
+			options: Options{RoundAmount: true},
+			body:    map[string]string{"value": "-1234.4", "currency": "JPY"},
+			want: &amountPreview{Value: "-1234.4", Normalized: "-1234", Sign: "negative", DecimalSeparator: ".", Symbols: []string{},
+				Warnings: []string{"rounded to the precision of JPY"}},
+		},
+		{
+			name: "sign against the type",
+			body: map[string]string{"value": "5.00", "type": "expense"},
+			want: &amountPreview{Value: "5.00", Normalized: "5.00", Sign: "positive", DecimalSeparator: ".", Symbols: []string{},
+				Warnings: []string{"expense amounts are usually negative, but this one is positive"}},
+		},
+		{
+			name:       "every problem reported",
+			options:    Options{MaxAmountDecimals: 2},
+			body:       map[string]string{"value": "1.000,50", "currency": "EURO", "type": "transfer"},
+			wantFields: map[string]string{"value": "multiple decimal separators", "currency": "invalid currency code, expected 3 letters", "type": "unknown type, expected one of: expense, income"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.CurrencySymbols = symbols
+			h := NewHTTPHandler(nil, "key", options)
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/amounts/normalize", strings.NewReader(string(body)))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			h.handleNormalizeAmount(recorder, req)
+
+			if tt.wantFields != nil {
+				var got struct {
+					Errors map[string]string `json:"errors"`
+				}
+				if recorder.Code != http.StatusUnprocessableEntity {
+					t.Fatalf("status = %d, want 422 (body %s)", recorder.Code, recorder.Body)
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+					t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+				}
+				if !reflect.DeepEqual(got.Errors, tt.wantFields) {
+					t.Errorf("errors = %v, want %v", got.Errors, tt.wantFields)
+				}
+				return
+			}
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", recorder.Code, recorder.Body)
+			}
+			var got amountPreview
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if !reflect.DeepEqual(&got, tt.want) {
+				t.Errorf("preview = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// AuthHeaderMode selects where the client auth key is read from
+type AuthHeaderMode string
+
+const (
+	// AuthHeaderBearer reads "Authorization: Bearer <key>" (default)
+	AuthHeaderBearer AuthHeaderMode = "bearer"
+	// AuthHeaderToken reads "Authorization: Token <key>"
+	AuthHeaderToken AuthHeaderMode = "token"
+	// AuthHeaderAPIKey reads "X-API-Key: <key>"
+	AuthHeaderAPIKey AuthHeaderMode = "api_key"
+	// AuthHeaderAny accepts any of the forms above
+	AuthHeaderAny AuthHeaderMode = "any"
+)
+
+// ParseAuthHeaderMode parses an auth header mode, defaulting to bearer when empty
+func ParseAuthHeaderMode(value string) (AuthHeaderMode, error) {
+	switch mode := AuthHeaderMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return AuthHeaderBearer, nil
+	case AuthHeaderBearer, AuthHeaderToken, AuthHeaderAPIKey, AuthHeaderAny:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown auth header mode: %s", value)
+	}
+}
+
+// ClientToken returns the client auth key sent with the request, per the auth header mode
+func ClientToken(r *http.Request, mode AuthHeaderMode) string {
+	authorization := r.Header.Get("Authorization")
+
+	switch mode {
+	case AuthHeaderToken:
+		return strings.TrimPrefix(authorization, "Token ")
+	case AuthHeaderAPIKey:
+		return r.Header.Get("X-API-Key")
+	case AuthHeaderAny:
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			return apiKey
+		}
+		if token, ok := strings.CutPrefix(authorization, "Token "); ok {
+			return token
+		}
+		return strings.TrimPrefix(authorization, "Bearer ")
+	default:
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+}
+
+// ParseAuthKeyDigest parses a hex-encoded SHA-256 digest of the client auth key (empty means none)
+func ParseAuthKeyDigest(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	digest, err := hex.DecodeString(value)
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("expected a hex-encoded SHA-256 digest (%d hex characters)", 2*sha256.Size)
+	}
+	return digest, nil
+}
+
+// validateAuth validates the client auth key sent with the request.
+// When a digest of the key is configured it takes precedence over the plaintext key.
+func (h *HTTPHandler) validateAuth(r *http.Request) bool {
+	clientToken := ClientToken(r, h.options.AuthHeaderMode)
+
+	// A missing key never matches, even when no client key is configured
+	valid := clientToken != "" && h.clientAuthKey == clientToken
+	if h.options.ClientAuthKeySHA256 != nil {
+		digest := sha256.Sum256([]byte(clientToken))
+		valid = subtle.ConstantTimeCompare(digest[:], h.options.ClientAuthKeySHA256) == 1
+	}
+	if !valid {
+		log.Println("Invalid client auth")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAuthHeaderMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    AuthHeaderMode
+		wantErr bool
+	}{
+		{value: "", want: AuthHeaderBearer},
+		{value: "bearer", want: AuthHeaderBearer},
+		{value: " Token ", want: AuthHeaderToken},
+		{value: "API_KEY", want: AuthHeaderAPIKey},
+		{value: "any", want: AuthHeaderAny},
+		{value: "basic", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAuthHeaderMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAuthHeaderMode(%q) = %q, %v, want %q (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAuthHeaderForms(t *testing.T) {
+	bearer := http.Header{"Authorization": {"Bearer key"}}
+	token := http.Header{"Authorization": {"Token key"}}
+	apiKey := http.Header{"X-Api-Key": {"key"}}
+	tests := []struct {
+		name   string
+		mode   AuthHeaderMode
+		header http.Header
+		want   bool
+	}{
+		{name: "default mode, bearer", header: bearer, want: true},
+		{name: "default mode, token", header: token},
+		{name: "default mode, API key", header: apiKey},
+		{name: "bearer mode, bearer", mode: AuthHeaderBearer, header: bearer, want: true},
+		{name: "bearer mode, wrong key", mode: AuthHeaderBearer, header: http.Header{"Authorization": {"Bearer other"}}},
+		{name: "token mode, token", mode: AuthHeaderToken, header: token, want: true},
+		{name: "token mode, bearer", mode: AuthHeaderToken, header: bearer},
+		{name: "API key mode, API key", mode: AuthHeaderAPIKey, header: apiKey, want: true},
+		{name: "API key mode, bearer", mode: AuthHeaderAPIKey, header: bearer},
+		{name: "any mode, bearer", mode: AuthHeaderAny, header: bearer, want: true},
+		{name: "any mode, token", mode: AuthHeaderAny, header: token, want: true},
+		{name: "any mode, API key", mode: AuthHeaderAny, header: apiKey, want: true},
+		{name: "any mode, API key taking precedence", mode: AuthHeaderAny, header: http.Header{"X-Api-Key": {"key"}, "Authorization": {"Bearer other"}}, want: true},
+		{name: "any mode, nothing sent", mode: AuthHeaderAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "key", Options{AuthHeaderMode: tt.mode})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			if got := h.validateAuth(req); got != tt.want {
+				t.Errorf("validateAuth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A missing key never matches, even with no client key configured
+	h := NewHTTPHandler(nil, "", Options{})
+	if h.validateAuth(httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)) {
+		t.Error("validateAuth() without a key = true, want false")
+	}
+}
+
+func TestParseAuthKeyDigest(t *testing.T) {
+	digest := sha256.Sum256([]byte("key"))
+	tests := []struct {
+		name    string
+		value   string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "hex digest", value: " " + strings.ToUpper(hex.EncodeToString(digest[:])) + " ", want: digest[:]},
+		{name: "not hex", value: strings.Repeat("z", 64), wantErr: true},
+		{name: "too short", value: hex.EncodeToString(digest[:16]), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthKeyDigest(tt.value)
+			if (err != nil) != tt.wantErr || !bytes.Equal(got, tt.want) {
+				t.Errorf("ParseAuthKeyDigest() = %x, %v, want %x (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAuthDigest(t *testing.T) {
+	digest := sha256.Sum256([]byte("hashed-key"))
+	tests := []struct {
+		name      string
+		plaintext string
+		digest    []byte
+		token     string
+		want      bool
+	}{
+		{name: "digest, correct token", digest: digest[:], token: "hashed-key", want: true},
+		{name: "digest, wrong token", digest: digest[:], token: "other"},
+		{name: "digest, no token", digest: digest[:]},
+		{name: "both, token matching the digest", plaintext: "plain-key", digest: digest[:], token: "hashed-key", want: true},
+		{name: "both, token matching the plaintext key", plaintext: "plain-key", digest: digest[:], token: "plain-key"},
+		{name: "plaintext only, correct token", plaintext: "plain-key", token: "plain-key", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, tt.plaintext, Options{ClientAuthKeySHA256: tt.digest})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if got := h.validateAuth(req); got != tt.want {
+				t.Errorf("validateAuth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
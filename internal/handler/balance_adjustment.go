@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// balanceAdjustmentParams are the params of the transactions.adjustBalance JSON-RPC method
+type balanceAdjustmentParams struct {
+	Account string `json:"account"`
+	// Balance is the target balance, accepting the same formats as a transaction's value
+	Balance  domain.Amount `json:"balance"`
+	Currency string        `json:"currency"`
+}
+
+// rpcAdjustBalance handles the transactions.adjustBalance JSON-RPC method, creating a
+// single transaction that brings an account's balance to the target
+func (h *HTTPHandler) rpcAdjustBalance(ctx context.Context, w http.ResponseWriter, params map[string]any) int {
+	var adjust balanceAdjustmentParams
+	if reqErr := h.decodeParams(params, &adjust); reqErr != nil {
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	fieldErrors := make(map[string]string)
+	account := strings.TrimSpace(adjust.Account)
+	if account == "" {
+		fieldErrors["account"] = "required"
+	}
+	balance, reason := normalizeAmount(string(adjust.Balance), h.options.CurrencySymbols)
+	if reason != "" {
+		fieldErrors["balance"] = reason
+	}
+	currency := strings.ToUpper(strings.TrimSpace(adjust.Currency))
+	if currency != "" && !isCurrencyCode(currency) {
+		fieldErrors["currency"] = "invalid currency code, expected 3 letters"
+	}
+	if len(fieldErrors) > 0 {
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	adjustment, err := h.service.AdjustBalance(ctx, account, currency, balance)
+	if err != nil {
+		statusCode := statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(errorBody(err))
+		return statusCode
+	}
+
+	// Report an already balanced account rather than posting a zero transaction
+	response := map[string]any{
+		"result":     "ok",
+		"adjustment": adjustment,
+	}
+	if adjustment.Delta == 0 {
+		response["result"] = "balanced"
+		response["message"] = "account is already at the target balance, no transaction created"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	return http.StatusOK
+}
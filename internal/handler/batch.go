@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// maxBatchSize is the most transactions accepted by one transactions.addBatch call
+const maxBatchSize = 100
+
+// batchParams are the params of the transactions.addBatch JSON-RPC method
+type batchParams struct {
+	Transactions []map[string]any `json:"transactions"`
+}
+
+// rpcAddBatch handles the transactions.addBatch JSON-RPC method.
+// Each item is validated and created independently, so the response is 200 with
+// a result per item even when some of them fail.
+func (h *HTTPHandler) rpcAddBatch(ctx context.Context, w http.ResponseWriter, params map[string]any) int {
+	var batch batchParams
+	if reqErr := h.decodeParams(params, &batch); reqErr != nil {
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+	switch {
+	case len(batch.Transactions) == 0:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{"transactions": "required"}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	case len(batch.Transactions) > maxBatchSize:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{
+			"transactions": fmt.Sprintf("at most %d transactions per batch", maxBatchSize),
+		}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	// Validate every item first, only sending the valid ones to the service
+	results, txs, txIndexes := h.validateBatchItems(ctx, batch.Transactions)
+
+	for j, result := range h.service.AddTransactions(ctx, txs) {
+		i := txIndexes[j]
+		results[i] = describeBatchResult(result)
+		results[i].Index = i
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"results": results})
+	return http.StatusOK
+}
+
+// validateBatchItems validates each batch item, returning a result per item with those
+// that failed validation filled in, and the valid transactions with their indexes
+func (h *HTTPHandler) validateBatchItems(ctx context.Context, items []map[string]any) ([]domain.BatchItemResult, []*domain.Transaction, []int) {
+	results := make([]domain.BatchItemResult, len(items))
+	var txs []*domain.Transaction
+	var txIndexes []int
+	for i, item := range items {
+		results[i].Index = i
+		tx, reqErr := h.parseTransactionParams(ctx, item)
+		if reqErr != nil {
+			results[i].Status = reqErr.statusCode
+			results[i].Error = reqErr.message
+			results[i].Errors = reqErr.fields
+			continue
+		}
+		txs = append(txs, tx)
+		txIndexes = append(txIndexes, i)
+	}
+	return results, txs, txIndexes
+}
+
+// describeBatchResult turns the outcome of creating a batch item into its result, without the index
+func describeBatchResult(result service.BatchResult) domain.BatchItemResult {
+	if result.Err != nil {
+		return domain.BatchItemResult{
+			Status: statusForError(result.Err),
+			Error:  result.Err.Error(),
+			Code:   string(service.ErrorCode(result.Err)),
+		}
+	}
+	return domain.BatchItemResult{Status: http.StatusOK, Result: "ok", ID: result.ID}
+}
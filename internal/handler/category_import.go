@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// maxCategoryImportSize is the most categories accepted by one categories.import call
+const maxCategoryImportSize = 100
+
+// categoryPathSeparator separates the titles of a category path, e.g. "Food > Groceries"
+const categoryPathSeparator = ">"
+
+// categoryImportParams are the params of the categories.import JSON-RPC method
+type categoryImportParams struct {
+	Categories []string `json:"categories"`
+}
+
+// rpcImportCategories handles the categories.import JSON-RPC method.
+// Like transactions.addBatch, the response is 200 with a result per item even
+// when some of them fail.
+func (h *HTTPHandler) rpcImportCategories(ctx context.Context, w http.ResponseWriter, params map[string]any) int {
+	var imports categoryImportParams
+	if reqErr := h.decodeParams(params, &imports); reqErr != nil {
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+	switch {
+	case len(imports.Categories) == 0:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{"categories": "required"}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	case len(imports.Categories) > maxCategoryImportSize:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{
+			"categories": fmt.Sprintf("at most %d categories per import", maxCategoryImportSize),
+		}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	// Validate every path first, only sending the valid ones to the service
+	results := make([]domain.BatchItemResult, len(imports.Categories))
+	var paths [][]string
+	var pathIndexes []int
+	for i, item := range imports.Categories {
+		results[i].Index = i
+		path, ok := parseCategoryPath(item)
+		if !ok {
+			results[i].Status = http.StatusUnprocessableEntity
+			results[i].Errors = map[string]string{"title": "must not be empty"}
+			continue
+		}
+		paths = append(paths, path)
+		pathIndexes = append(pathIndexes, i)
+	}
+
+	imported, err := h.service.ImportCategories(ctx, paths)
+	if err != nil {
+		statusCode := statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(errorBody(err))
+		return statusCode
+	}
+
+	for j, result := range imported {
+		i := pathIndexes[j]
+		if result.Err != nil {
+			results[i].Status = statusForError(result.Err)
+			results[i].Error = result.Err.Error()
+			continue
+		}
+		results[i].Status = http.StatusOK
+		results[i].Result = "skipped"
+		if result.Created {
+			results[i].Result = "created"
+		}
+		results[i].ID = result.ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"results": results})
+	return http.StatusOK
+}
+
+// parseCategoryPath splits a category path into its titles, reporting false when any is empty
+func parseCategoryPath(value string) ([]string, bool) {
+	path := strings.Split(value, categoryPathSeparator)
+	for i, title := range path {
+		path[i] = strings.TrimSpace(title)
+		if path[i] == "" {
+			return nil, false
+		}
+	}
+	return path, true
+}
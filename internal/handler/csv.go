@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// mediaTypeCSV is the Accept media type that asks the categories and accounts lists for CSV
+const mediaTypeCSV = "text/csv"
+
+// wantsCSV reports whether the client asked for CSV instead of JSON
+func wantsCSV(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		if strings.EqualFold(mediaType, mediaTypeCSV) {
+			return true
+		}
+	}
+	return false
+}
+
+// csvBody renders a list payload as CSV with a header row, quoting fields as needed
+func csvBody(payload any) ([]byte, error) {
+	var rows [][]string
+	switch list := payload.(type) {
+	case []string:
+		rows = append(rows, []string{"title"})
+		for _, title := range list {
+			rows = append(rows, []string{csvText(title)})
+		}
+	case []domain.Category:
+		rows = append(rows, []string{"id", "title", "parent_id"})
+		rows = appendCategoryRows(rows, list)
+	case []domain.AccountInfo:
+		rows = append(rows, []string{"name", "currency", "type"})
+		for _, account := range list {
+			rows = append(rows, []string{csvText(account.Name), account.Currency, account.Type})
+		}
+	case []domain.TransactionAccount:
+		rows = append(rows, []string{"id", "name", "currency", "type", "current_balance", "institution"})
+		for _, account := range list {
+			institution := ""
+			if account.Institution != nil {
+				institution = account.Institution.Title
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(account.ID), csvText(account.Name), account.CurrencyCode, account.Type,
+				strconv.FormatFloat(account.CurrentBalance, 'f', -1, 64), csvText(institution),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("no CSV format for %T", payload)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// appendCategoryRows flattens a category tree into rows, each parent before its children
+func appendCategoryRows(rows [][]string, categories []domain.Category) [][]string {
+	for _, category := range categories {
+		parentID := ""
+		if category.ParentID != nil {
+			parentID = strconv.Itoa(*category.ParentID)
+		}
+		rows = append(rows, []string{strconv.Itoa(category.ID), csvText(category.Title), parentID})
+		rows = appendCategoryRows(rows, category.Children)
+	}
+	return rows
+}
+
+// csvText guards a free-text cell against formula injection: a spreadsheet would run a
+// cell starting with =, +, -, @, a tab or a carriage return as a formula, so those get a
+// leading ' that makes it plain text. Numeric cells are left alone, since a negative
+// number must stay one.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestCSVBody(t *testing.T) {
+	parentID := 1
+	tests := []struct {
+		name    string
+		payload any
+		want    string
+	}{
+		{
+			name:    "titles with a comma and a quote",
+			payload: []string{"Food, Drink", `Say "hi"`},
+			want:    "title\n\"Food, Drink\"\n\"Say \"\"hi\"\"\"\n",
+		},
+		{
+			name:    "accounts",
+			payload: []domain.AccountInfo{{Name: "Wallet, Cash", Currency: "USD", Type: "cash"}},
+			want:    "name,currency,type\n\"Wallet, Cash\",USD,cash\n",
+		},
+		{
+			name: "category tree",
+			payload: []domain.Category{{ID: 1, Title: "Food", Children: []domain.Category{
+				{ID: 2, Title: "Groceries", ParentID: &parentID},
+			}}},
+			want: "id,title,parent_id\n1,Food,\n2,Groceries,1\n",
+		},
+		{
+			name:    "formulas neutralized",
+			payload: []string{"=HYPERLINK(\"http://evil\")", "+1", "-1", "@SUM(A1)", "\tTab", "Plain - text"},
+			want:    "title\n\"'=HYPERLINK(\"\"http://evil\"\")\"\n'+1\n'-1\n'@SUM(A1)\n'\tTab\nPlain - text\n",
+		},
+		{
+			name: "negative balance kept numeric",
+			payload: []domain.TransactionAccount{{
+				ID: 3, Name: "-Overdraft", CurrencyCode: "aud", Type: "bank", CurrentBalance: -12.5,
+				Institution: &domain.Institution{Title: "=Bank"},
+			}},
+			want: "id,name,currency,type,current_balance,institution\n3,'-Overdraft,aud,bank,-12.5,'=Bank\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvBody(tt.payload)
+			if err != nil {
+				t.Fatalf("csvBody() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("csvBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := csvBody(42); err == nil {
+		t.Error("csvBody(42) error = nil")
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParseAllowedCurrencies parses a comma-separated list of ISO 4217 currency codes.
+// Codes are uppercased and sorted; an empty list means any currency is allowed.
+func ParseAllowedCurrencies(value string) ([]string, error) {
+	var currencies []string
+	for _, currency := range strings.Split(value, ",") {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if currency == "" {
+			continue
+		}
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("invalid currency code %q: expected 3 letters", currency)
+		}
+		if !slices.Contains(currencies, currency) {
+			currencies = append(currencies, currency)
+		}
+	}
+
+	sort.Strings(currencies)
+	return currencies, nil
+}
+
+// currencyAllowed reports whether an uppercase currency code is in the allowlist.
+// Every code is allowed when the allowlist is empty.
+func currencyAllowed(currency string, allowed []string) bool {
+	return len(allowed) == 0 || slices.Contains(allowed, currency)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultCurrencySymbols are stripped from amounts when no custom list is configured
+var DefaultCurrencySymbols = []string{
+	"$", "€", "£", "¥", "₹", "₽", "₩", "₪", "₺", "₴", "₫", "₱", "฿",
+	"R$", "US$", "A$", "C$", "NZ$", "HK$", "CHF", "kr", "zł",
+}
+
+// symbolCurrencies maps the default symbols to the ISO 4217 codes they stand for.
+// Symbols shared by several currencies list each of them; custom symbols aren't listed,
+// so they never conflict with the currency param.
+var symbolCurrencies = map[string][]string{
+	"$":   {"USD", "AUD", "CAD", "NZD", "HKD", "SGD", "TWD", "MXN", "ARS", "CLP", "COP", "UYU", "BSD", "BBD", "BMD", "BND", "BZD", "FJD", "GYD", "JMD", "KYD", "LRD", "NAD", "SBD", "SRD", "TTD", "XCD"},
+	"€":   {"EUR"},
+	"£":   {"GBP", "EGP", "FKP", "GIP", "SHP"},
+	"¥":   {"JPY", "CNY"},
+	"₹":   {"INR"},
+	"₽":   {"RUB"},
+	"₩":   {"KRW", "KPW"},
+	"₪":   {"ILS"},
+	"₺":   {"TRY"},
+	"₴":   {"UAH"},
+	"₫":   {"VND"},
+	"₱":   {"PHP"},
+	"฿":   {"THB"},
+	"R$":  {"BRL"},
+	"US$": {"USD"},
+	"A$":  {"AUD"},
+	"C$":  {"CAD", "NIO"},
+	"NZ$": {"NZD"},
+	"HK$": {"HKD"},
+	"CHF": {"CHF"},
+	"kr":  {"SEK", "NOK", "DKK", "ISK"},
+	"zł":  {"PLN"},
+}
+
+// SymbolMismatchMode selects what happens to an amount whose currency symbol conflicts
+// with the currency param, e.g. "$12.50" in EUR
+type SymbolMismatchMode string
+
+const (
+	// SymbolMismatchWarn logs the conflict and accepts the amount (default)
+	SymbolMismatchWarn SymbolMismatchMode = "warn"
+	// SymbolMismatchReject fails the amount's field with a 422
+	SymbolMismatchReject SymbolMismatchMode = "reject"
+)
+
+// ParseSymbolMismatchMode parses a symbol mismatch mode, defaulting to warn when empty
+func ParseSymbolMismatchMode(value string) (SymbolMismatchMode, error) {
+	switch mode := SymbolMismatchMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return SymbolMismatchWarn, nil
+	case SymbolMismatchWarn, SymbolMismatchReject:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown symbol mismatch mode: %s", value)
+	}
+}
+
+// ParseCurrencySymbols parses a comma-separated list of currency symbols,
+// falling back to DefaultCurrencySymbols when the list is empty.
+// Symbols are returned longest first so "R$" is stripped before "$".
+func ParseCurrencySymbols(value string) ([]string, error) {
+	var symbols []string
+	for _, symbol := range strings.Split(value, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+		// A symbol overlapping numeric content would mangle amounts
+		if strings.ContainsAny(symbol, "0123456789.,+-") {
+			return nil, fmt.Errorf("invalid currency symbol %q: must not contain digits, separators, or signs", symbol)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	if len(symbols) == 0 {
+		symbols = append(symbols, DefaultCurrencySymbols...)
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return len(symbols[i]) > len(symbols[j])
+	})
+	return symbols, nil
+}
+
+// stripCurrencySymbols removes a currency symbol and surrounding whitespace from
+// either end of an amount, keeping a leading sign, e.g. "-$5.50" becomes "-5.50"
+func stripCurrencySymbols(amount string, symbols []string) string {
+	amount, _ = cutCurrencySymbols(amount, symbols)
+	return amount
+}
+
+// cutCurrencySymbols strips an amount like stripCurrencySymbols, also returning the
+// symbols it removed
+func cutCurrencySymbols(amount string, symbols []string) (string, []string) {
+	amount = strings.TrimSpace(amount)
+
+	var found []string
+	sign, amount := cutSign(amount)
+	for _, symbol := range symbols {
+		if trimmed, ok := strings.CutPrefix(amount, symbol); ok {
+			amount = strings.TrimSpace(trimmed)
+			found = append(found, symbol)
+			break
+		}
+	}
+	for _, symbol := range symbols {
+		if trimmed, ok := strings.CutSuffix(amount, symbol); ok {
+			amount = strings.TrimSpace(trimmed)
+			found = append(found, symbol)
+			break
+		}
+	}
+
+	// The sign may also follow the symbol, e.g. "$-5.50"
+	if sign == "" {
+		sign, amount = cutSign(amount)
+	}
+	return sign + amount, found
+}
+
+// checkSymbolMismatch compares the currency symbols of an amount with the currency it is
+// declared in. A conflict is logged, or with SymbolMismatchReject returned as a reason
+// the amount is invalid; otherwise it returns an empty string.
+func (h *HTTPHandler) checkSymbolMismatch(amount, currency string) string {
+	conflict := h.symbolMismatch(amount, currency)
+	if conflict == "" {
+		return ""
+	}
+	if h.options.OnSymbolMismatch == SymbolMismatchReject {
+		return conflict
+	}
+	log.Printf("Warning: Amount %q: %s", amount, conflict)
+	return ""
+}
+
+// symbolMismatch describes the first currency symbol of an amount that doesn't belong to
+// the currency, or returns an empty string when there is none
+func (h *HTTPHandler) symbolMismatch(amount, currency string) string {
+	_, found := cutCurrencySymbols(amount, h.options.CurrencySymbols)
+	for _, symbol := range found {
+		if codes, known := symbolCurrencies[symbol]; known && !slices.Contains(codes, currency) {
+			return fmt.Sprintf("currency symbol %s doesn't match currency %s", symbol, currency)
+		}
+	}
+	return ""
+}
+
+// cutSign splits a leading sign off an amount
+func cutSign(amount string) (string, string) {
+	if strings.HasPrefix(amount, "-") || strings.HasPrefix(amount, "+") {
+		return amount[:1], strings.TrimSpace(amount[1:])
+	}
+	return "", amount
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseCurrencySymbols(t *testing.T) {
+	symbols, err := ParseCurrencySymbols(" $, R$ ,,kr")
+	if err != nil {
+		t.Fatalf("ParseCurrencySymbols() error = %v", err)
+	}
+	if want := []string{"R$", "kr", "$"}; !reflect.DeepEqual(symbols, want) {
+		t.Errorf("ParseCurrencySymbols() = %v, want %v", symbols, want)
+	}
+
+	defaults, _ := ParseCurrencySymbols("")
+	if len(defaults) != len(DefaultCurrencySymbols) || len(defaults[0]) < len(defaults[len(defaults)-1]) {
+		t.Errorf("ParseCurrencySymbols(\"\") = %v, want the defaults longest first", defaults)
+	}
+
+	for _, value := range []string{"1$", "$.", "-"} {
+		if _, err := ParseCurrencySymbols(value); err == nil {
+			t.Errorf("ParseCurrencySymbols(%q) error = nil", value)
+		}
+	}
+}
+
+func TestCutCurrencySymbols(t *testing.T) {
+	symbols, _ := ParseCurrencySymbols("")
+	tests := []struct {
+		amount    string
+		want      string
+		wantFound []string
+	}{
+		{amount: "12.50", want: "12.50"},
+		{amount: " $12.50 ", want: "12.50", wantFound: []string{"$"}},
+		{amount: "-US$ 5", want: "-5", wantFound: []string{"US$"}},
+		{amount: "$-5", want: "-5", wantFound: []string{"$"}},
+		{amount: "+10 zł", want: "+10", wantFound: []string{"zł"}},
+		{amount: "€5€", want: "5", wantFound: []string{"€", "€"}},
+	}
+	for _, tt := range tests {
+		got, found := cutCurrencySymbols(tt.amount, symbols)
+		if got != tt.want || !reflect.DeepEqual(found, tt.wantFound) {
+			t.Errorf("cutCurrencySymbols(%q) = %q, %v, want %q, %v", tt.amount, got, found, tt.want, tt.wantFound)
+		}
+	}
+}
+
+func TestSymbolMismatch(t *testing.T) {
+	symbols, _ := ParseCurrencySymbols("")
+	h := NewHTTPHandler(nil, "", Options{CurrencySymbols: symbols})
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		{amount: "$12.50", currency: "AUD"},
+		{amount: "12.50", currency: "EUR"},
+		{amount: "$12.50", currency: "EUR", want: "currency symbol $ doesn't match currency EUR"},
+		{amount: "10 kr", currency: "NOK"},
+		{amount: "R$ 10", currency: "USD", want: "currency symbol R$ doesn't match currency USD"},
+	}
+	for _, tt := range tests {
+		if got := h.symbolMismatch(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("symbolMismatch(%q, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseSymbolMismatchMode(t *testing.T) {
+	tests := map[string]SymbolMismatchMode{"": SymbolMismatchWarn, " Reject ": SymbolMismatchReject, "warn": SymbolMismatchWarn}
+	for value, want := range tests {
+		if got, err := ParseSymbolMismatchMode(value); err != nil || got != want {
+			t.Errorf("ParseSymbolMismatchMode(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseSymbolMismatchMode("ignore"); err == nil {
+		t.Error("ParseSymbolMismatchMode(ignore) error = nil")
+	}
+}
+
+func TestValidateTransactionParamsSymbolMismatch(t *testing.T) {
+	symbols, _ := ParseCurrencySymbols("")
+	tests := []struct {
+		name          string
+		mode          SymbolMismatchMode
+		value         string
+		foreignAmount string
+		currency      string
+		want          map[string]string
+	}{
+		{name: "matching symbol", mode: SymbolMismatchReject, value: "$12.50", currency: "USD"},
+		{name: "no symbol", mode: SymbolMismatchReject, value: "12.50", currency: "EUR"},
+		{name: "conflict warned", mode: SymbolMismatchWarn, value: "$12.50", currency: "EUR"},
+		{name: "conflict rejected", mode: SymbolMismatchReject, value: "$12.50", currency: "EUR",
+			want: map[string]string{"value": "currency symbol $ doesn't match currency EUR"}},
+		{name: "conflict in the foreign amount", mode: SymbolMismatchReject, value: "-20.00", foreignAmount: "£10", currency: "EUR",
+			want: map[string]string{"foreign_amount": "currency symbol £ doesn't match currency EUR"}},
+		{name: "value not checked against a foreign currency", mode: SymbolMismatchReject, value: "$-20.00", foreignAmount: "€10", currency: "EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&zoneService{}, "", Options{CurrencySymbols: symbols, OnSymbolMismatch: tt.mode})
+			params := domain.TransactionParams{
+				Account:       "Everyday",
+				Category:      "Groceries",
+				Merchant:      "Corner Store",
+				Value:         domain.Amount(tt.value),
+				ForeignAmount: domain.Amount(tt.foreignAmount),
+				Currency:      tt.currency,
+				Date:          "2025-01-13",
+			}
+
+			_, fieldErrors := h.validateTransactionParams(context.Background(), params)
+			if len(fieldErrors) == 0 {
+				fieldErrors = nil
+			}
+			if !reflect.DeepEqual(fieldErrors, tt.want) {
+				t.Errorf("validateTransactionParams() field errors = %v, want %v", fieldErrors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// checkDuplicateKeys rejects a JSON body in which any object repeats a key, since the
+// decoder would silently keep the last value. Only checked in strict mode. The key is named
+// by its path below params, e.g. "value" or "transactions[1].value", or from the top of the
+// body for envelope keys such as "method".
+func (h *HTTPHandler) checkDuplicateKeys(body []byte) *requestError {
+	if !h.options.StrictParams {
+		return nil
+	}
+
+	path, found := duplicateKey(json.NewDecoder(bytes.NewReader(body)), "")
+	if !found {
+		return nil
+	}
+	return &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{
+		strings.TrimPrefix(path, "params."): "duplicate key",
+	}}
+}
+
+// duplicateKey walks the next JSON value from decoder, returning the path of the first key
+// repeated within an object. Malformed JSON reports no duplicate, leaving the error to the
+// regular decoding.
+func duplicateKey(decoder *json.Decoder, path string) (string, bool) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", false
+	}
+
+	switch token {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return "", false
+			}
+			key, _ := keyToken.(string)
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if seen[key] {
+				return keyPath, true
+			}
+			seen[key] = true
+			if duplicate, found := duplicateKey(decoder, keyPath); found {
+				return duplicate, true
+			}
+		}
+		decoder.Token() // closing brace
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if duplicate, found := duplicateKey(decoder, fmt.Sprintf("%s[%d]", path, i)); found {
+				return duplicate, true
+			}
+		}
+		decoder.Token() // closing bracket
+	}
+	return "", false
+}
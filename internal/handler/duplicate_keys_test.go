@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantPath  string
+		wantFound bool
+	}{
+		{name: "no duplicates", body: `{"method": "transactions.add", "params": {"value": "10", "merchant": "Corner Store"}}`},
+		{name: "same key in sibling objects", body: `{"params": {"transactions": [{"value": "10"}, {"value": "20"}]}}`},
+		{name: "in params", body: `{"method": "transactions.add", "params": {"value": "10", "value": "20"}}`, wantPath: "params.value", wantFound: true},
+		{name: "at the top level", body: `{"method": "transactions.add", "method": "transactions.addBatch", "params": {}}`, wantPath: "method", wantFound: true},
+		{name: "in a batch item", body: `{"params": {"transactions": [{"value": "10"}, {"value": "10", "value": "20"}]}}`, wantPath: "params.transactions[1].value", wantFound: true},
+		{name: "malformed before the duplicate", body: `{"params": {"value": "10",, "value": "20"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, found := duplicateKey(json.NewDecoder(bytes.NewReader([]byte(tt.body))), "")
+			if path != tt.wantPath || found != tt.wantFound {
+				t.Errorf("duplicateKey() = %q, %v, want %q, %v", path, found, tt.wantPath, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestParseRPCRequestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		body       string
+		wantFields map[string]string
+	}{
+		{name: "in params", strict: true, body: `{"method": "transactions.add", "params": {"value": "10", "value": "20"}}`,
+			wantFields: map[string]string{"value": "duplicate key"}},
+		{name: "at the top level", strict: true, body: `{"method": "transactions.add", "method": "transactions.add", "params": {}}`,
+			wantFields: map[string]string{"method": "duplicate key"}},
+		{name: "in a batch item", strict: true, body: `{"method": "transactions.addBatch", "params": {"transactions": [{"value": "10", "value": "20"}]}}`,
+			wantFields: map[string]string{"transactions[0].value": "duplicate key"}},
+		{name: "last value kept without strict params", body: `{"method": "transactions.add", "params": {"value": "10", "value": "20"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "key", Options{StrictParams: tt.strict})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/append", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("Content-Type", "application/json")
+
+			_, reqErr := h.parseRPCRequest(req)
+			if tt.wantFields == nil {
+				if reqErr != nil {
+					t.Fatalf("parseRPCRequest() error = %+v, want none", reqErr)
+				}
+				return
+			}
+			if reqErr == nil {
+				t.Fatal("parseRPCRequest() error = nil, want the duplicate key rejected")
+			}
+			if reqErr.statusCode != http.StatusUnprocessableEntity || !reflect.DeepEqual(reqErr.fields, tt.wantFields) {
+				t.Errorf("parseRPCRequest() error = %d %v, want 422 %v", reqErr.statusCode, reqErr.fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// mediaTypeV2 is the Accept media type that opts into the unified response envelope
+const mediaTypeV2 = "application/vnd.proxy.v2+json"
+
+// wantsV2Envelope reports whether the client asked for the unified v2 envelope
+func wantsV2Envelope(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		if strings.EqualFold(mediaType, mediaTypeV2) {
+			return true
+		}
+	}
+	return false
+}
+
+// envelopeBody builds a list response body and its content type.
+// v2 clients always get the payload under "data"; legacy clients get it under legacyKey.
+// Payloads built from a capped list are flagged with "truncated": true.
+func envelopeBody(ctx context.Context, r *http.Request, legacyKey string, payload any) ([]byte, string, error) {
+	key := legacyKey
+	contentType := "application/json"
+	if wantsV2Envelope(r) {
+		key = "data"
+		contentType = mediaTypeV2
+	}
+
+	envelope := map[string]any{
+		key: payload,
+	}
+	if service.Truncated(ctx) {
+		envelope["truncated"] = true
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, "", err
+	}
+	return append(body, '\n'), contentType, nil
+}
+
+// writeEnvelope writes a successful list response
+func writeEnvelope(ctx context.Context, w http.ResponseWriter, r *http.Request, legacyKey string, payload any) {
+	body, contentType, err := envelopeBody(ctx, r, legacyKey, payload)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
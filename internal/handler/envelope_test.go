@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// listService answers the list endpoints with fixed entities, each cached for ttl, or of
+// unknown TTL when ttlErr is set.
+// Methods it doesn't override panic through the nil embedded service.
+type listService struct {
+	service.TransactionService
+	categories []string
+	ttl        time.Duration
+	ttlErr     error
+}
+
+func newListService() *listService {
+	return &listService{categories: []string{"Groceries", "Salary"}, ttl: time.Hour}
+}
+
+func (s *listService) GetCategories(ctx context.Context) ([]string, error) {
+	return s.categories, nil
+}
+
+func (s *listService) SearchCategories(ctx context.Context, query string, limit int) ([]domain.CategoryMatch, error) {
+	return []domain.CategoryMatch{{ID: 20, Title: "Groceries", Path: []string{"Groceries"}}}, nil
+}
+
+func (s *listService) GetAccounts(ctx context.Context, types []string) ([]domain.AccountInfo, error) {
+	return []domain.AccountInfo{{Name: "Everyday", Currency: "AUD", Type: "bank"}}, nil
+}
+
+func (s *listService) GetAccountDetails(ctx context.Context, types []string) ([]domain.TransactionAccount, error) {
+	return []domain.TransactionAccount{{
+		ID: 10, Name: "Everyday", Type: "bank", CurrencyCode: "aud", IsNetWorth: true, CurrentBalance: 1234.5,
+		CurrentBalanceDate: "2025-01-13", Institution: &domain.Institution{ID: 5, Title: "Big Bank"},
+	}}, nil
+}
+
+func (s *listService) GetShortcutEntities(ctx context.Context) (*domain.ShortcutEntities, error) {
+	return &domain.ShortcutEntities{
+		Accounts:   []domain.AccountInfo{{Name: "Everyday", Currency: "AUD", Type: "bank"}},
+		Categories: s.categories,
+	}, nil
+}
+
+func (s *listService) GetCacheTTL(ctx context.Context, entity domain.CacheEntity) (time.Duration, error) {
+	return s.ttl, s.ttlErr
+}
+
+// listEndpoint is a list handler along with a request it answers
+type listEndpoint struct {
+	target string
+	handle func(h *HTTPHandler, ctx context.Context, w http.ResponseWriter, r *http.Request)
+}
+
+var listEndpoints = map[string]listEndpoint{
+	"categories":        {target: "/api/v1/categories", handle: (*HTTPHandler).handleGetCategories},
+	"category search":   {target: "/api/v1/categories/search?q=groc", handle: (*HTTPHandler).handleSearchCategories},
+	"accounts":          {target: "/api/v1/accounts", handle: (*HTTPHandler).handleGetAccounts},
+	"shortcut entities": {target: "/api/v1/shortcut_entities", handle: (*HTTPHandler).handleGetShortcutEntities},
+}
+
+// serveList sends an authorized GET to a list endpoint with the given extra headers
+func serveList(h *HTTPHandler, ctx context.Context, endpoint string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, listEndpoints[endpoint].target, nil)
+	req.Header.Set("Authorization", "Bearer key")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	listEndpoints[endpoint].handle(h, ctx, recorder, req)
+	return recorder
+}
+
+func TestEnvelopeShapes(t *testing.T) {
+	const (
+		categories = `["Groceries","Salary"]`
+		matches    = `[{"id":20,"title":"Groceries","path":["Groceries"]}]`
+		accounts   = `[{"name":"Everyday","currency":"AUD","type":"bank"}]`
+		entities   = `{"accounts":` + accounts + `,"categories":` + categories + `}`
+	)
+	tests := []struct {
+		endpoint        string
+		accept          string
+		wantBody        string
+		wantContentType string
+	}{
+		{endpoint: "categories", wantBody: `{"items":` + categories + `}`, wantContentType: "application/json"},
+		{endpoint: "categories", accept: mediaTypeV2, wantBody: `{"data":` + categories + `}`, wantContentType: mediaTypeV2},
+		{endpoint: "category search", wantBody: `{"items":` + matches + `}`, wantContentType: "application/json"},
+		{endpoint: "category search", accept: mediaTypeV2, wantBody: `{"data":` + matches + `}`, wantContentType: mediaTypeV2},
+		{endpoint: "accounts", wantBody: `{"items":` + accounts + `}`, wantContentType: "application/json"},
+		{endpoint: "accounts", accept: "application/json, " + mediaTypeV2 + "; q=0.9", wantBody: `{"data":` + accounts + `}`, wantContentType: mediaTypeV2},
+		{endpoint: "shortcut entities", wantBody: `{"data":` + entities + `}`, wantContentType: "application/json"},
+		{endpoint: "shortcut entities", accept: mediaTypeV2, wantBody: `{"data":` + entities + `}`, wantContentType: mediaTypeV2},
+	}
+	for _, tt := range tests {
+		name := tt.endpoint + " legacy"
+		if tt.accept != "" {
+			name = tt.endpoint + " v2"
+		}
+		t.Run(name, func(t *testing.T) {
+			h := NewHTTPHandler(newListService(), "key", Options{})
+			header := http.Header{}
+			if tt.accept != "" {
+				header.Set("Accept", tt.accept)
+			}
+
+			recorder := serveList(h, context.Background(), tt.endpoint, header)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+			}
+			if got := strings.TrimSpace(recorder.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestGetAccountsFull(t *testing.T) {
+	const (
+		lean = `{"items":[{"name":"Everyday","currency":"AUD","type":"bank"}]}`
+		full = `{"items":[{"id":10,"name":"Everyday","type":"bank","currency_code":"aud","is_net_worth":true,` +
+			`"current_balance":1234.5,"current_balance_date":"2025-01-13","institution":{"id":5,"title":"Big Bank"}}]}`
+	)
+	tests := []struct {
+		query    string
+		wantBody string
+	}{
+		{query: "", wantBody: lean},
+		{query: "?full=false", wantBody: lean},
+		{query: "?full=true", wantBody: full},
+		{query: "?full=1&type=bank", wantBody: full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			h := NewHTTPHandler(newListService(), "key", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer key")
+			recorder := httptest.NewRecorder()
+
+			h.handleGetAccounts(context.Background(), recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+			}
+			if got := strings.TrimSpace(recorder.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// writeCacheableEnvelope writes a list response with Cache-Control and ETag headers,
+// as CSV when the client accepts text/csv.
+// It answers 304 Not Modified when the client's copy is still current, and returns the status written.
+func (h *HTTPHandler) writeCacheableEnvelope(ctx context.Context, w http.ResponseWriter, r *http.Request, legacyKey string, payload any, entity domain.CacheEntity) int {
+	var body []byte
+	var contentType string
+	var err error
+	if wantsCSV(r) {
+		body, err = csvBody(payload)
+		contentType = mediaTypeCSV + "; charset=utf-8"
+	} else {
+		body, contentType, err = envelopeBody(ctx, r, legacyKey, payload)
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	etag := computeETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", h.cacheControl(ctx, entity))
+	w.Header().Set("Vary", "Accept")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return http.StatusOK
+}
+
+// setCacheStatusHeader reports in X-Cache whether the response was served from the
+// proxy's cache (HIT), fetched from PocketSmith (MISS), or a mix of both (PARTIAL)
+func setCacheStatusHeader(ctx context.Context, w http.ResponseWriter) {
+	if status := service.CacheStatus(ctx); status != "" {
+		w.Header().Set("X-Cache", status)
+	}
+}
+
+// cacheControl lets clients keep the response for as long as the underlying data stays cached
+func (h *HTTPHandler) cacheControl(ctx context.Context, entity domain.CacheEntity) string {
+	ttl, err := h.service.GetCacheTTL(ctx, entity)
+	if err != nil {
+		log.Printf("Warning: Failed to get cache TTL for %s, clients must revalidate: %v", entity, err)
+		return "private, no-cache"
+	}
+	return fmt.Sprintf("private, max-age=%d", int(ttl.Seconds()))
+}
+
+// computeETag returns a strong ETag for a response body
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestComputeETag(t *testing.T) {
+	etag := computeETag([]byte(`{"items":["Groceries"]}`))
+	if len(etag) != 34 || etag[0] != '"' || etag[33] != '"' {
+		t.Errorf("computeETag() = %s, want 32 quoted hex digits", etag)
+	}
+	if again := computeETag([]byte(`{"items":["Groceries"]}`)); again != etag {
+		t.Errorf("computeETag() of the same body = %s, want %s", again, etag)
+	}
+	if changed := computeETag([]byte(`{"items":["Groceries","Salary"]}`)); changed == etag {
+		t.Errorf("computeETag() of a changed body = %s, want a new ETag", changed)
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{ifNoneMatch: `"abc"`, want: true},
+		{ifNoneMatch: `W/"abc"`, want: true},
+		{ifNoneMatch: `"old", "abc"`, want: true},
+		{ifNoneMatch: `*`, want: true},
+		{ifNoneMatch: `"old"`},
+		{ifNoneMatch: `abc`},
+		{ifNoneMatch: ``},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCacheableEnvelope(t *testing.T) {
+	svc := newListService()
+	h := NewHTTPHandler(svc, "key", Options{})
+	first := serveList(h, context.Background(), "categories", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first response = %d with ETag %q, want 200 with an ETag", first.Code, etag)
+	}
+	if got := first.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+
+	tests := []struct {
+		name             string
+		endpoint         string
+		categories       []string
+		ttlErr           error
+		header           http.Header
+		wantStatus       int
+		wantSameETag     bool
+		wantCacheControl string
+	}{
+		{
+			name:       "matching If-None-Match",
+			endpoint:   "categories",
+			header:     http.Header{"If-None-Match": {etag}},
+			wantStatus: http.StatusNotModified, wantSameETag: true, wantCacheControl: "private, max-age=3600",
+		},
+		{
+			name:       "stale If-None-Match",
+			endpoint:   "categories",
+			header:     http.Header{"If-None-Match": {`"stale"`}},
+			wantStatus: http.StatusOK, wantSameETag: true, wantCacheControl: "private, max-age=3600",
+		},
+		{
+			name:       "changed body",
+			endpoint:   "categories",
+			categories: []string{"Groceries", "Rent", "Salary"},
+			header:     http.Header{"If-None-Match": {etag}},
+			wantStatus: http.StatusOK, wantCacheControl: "private, max-age=3600",
+		},
+		{
+			name:       "other envelope",
+			endpoint:   "categories",
+			header:     http.Header{"If-None-Match": {etag}, "Accept": {mediaTypeV2}},
+			wantStatus: http.StatusOK, wantCacheControl: "private, max-age=3600",
+		},
+		{
+			name:       "unknown TTL",
+			endpoint:   "categories",
+			ttlErr:     errors.New("redis unavailable"),
+			wantStatus: http.StatusOK, wantSameETag: true, wantCacheControl: "private, no-cache",
+		},
+		{
+			name:       "accounts",
+			endpoint:   "accounts",
+			wantStatus: http.StatusOK, wantCacheControl: "private, max-age=3600",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newListService()
+			if tt.categories != nil {
+				svc.categories = tt.categories
+			}
+			svc.ttlErr = tt.ttlErr
+			h := NewHTTPHandler(svc, "key", Options{})
+
+			recorder := serveList(h, context.Background(), tt.endpoint, tt.header)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotModified && recorder.Body.Len() > 0 {
+				t.Errorf("304 body = %s, want none", recorder.Body)
+			}
+			got := recorder.Header().Get("ETag")
+			if got == "" || (got == etag) != tt.wantSameETag {
+				t.Errorf("ETag = %s, want same as the first (%s): %v", got, etag, tt.wantSameETag)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+			}
+		})
+	}
+
+	// The max-age counts down with the cached data's remaining TTL
+	svc.ttl = 90*time.Second + 500*time.Millisecond
+	if got := serveList(h, context.Background(), "categories", nil).Header().Get("Cache-Control"); got != "private, max-age=90" {
+		t.Errorf("Cache-Control = %q, want private, max-age=90", got)
+	}
+}
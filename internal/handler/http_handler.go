@@ -1,96 +1,353 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pocketsmith-proxy/internal/clock"
 	"github.com/pocketsmith-proxy/internal/domain"
 	"github.com/pocketsmith-proxy/internal/service"
 )
 
+// defaultSearchLimit caps search results when the client doesn't pass a limit
+const defaultSearchLimit = 10
+
+// categoriesPathPrefix is the path of the single category route, followed by the category ID
+const categoriesPathPrefix = "/api/v1/categories/"
+
+// statusClientClosedRequest is the non-standard status (from nginx) logged for requests
+// abandoned because the client disconnected
+const statusClientClosedRequest = 499
+
+// Options holds optional handler behaviors
+type Options struct {
+	// TitleCaseMerchant title-cases each word of the merchant name
+	TitleCaseMerchant bool
+	// RequestTimeout bounds the whole request handling (0 disables)
+	RequestTimeout time.Duration
+	// AuthHeaderMode selects where the client auth key is read from
+	AuthHeaderMode AuthHeaderMode
+	// ClientAuthKeySHA256 is the SHA-256 digest of the client auth key, checked instead of the plaintext key when set
+	ClientAuthKeySHA256 []byte
+	// CurrencySymbols are stripped from either end of amounts, longest first
+	CurrencySymbols []string
+	// OnSymbolMismatch selects what happens when an amount's currency symbol conflicts
+	// with the currency param (empty warns)
+	OnSymbolMismatch SymbolMismatchMode
+	// StrictParams rejects unknown fields in transaction params
+	StrictParams bool
+	// ParamAliases maps alternative transaction param names to the params they stand for
+	ParamAliases map[string]string
+	// MaxDateAgeDays rejects transactions dated more than this many days ago (0 disables)
+	MaxDateAgeDays int
+	// MaxDateAheadDays rejects transactions dated more than this many days ahead (0 disables)
+	MaxDateAheadDays int
+	// CategoryOptional accepts transactions without a category, for the service to derive one
+	CategoryOptional bool
+	// MaxAmountDecimals rejects amounts with more fractional digits than this (0 allows any)
+	MaxAmountDecimals int
+	// RoundAmount rounds amounts with too many fractional digits to MaxAmountDecimals
+	// instead of rejecting them
+	RoundAmount bool
+	// AllowedCurrencies restricts the currency param to these uppercase codes (empty allows any)
+	AllowedCurrencies []string
+	// SlowRequestThreshold logs a warning for requests taking longer than this (0 disables)
+	SlowRequestThreshold time.Duration
+	// DebugRoutes lists the known routes in the body of a 404 for an unknown route
+	DebugRoutes bool
+	// StrictPaths routes paths exactly as sent, rather than collapsing repeated slashes and
+	// stripping a trailing slash first
+	StrictPaths bool
+	// ServiceName names the service in the GET / descriptor (defaults to DefaultServiceName)
+	ServiceName string
+	// EffectiveConfig is the loaded configuration with secrets redacted, shown by GET /api/v1/config
+	EffectiveConfig map[string]any
+	// Clock tells the time (defaults to the wall clock)
+	Clock clock.Clock
+}
+
 // HTTPHandler handles HTTP requests for the transaction API
 type HTTPHandler struct {
 	service       service.TransactionService
 	clientAuthKey string
+	options       Options
 }
 
 // NewHTTPHandler creates a new HTTP handler
-func NewHTTPHandler(svc service.TransactionService, clientAuthKey string) *HTTPHandler {
+func NewHTTPHandler(svc service.TransactionService, clientAuthKey string, options Options) *HTTPHandler {
+	options.Clock = clock.OrReal(options.Clock)
 	return &HTTPHandler{
 		service:       svc,
 		clientAuthKey: clientAuthKey,
+		options:       options,
 	}
 }
 
 // Handle processes incoming HTTP requests
 func (h *HTTPHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	// Route sloppy paths like "/api/v1/categories/" unless exact matching is wanted.
+	// The request is updated too, since handlers read the path from it.
+	if !h.options.StrictPaths {
+		r.URL.Path = normalizePath(r.URL.Path)
+	}
+
 	method := r.Method
 	path := r.URL.Path
 
+	// Time the whole request, from before routing until the response is written
+	start := h.options.Clock.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
+	w = recorder
+	defer h.logSlowRequest(method, path, recorder, start)
+
+	// Bound the whole chain of PocketSmith calls by the request timeout
+	ctx := r.Context()
+	if h.options.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.options.RequestTimeout)
+		defer cancel()
+	}
+
+	// Scope lookups to this request so each entity is fetched at most once
+	ctx = service.WithRequestCache(ctx)
+
+	// Echo PocketSmith's rate-limit headers so clients can pace themselves
+	w = &rateLimitWriter{ResponseWriter: w, service: h.service}
+
+	// Rewrite string amounts as JSON numbers in whichever response the request gets
+	if wantNumericAmounts(r) {
+		numericWriter := &numericAmountsWriter{ResponseWriter: w}
+		defer numericWriter.finish()
+		w = numericWriter
+	}
+
 	// Route based on path and method
 	switch {
 	case path == "/api/v1/transactions/append" && method == http.MethodPost:
-		h.handleAddTransaction(w, r)
+		h.handleRPC(ctx, w, r)
 	case path == "/api/v1/categories" && method == http.MethodGet:
-		h.handleGetCategories(w, r)
+		h.handleGetCategories(ctx, w, r)
+	case path == "/api/v1/categories/search" && method == http.MethodGet:
+		h.handleSearchCategories(ctx, w, r)
+	case strings.HasPrefix(path, categoriesPathPrefix) && method == http.MethodGet:
+		h.handleGetCategory(ctx, w, r)
+	case path == "/api/v1/transactions" && method == http.MethodGet:
+		h.handleListTransactions(ctx, w, r)
+	case path == "/api/v1/transactions/validate" && method == http.MethodPost:
+		h.handleValidateTransaction(ctx, w, r)
+	case path == "/api/v1/amounts/normalize" && method == http.MethodPost:
+		h.handleNormalizeAmount(w, r)
+	case path == "/api/v1/transactions/search" && method == http.MethodGet:
+		h.handleSearchTransactions(ctx, w, r)
+	case path == "/api/v1/summary" && method == http.MethodGet:
+		h.handleSummary(ctx, w, r)
 	case path == "/api/v1/accounts" && method == http.MethodGet:
-		h.handleGetAccounts(w, r)
+		h.handleGetAccounts(ctx, w, r)
+	case path == "/api/v1/accounts" && method == http.MethodPost:
+		h.handleCreateAccount(ctx, w, r)
 	case path == "/api/v1/shortcut_entities" && method == http.MethodGet:
-		h.handleGetShortcutEntities(w, r)
+		h.handleGetShortcutEntities(ctx, w, r)
+	case path == "/api/v1/cache/refresh" && method == http.MethodPost:
+		h.handleRefreshCache(ctx, w, r)
+	case path == "/api/v1/cache/all" && method == http.MethodDelete:
+		h.handleFlushCache(ctx, w, r)
+	case path == "/api/v1/cache/stats" && method == http.MethodGet:
+		h.handleCacheStats(ctx, w, r)
+	case strings.HasPrefix(path, jobsPathPrefix) && method == http.MethodGet:
+		h.handleGetJob(ctx, w, r)
+	case path == "/api/v1/whoami" && method == http.MethodGet:
+		h.handleWhoAmI(ctx, w, r)
+	case path == "/api/v1/config" && method == http.MethodGet:
+		h.handleConfig(w, r)
+	case path == "/openapi.json" && method == http.MethodGet:
+		h.handleOpenAPI(w, r)
+	case path == "/version" && method == http.MethodGet:
+		h.handleVersion(w, r)
+	case path == "/" && method == http.MethodGet:
+		h.handleRoot(w, r)
 	default:
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintln(w, "Not found")
-		h.logRequest(method, path, http.StatusNotFound)
+		h.handleNotFound(w, method, path)
 	}
 }
 
-// handleAddTransaction handles POST /api/v1/transactions/append
-func (h *HTTPHandler) handleAddTransaction(w http.ResponseWriter, r *http.Request) {
-	var statusCode int
+// rpcAddTransaction handles the transactions.add JSON-RPC method
+func (h *HTTPHandler) rpcAddTransaction(ctx context.Context, w http.ResponseWriter, params map[string]any) int {
+	// Validate and parse params
+	tx, reqErr := h.parseTransactionParams(ctx, params)
+	if reqErr != nil {
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	// Process transaction
+	added, err := h.service.AddTransaction(ctx, tx)
+	if err != nil {
+		statusCode := statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		return statusCode
+	}
+
+	// Success
+	// The ID isn't sent as id, which is reserved for the JSON-RPC request's own id
+	response := map[string]any{"result": "ok", "resolved_account": added.Account}
+	if added.ID != 0 {
+		response["transaction_id"] = added.ID
+	}
+	if added.Category != nil {
+		response["resolved_category"] = added.Category
+	}
+	if added.Duplicate {
+		response["duplicate"] = true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	return http.StatusOK
+}
+
+// handleListTransactions handles GET /api/v1/transactions.
+// Pages are streamed to the client as they arrive from PocketSmith.
+func (h *HTTPHandler) handleListTransactions(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	method := r.Method
 	path := r.URL.Path
+	var statusCode int
 
-	// Validate and parse request
-	tx, statusCode, errMsg := h.validateAndParseRequest(r)
-	if statusCode != http.StatusOK {
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
 		w.WriteHeader(statusCode)
-		fmt.Fprintln(w, errMsg)
+		fmt.Fprintln(w, "Forbidden")
 		h.logRequest(method, path, statusCode)
 		return
 	}
 
-	// Process transaction
-	if err := h.service.AddTransaction(tx); err != nil {
-		// Check if it's a lookup error (return 400) or internal error (return 500)
-		if service.IsLookupError(err) {
-			statusCode = http.StatusBadRequest
-		} else {
-			statusCode = http.StatusInternalServerError
-		}
+	// Validate query params
+	query := r.URL.Query()
+	account, startDate, endDate, fieldErrors := parseTransactionRange(query, 0)
+	if len(fieldErrors) > 0 {
+		statusCode = http.StatusUnprocessableEntity
+		writeRequestError(w, &requestError{statusCode: statusCode, fields: fieldErrors})
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Stream each page as it is fetched
+	stream := newListStream(w, r, "items")
+	err := h.service.ListTransactions(ctx, account, startDate, endDate, func(page []domain.TransactionRecord) error {
+		return writePage(stream, page)
+	})
+	if err != nil && !stream.started {
+		statusCode = statusForError(err)
 		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
 		w.WriteHeader(statusCode)
-		errorResponse := map[string]string{
-			"error": err.Error(),
-		}
+		errorResponse := errorBody(err)
 		json.NewEncoder(w).Encode(errorResponse)
 		h.logRequest(method, path, statusCode)
 		return
 	}
 
-	// Success
+	// The status is already sent, so a failure mid-stream can only be reported in the body
 	statusCode = http.StatusOK
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	fmt.Fprintln(w, `{"result": "ok"}`)
+	if err != nil {
+		log.Printf("ERROR: Transaction list for account '%s' failed after %d transactions: %v", account, stream.count, err)
+		stream.abort(err)
+	} else {
+		stream.close()
+	}
 	h.logRequest(method, path, statusCode)
 }
 
+// parseTransactionRange validates the account and date range query params of a transaction
+// listing, limiting the range to maxDays when not 0
+func parseTransactionRange(query url.Values, maxDays int) (account, startDate, endDate string, fieldErrors map[string]string) {
+	account = strings.TrimSpace(query.Get("account"))
+	startDate, endDate, fieldErrors = parseDateRange(query, maxDays)
+	if account == "" {
+		fieldErrors["account"] = "required"
+	}
+	return account, startDate, endDate, fieldErrors
+}
+
+// parseDateRange validates the start_date and end_date query params, limiting the
+// range to maxDays when not 0
+func parseDateRange(query url.Values, maxDays int) (startDate, endDate string, fieldErrors map[string]string) {
+	startDate = query.Get("start_date")
+	endDate = query.Get("end_date")
+	fieldErrors = make(map[string]string)
+	start, startErr := time.Parse(dateLayout, startDate)
+	end, endErr := time.Parse(dateLayout, endDate)
+	if startErr != nil {
+		fieldErrors["start_date"] = "invalid date, expected YYYY-MM-DD"
+	}
+	if endErr != nil {
+		fieldErrors["end_date"] = "invalid date, expected YYYY-MM-DD"
+	}
+	if startErr == nil && endErr == nil {
+		switch {
+		case end.Before(start):
+			fieldErrors["end_date"] = "must not be before start_date"
+		case maxDays > 0 && end.Sub(start) >= time.Duration(maxDays)*24*time.Hour:
+			fieldErrors["end_date"] = fmt.Sprintf("range must not exceed %d days", maxDays)
+		}
+	}
+	return startDate, endDate, fieldErrors
+}
+
 // handleGetCategories handles GET /api/v1/categories
-func (h *HTTPHandler) handleGetCategories(w http.ResponseWriter, r *http.Request) {
+func (h *HTTPHandler) handleGetCategories(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Rich category objects are opt-in via ?detail=true
+	var categories any
+	var err error
+	if detail, _ := strconv.ParseBool(r.URL.Query().Get("detail")); detail {
+		categories, err = h.service.GetCategoryDetails(ctx)
+	} else {
+		categories, err = h.service.GetCategories(ctx)
+	}
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success response
+	setCacheStatusHeader(ctx, w)
+	statusCode = h.writeCacheableEnvelope(ctx, w, r, "items", categories, domain.CacheEntityCategories)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleSearchCategories handles GET /api/v1/categories/search
+func (h *HTTPHandler) handleSearchCategories(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	method := r.Method
 	path := r.URL.Path
 	var statusCode int
@@ -104,33 +361,96 @@ func (h *HTTPHandler) handleGetCategories(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Get categories from service
-	categories, err := h.service.GetCategories()
+	// Validate query params
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	limit := defaultSearchLimit
+	fieldErrors := make(map[string]string)
+	if query == "" {
+		fieldErrors["q"] = "required"
+	}
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			fieldErrors["limit"] = "must be a positive integer"
+		}
+		limit = parsed
+	}
+	if len(fieldErrors) > 0 {
+		statusCode = http.StatusUnprocessableEntity
+		writeRequestError(w, &requestError{statusCode: statusCode, fields: fieldErrors})
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Search categories
+	matches, err := h.service.SearchCategories(ctx, query, limit)
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = statusForError(err)
 		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success response
+	setCacheStatusHeader(ctx, w)
+	statusCode = http.StatusOK
+	writeEnvelope(ctx, w, r, "items", matches)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleGetCategory handles GET /api/v1/categories/{id}
+func (h *HTTPHandler) handleGetCategory(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
 		w.WriteHeader(statusCode)
-		errorResponse := map[string]string{
-			"error": err.Error(),
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Only positive numeric IDs name a category, anything else is an unknown route
+	id, err := strconv.Atoi(strings.TrimPrefix(path, categoriesPathPrefix))
+	if err != nil || id <= 0 {
+		h.handleNotFound(w, method, path)
+		return
+	}
+
+	// Look up the category
+	category, err := h.service.GetCategory(ctx, id)
+	if err != nil {
+		statusCode = statusForError(err)
+		if service.ErrorCode(err) == service.CodeCategoryNotFound {
+			statusCode = http.StatusNotFound
 		}
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
 		json.NewEncoder(w).Encode(errorResponse)
 		h.logRequest(method, path, statusCode)
 		return
 	}
 
 	// Success response
+	setCacheStatusHeader(ctx, w)
 	statusCode = http.StatusOK
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	response := map[string]interface{}{
-		"items": categories,
-	}
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(category)
 	h.logRequest(method, path, statusCode)
 }
 
 // handleGetAccounts handles GET /api/v1/accounts
-func (h *HTTPHandler) handleGetAccounts(w http.ResponseWriter, r *http.Request) {
+func (h *HTTPHandler) handleGetAccounts(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	method := r.Method
 	path := r.URL.Path
 	var statusCode int
@@ -144,33 +464,92 @@ func (h *HTTPHandler) handleGetAccounts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Get accounts from service
-	accounts, err := h.service.GetAccounts()
+	// Optional comma-separated account type filter, e.g. ?type=bank,credit
+	var types []string
+	for _, accountType := range strings.Split(r.URL.Query().Get("type"), ",") {
+		if accountType = strings.TrimSpace(accountType); accountType != "" {
+			types = append(types, accountType)
+		}
+	}
+
+	// Full account objects are opt-in via ?full=true
+	var accounts any
+	var err error
+	if full, _ := strconv.ParseBool(r.URL.Query().Get("full")); full {
+		accounts, err = h.service.GetAccountDetails(ctx, types)
+	} else {
+		accounts, err = h.service.GetAccounts(ctx, types)
+	}
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = statusForError(err)
 		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
 		w.WriteHeader(statusCode)
-		errorResponse := map[string]string{
-			"error": err.Error(),
-		}
+		errorResponse := errorBody(err)
 		json.NewEncoder(w).Encode(errorResponse)
 		h.logRequest(method, path, statusCode)
 		return
 	}
 
 	// Success response
-	statusCode = http.StatusOK
+	setCacheStatusHeader(ctx, w)
+	statusCode = h.writeCacheableEnvelope(ctx, w, r, "items", accounts, domain.CacheEntityAccounts)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleCreateAccount handles POST /api/v1/accounts
+func (h *HTTPHandler) handleCreateAccount(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Decode and validate the body
+	var params domain.AccountParams
+	reqErr := h.decodeJSONBody(r, &params)
+	if reqErr == nil {
+		if fieldErrors := h.validateAccountParams(&params); len(fieldErrors) > 0 {
+			reqErr = &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
+		}
+	}
+	if reqErr != nil {
+		statusCode = reqErr.statusCode
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Create the account
+	id, err := h.service.CreateAccount(ctx, &params)
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success
+	statusCode = http.StatusCreated
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	response := map[string]interface{}{
-		"items": accounts,
-	}
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]int{"id": id})
 	h.logRequest(method, path, statusCode)
 }
 
 // handleGetShortcutEntities handles GET /api/v1/shortcut_entities
-func (h *HTTPHandler) handleGetShortcutEntities(w http.ResponseWriter, r *http.Request) {
+func (h *HTTPHandler) handleGetShortcutEntities(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	method := r.Method
 	path := r.URL.Path
 	var statusCode int
@@ -185,118 +564,244 @@ func (h *HTTPHandler) handleGetShortcutEntities(w http.ResponseWriter, r *http.R
 	}
 
 	// Get shortcut entities from service
-	entities, err := h.service.GetShortcutEntities()
+	entities, err := h.service.GetShortcutEntities(ctx)
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = statusForError(err)
 		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
 		w.WriteHeader(statusCode)
-		errorResponse := map[string]string{
-			"error": err.Error(),
-		}
+		errorResponse := errorBody(err)
 		json.NewEncoder(w).Encode(errorResponse)
 		h.logRequest(method, path, statusCode)
 		return
 	}
 
 	// Success response
+	setCacheStatusHeader(ctx, w)
 	statusCode = http.StatusOK
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	response := map[string]interface{}{
-		"data": entities,
-	}
-	json.NewEncoder(w).Encode(response)
+	writeEnvelope(ctx, w, r, "data", entities)
 	h.logRequest(method, path, statusCode)
 }
 
-// validateAndParseRequest validates the HTTP request and parses it into a Transaction
-func (h *HTTPHandler) validateAndParseRequest(r *http.Request) (*domain.Transaction, int, string) {
-	// Validate HTTP method is POST
-	if r.Method != http.MethodPost {
-		return nil, http.StatusMethodNotAllowed, "Method not allowed"
+// handleRefreshCache handles POST /api/v1/cache/refresh
+func (h *HTTPHandler) handleRefreshCache(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Validate Content-Type is application/json
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		return nil, http.StatusBadRequest, "Bad request"
+	// Refresh accounts and categories
+	if err := h.service.RefreshAll(ctx); err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Validate Authorization header
-	clientToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-	if h.clientAuthKey != clientToken {
-		log.Println("Invalid client auth")
-		return nil, http.StatusForbidden, "Forbidden"
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	fmt.Fprintln(w, `{"result": "ok"}`)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleFlushCache handles DELETE /api/v1/cache/all, dropping every cached entry
+func (h *HTTPHandler) handleFlushCache(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Read request body
-	body, err := io.ReadAll(r.Body)
+	// Delete the namespace's keys
+	deleted, err := h.service.FlushCache(ctx)
 	if err != nil {
-		return nil, http.StatusBadRequest, "Error reading request body"
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
 	}
-	defer r.Body.Close()
 
-	// Decode JSON body into RPCRequest
-	var rpcReq domain.RPCRequest
-	if err := json.Unmarshal(body, &rpcReq); err != nil {
-		return nil, http.StatusBadRequest, "Bad request"
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]int{"deleted": deleted})
+	h.logRequest(method, path, statusCode)
+}
+
+// handleCacheStats handles GET /api/v1/cache/stats, showing the cumulative cache hits and misses
+func (h *HTTPHandler) handleCacheStats(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Validate method field equals 'transactions.add'
-	if rpcReq.Method != "transactions.add" {
-		return nil, http.StatusBadRequest, "Bad request"
+	stats, err := h.service.GetCacheStats(ctx)
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Validate params contains required fields for transactions.add
-	if rpcReq.Params == nil {
-		return nil, http.StatusBadRequest, "Bad request"
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(stats)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleWhoAmI handles GET /api/v1/whoami, showing which PocketSmith user the API key belongs to
+func (h *HTTPHandler) handleWhoAmI(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Convert params map to TransactionParams to validate structure
-	paramsJSON, err := json.Marshal(rpcReq.Params)
+	// Fetch the user behind the API key
+	user, err := h.service.WhoAmI(ctx)
 	if err != nil {
-		return nil, http.StatusBadRequest, "Bad request"
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	var txParams domain.TransactionParams
-	if err := json.Unmarshal(paramsJSON, &txParams); err != nil {
-		return nil, http.StatusBadRequest, "Bad request"
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(user)
+	h.logRequest(method, path, statusCode)
+}
+
+// handleConfig handles GET /api/v1/config, showing the effective configuration without secrets
+func (h *HTTPHandler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
 	}
 
-	// Validate all required fields are present
-	if txParams.Account == "" || txParams.Category == "" || txParams.Merchant == "" || txParams.Value == "" || txParams.Date == "" {
-		return nil, http.StatusBadRequest, "Bad request"
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(h.options.EffectiveConfig)
+	h.logRequest(method, path, statusCode)
+}
+
+// parseTransactionParams validates transactions.add params and parses them into a Transaction
+func (h *HTTPHandler) parseTransactionParams(ctx context.Context, params map[string]any) (*domain.Transaction, *requestError) {
+	params, fieldErrors := applyParamAliases(params, h.options.ParamAliases)
+	if len(fieldErrors) > 0 {
+		return nil, &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
 	}
 
-	// Validate and normalize the amount field
-	amount := txParams.Value
-	// Replace commas with dots
-	amount = strings.ReplaceAll(amount, ",", ".")
-	// Check for multiple dots
-	if strings.Count(amount, ".") > 1 {
-		return nil, http.StatusUnprocessableEntity, "Invalid amount format: multiple decimal separators"
+	// Check param types first, so mistyped params are reported alongside the other problems
+	params, typeErrors := checkParamTypes(params, transactionParamSchema)
+
+	var txParams domain.TransactionParams
+	if reqErr := h.decodeParams(params, &txParams); reqErr != nil {
+		return nil, reqErr
 	}
 
-	// Create domain transaction
-	tx := &domain.Transaction{
-		Account:  txParams.Account,
-		Category: txParams.Category,
-		Merchant: txParams.Merchant,
-		Amount:   amount,
-		Date:     txParams.Date,
+	// Validate every field, reporting all problems at once
+	tx, fieldErrors := h.validateCheckedParams(ctx, txParams, typeErrors)
+	if len(fieldErrors) > 0 {
+		return nil, &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
 	}
 
-	return tx, http.StatusOK, ""
+	return tx, nil
+}
+
+// errorBody builds the JSON body of a service error, with the lookup error code when there is
+// one and whether the client may retry the request
+func errorBody(err error) map[string]any {
+	body := map[string]any{"error": err.Error(), "retryable": service.IsRetryable(err)}
+	if code := service.ErrorCode(err); code != "" {
+		body["code"] = string(code)
+	}
+	return body
 }
 
-// validateAuth validates the Authorization header
-func (h *HTTPHandler) validateAuth(r *http.Request) bool {
-	clientToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-	if h.clientAuthKey != clientToken {
-		log.Println("Invalid client auth")
-		return false
+// statusForError maps a service error to an HTTP status code
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case service.IsLookupError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDuplicateSubmission):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRateLimitExhausted), service.IsRateLimited(err):
+		return http.StatusTooManyRequests
+	case service.IsUnavailable(err):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
 	}
-	return true
 }
 
 // logRequest logs the HTTP request details
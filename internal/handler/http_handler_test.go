@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// userClient is a PocketSmith client returning user from /me.
+// Methods it doesn't override panic through the nil embedded client.
+type userClient struct {
+	api.PocketSmithClient
+	user *domain.User
+}
+
+func (c *userClient) RefreshUser(ctx context.Context) (*domain.User, error) {
+	return c.user, nil
+}
+
+func TestHandleWhoAmI(t *testing.T) {
+	tests := []struct {
+		name string
+		user *domain.User
+		want map[string]any
+	}{
+		{
+			name: "enriched user",
+			user: &domain.User{ID: 42, Login: "jo", Name: "Jo Bloggs", BaseCurrencyCode: "aud", TimeZone: "Australia/Melbourne"},
+			want: map[string]any{"id": 42.0, "login": "jo", "name": "Jo Bloggs", "base_currency_code": "aud", "time_zone": "Australia/Melbourne"},
+		},
+		{
+			name: "bare user",
+			user: &domain.User{ID: 42},
+			want: map[string]any{"id": 42.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := service.NewTransactionService(&userClient{user: tt.user}, service.Options{})
+			h := NewHTTPHandler(svc, "client-secret", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/whoami", nil)
+			req.Header.Set("Authorization", "Bearer client-secret")
+			recorder := httptest.NewRecorder()
+
+			h.handleWhoAmI(context.Background(), recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", recorder.Code)
+			}
+			// Exactly the user's fields, nothing else such as a key
+			var got map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleConfig(t *testing.T) {
+	effective := map[string]any{"client_auth_key": "********", "request_timeout_ms": 2500.0}
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "authorized", token: "key", wantStatus: http.StatusOK},
+		{name: "wrong key", token: "other", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "key", Options{EffectiveConfig: effective})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			recorder := httptest.NewRecorder()
+
+			h.handleConfig(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if !reflect.DeepEqual(got, effective) {
+				t.Errorf("body = %v, want %v", got, effective)
+			}
+		})
+	}
+}
+
+// categoryClient is a PocketSmith client with one user and their categories.
+// Methods it doesn't override panic through the nil embedded client.
+type categoryClient struct {
+	api.PocketSmithClient
+	categories []domain.Category
+}
+
+func (c *categoryClient) GetMe(ctx context.Context) (*domain.User, error) {
+	return &domain.User{ID: 42}, nil
+}
+
+func (c *categoryClient) GetCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	return c.categories, nil
+}
+
+func TestHandleGetCategoryErrorCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []domain.Category
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "category not found", categories: []domain.Category{{ID: 20, Title: "Groceries"}}, wantStatus: http.StatusNotFound, wantCode: "category_not_found"},
+		{name: "no categories", categories: []domain.Category{}, wantStatus: http.StatusNotFound, wantCode: "category_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := service.NewTransactionService(&categoryClient{categories: tt.categories}, service.Options{})
+			h := NewHTTPHandler(svc, "client-secret", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/99", nil)
+			req.Header.Set("Authorization", "Bearer client-secret")
+			recorder := httptest.NewRecorder()
+
+			h.handleGetCategory(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			var got struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if got.Code != tt.wantCode || got.Error == "" {
+				t.Errorf("body = %s, want code %q alongside the message", recorder.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorRetryable(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantRetryable bool
+	}{
+		{name: "timeout", err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout, wantRetryable: true},
+		{name: "server error", err: &api.StatusError{StatusCode: http.StatusBadGateway}, wantStatus: http.StatusInternalServerError, wantRetryable: true},
+		{name: "outage page", err: &api.UnavailableError{StatusCode: http.StatusServiceUnavailable}, wantStatus: http.StatusServiceUnavailable, wantRetryable: true},
+		{name: "revoked API key", err: &api.StatusError{StatusCode: http.StatusUnauthorized}, wantStatus: http.StatusInternalServerError},
+		{name: "unknown job", err: service.ErrJobNotFound, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&failingService{err: tt.err}, "key", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+			req.Header.Set("Authorization", "Bearer key")
+			recorder := httptest.NewRecorder()
+
+			h.handleGetAccounts(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			// The flag is always present, false included
+			if got, ok := body["retryable"].(bool); !ok || got != tt.wantRetryable {
+				t.Errorf("retryable = %v, want %v", body["retryable"], tt.wantRetryable)
+			}
+		})
+	}
+}
+
+// addedService adds every transaction as added.
+// Methods it doesn't override panic through the nil embedded service.
+type addedService struct {
+	service.TransactionService
+	added *domain.AddedTransaction
+}
+
+func (s *addedService) AddTransaction(ctx context.Context, tx *domain.Transaction) (*domain.AddedTransaction, error) {
+	return s.added, nil
+}
+
+func TestRPCAddTransactionResolved(t *testing.T) {
+	tests := []struct {
+		name  string
+		added *domain.AddedTransaction
+		want  map[string]any
+	}{
+		{
+			name:  "account and category",
+			added: &domain.AddedTransaction{ID: 101, Account: domain.ResolvedEntity{ID: 10, Name: "Everyday"}, Category: &domain.ResolvedEntity{ID: 31, Name: "Groceries"}},
+			want: map[string]any{
+				"result":            "ok",
+				"transaction_id":    101.0,
+				"resolved_account":  map[string]any{"id": 10.0, "name": "Everyday"},
+				"resolved_category": map[string]any{"id": 31.0, "name": "Groceries"},
+			},
+		},
+		{
+			name:  "uncategorized without an ID",
+			added: &domain.AddedTransaction{Account: domain.ResolvedEntity{ID: 10, Name: "Everyday"}},
+			want: map[string]any{
+				"result":           "ok",
+				"resolved_account": map[string]any{"id": 10.0, "name": "Everyday"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&addedService{added: tt.added}, "key", Options{})
+			recorder := httptest.NewRecorder()
+
+			status := h.rpcAddTransaction(context.Background(), recorder, map[string]any{
+				"account": "everyday", "category": "groceries", "merchant": "Corner Store", "value": "-5.00", "date": "2025-01-13",
+			})
+
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", status, recorder.Body)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleGetCategory(t *testing.T) {
+	categories := []domain.Category{
+		{ID: 30, Title: "Food", Children: []domain.Category{{ID: 31, Title: "Groceries"}}},
+	}
+	svc := service.NewTransactionService(&categoryClient{categories: categories}, service.Options{})
+	h := NewHTTPHandler(svc, "client-secret", Options{})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/31", nil)
+	req.Header.Set("Authorization", "Bearer client-secret")
+	recorder := httptest.NewRecorder()
+
+	h.handleGetCategory(context.Background(), recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", recorder.Code, recorder.Body)
+	}
+	var got struct {
+		ID    int      `json:"id"`
+		Title string   `json:"title"`
+		Path  []string `json:"path"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+	}
+	if got.ID != 31 || got.Title != "Groceries" || !reflect.DeepEqual(got.Path, []string{"Food", "Groceries"}) {
+		t.Errorf("body = %s, want Groceries with its path under Food", recorder.Body)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// maxJobSize is the most transactions accepted by one transactions.addAsync call
+const maxJobSize = 1000
+
+// jobsPathPrefix is the path of the job status route, followed by the job ID
+const jobsPathPrefix = "/api/v1/jobs/"
+
+// rpcAddAsync handles the transactions.addAsync JSON-RPC method.
+// The items are validated and stored as a job, answered with 202 and the job's ID right away.
+// Spin doesn't run anything once the response is sent, so the job is processed a step at a
+// time by the polls of GET /api/v1/jobs/{id}.
+func (h *HTTPHandler) rpcAddAsync(ctx context.Context, w http.ResponseWriter, params map[string]any) int {
+	var batch batchParams
+	if reqErr := h.decodeParams(params, &batch); reqErr != nil {
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+	switch {
+	case len(batch.Transactions) == 0:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{"transactions": "required"}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	case len(batch.Transactions) > maxJobSize:
+		reqErr := &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{
+			"transactions": fmt.Sprintf("at most %d transactions per job", maxJobSize),
+		}}
+		writeRequestError(w, reqErr)
+		return reqErr.statusCode
+	}
+
+	// Items failing validation get their result now, the valid ones wait in the job
+	results, txs, txIndexes := h.validateBatchItems(ctx, batch.Transactions)
+	now := h.options.Clock.Now().UTC().Format(time.RFC3339)
+	job := &domain.Job{
+		ID:        newJobID(),
+		Status:    domain.JobPending,
+		Total:     len(results),
+		Processed: len(results) - len(txs),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Results:   results,
+	}
+	for j, tx := range txs {
+		job.Pending = append(job.Pending, domain.JobItem{Index: txIndexes[j], Transaction: tx})
+	}
+	if len(job.Pending) == 0 {
+		job.Status = domain.JobCompleted
+	}
+
+	if err := h.service.CreateJob(ctx, job); err != nil {
+		statusCode := statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(errorBody(err))
+		return statusCode
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(jobStatus(job))
+	return http.StatusAccepted
+}
+
+// handleGetJob handles GET /api/v1/jobs/{id}, processing the job's next step before
+// reporting its progress and the results so far
+func (h *HTTPHandler) handleGetJob(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	jobID := strings.TrimPrefix(path, jobsPathPrefix)
+	if jobID == "" || strings.Contains(jobID, "/") {
+		h.handleNotFound(w, method, path)
+		return
+	}
+
+	job, err := h.service.ProcessJob(ctx, jobID, describeBatchResult)
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(jobStatus(job))
+	h.logRequest(method, path, statusCode)
+}
+
+// jobStatus is a job as shown to clients: the pending items are left out, and only the
+// results of processed items are listed
+func jobStatus(job *domain.Job) domain.Job {
+	status := *job
+	status.Pending = nil
+	status.Results = make([]domain.BatchItemResult, 0, job.Processed)
+	for _, result := range job.Results {
+		if result.Status != 0 {
+			status.Results = append(status.Results, result)
+		}
+	}
+	return status
+}
+
+// newJobID returns a random job ID, unguessable so one user's jobs can't be probed by ID
+func newJobID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
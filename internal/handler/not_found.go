@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// notFoundBody is the JSON body of a 404 for an unknown route
+type notFoundBody struct {
+	Error string `json:"error"`
+	Path  string `json:"path"`
+	// Routes lists the known routes, only when DebugRoutes is enabled
+	Routes []string `json:"routes,omitempty"`
+}
+
+// handleNotFound responds 404 for a path and method no route matches
+func (h *HTTPHandler) handleNotFound(w http.ResponseWriter, method, path string) {
+	body := notFoundBody{Error: "not found", Path: path}
+	if h.options.DebugRoutes {
+		body.Routes = knownRoutes()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(body)
+	h.logRequest(method, path, http.StatusNotFound)
+}
+
+// knownRoutes lists every route as "METHOD /path", sorted. The routes are taken from the
+// OpenAPI document, which describes each of them except the document itself.
+func knownRoutes() []string {
+	routes := []string{http.MethodGet + " /openapi.json"}
+	for path, item := range openAPIDocument()["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
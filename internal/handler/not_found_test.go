@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestHandleNotFound(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		debugRoutes bool
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/api/v1/nope"},
+		{name: "known path, other method", method: http.MethodDelete, path: "/api/v1/accounts"},
+		{name: "non-numeric category ID", method: http.MethodGet, path: "/api/v1/categories/abc"},
+		{name: "debug routes", method: http.MethodGet, path: "/api/v1/nope", debugRoutes: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&idleService{}, "key", Options{DebugRoutes: tt.debugRoutes})
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer key")
+			recorder := httptest.NewRecorder()
+
+			h.Handle(recorder, req)
+
+			if recorder.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want 404", recorder.Code)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if body["error"] != "not found" || body["path"] != tt.path {
+				t.Errorf("body = %v, want the error and path %s", body, tt.path)
+			}
+
+			routes, listed := body["routes"].([]any)
+			if !tt.debugRoutes {
+				if _, ok := body["routes"]; ok {
+					t.Errorf("body = %v, want no routes without debug_routes", body)
+				}
+				return
+			}
+			if !listed {
+				t.Fatalf("body = %v, want the known routes", body)
+			}
+			var got []string
+			for _, route := range routes {
+				got = append(got, route.(string))
+			}
+			if !sort.StringsAreSorted(got) {
+				t.Errorf("routes = %v, want them sorted", got)
+			}
+			for _, want := range []string{"GET /api/v1/accounts", "POST /api/v1/accounts", "GET /openapi.json"} {
+				if i := sort.SearchStrings(got, want); i == len(got) || got[i] != want {
+					t.Errorf("routes = %v, missing %s", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// numericAmountsParam is the query param asking for amounts echoed as JSON numbers rather than strings
+const numericAmountsParam = "numeric_amounts"
+
+// numericAmountFields are the response fields holding amounts encoded as strings
+var numericAmountFields = map[string]bool{"value": true, "foreign_amount": true, "normalized": true}
+
+// wantNumericAmounts reports whether the request asked for amounts as JSON numbers
+func wantNumericAmounts(r *http.Request) bool {
+	numeric, _ := strconv.ParseBool(r.URL.Query().Get(numericAmountsParam))
+	return numeric
+}
+
+// numericAmountsWriter buffers a JSON response so its string amounts can be rewritten as
+// JSON numbers. Being json.Number, they keep their exact digits, e.g. -12.50 rather than "-12.50".
+// A flushed response is streaming, and streamed listings already report amounts as numbers,
+// so from then on it is passed through unchanged.
+type numericAmountsWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	passThrough bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *numericAmountsWriter) WriteHeader(statusCode int) {
+	if w.passThrough {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// Write implements http.ResponseWriter
+func (w *numericAmountsWriter) Write(body []byte) (int, error) {
+	if w.passThrough {
+		return w.ResponseWriter.Write(body)
+	}
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.body.Write(body)
+}
+
+// Flush implements http.Flusher, writing what was buffered so far as is and passing
+// the rest of the response through
+func (w *numericAmountsWriter) Flush() {
+	if !w.passThrough {
+		w.passThrough = true
+		if w.statusCode != 0 {
+			w.ResponseWriter.WriteHeader(w.statusCode)
+		}
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the buffered response, with its amounts as numbers when the body is JSON.
+// Other bodies, such as plain-text errors, are written unchanged.
+func (w *numericAmountsWriter) finish() {
+	if w.passThrough {
+		return
+	}
+	body := w.body.Bytes()
+	if strings.Contains(w.Header().Get("Content-Type"), "json") {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			if numeric, err := json.Marshal(numericAmounts(value)); err == nil {
+				body = append(numeric, '\n')
+			}
+		}
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	w.ResponseWriter.Write(body)
+}
+
+// numericAmounts replaces the decimal strings of amount fields anywhere in a decoded
+// JSON value with numbers. Strings that aren't plain decimals, like an amount echoed
+// as sent with its currency symbol, stay strings.
+func numericAmounts(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if amount, ok := field.(string); ok && numericAmountFields[key] {
+				if isJSONNumber(amount) {
+					v[key] = json.Number(amount)
+				}
+				continue
+			}
+			v[key] = numericAmounts(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = numericAmounts(item)
+		}
+	}
+	return value
+}
+
+// isJSONNumber reports whether a decimal string is also a valid JSON number,
+// which rules out forms like "+5", ".5" and "12."
+func isJSONNumber(amount string) bool {
+	return plainDecimalPattern.MatchString(amount) && json.Valid([]byte(amount))
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNumericAmountsWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        string
+	}{
+		{
+			name:        "nested transaction amounts",
+			contentType: "application/json",
+			body:        `{"valid": true, "transaction": {"value": "-12.50", "foreign_amount": "-7.00", "title": "12.50"}}`,
+			wantStatus:  http.StatusOK,
+			want:        `{"transaction":{"foreign_amount":-7.00,"title":"12.50","value":-12.50},"valid":true}` + "\n",
+		},
+		{
+			name:        "amount preview keeps the value as sent",
+			contentType: "application/json",
+			body:        `{"value": "€ -12,50", "normalized": "-12.50"}`,
+			wantStatus:  http.StatusOK,
+			want:        `{"normalized":-12.50,"value":"€ -12,50"}` + "\n",
+		},
+		{
+			name:        "amounts in a list and large numbers kept exact",
+			contentType: "application/vnd.proxy.v2+json",
+			body:        `{"data": [{"value": "1", "id": 12345678901234567890}, {"value": "12."}]}`,
+			wantStatus:  http.StatusOK,
+			want:        `{"data":[{"id":12345678901234567890,"value":1},{"value":"12."}]}` + "\n",
+		},
+		{
+			name:        "field errors unchanged",
+			contentType: "application/json",
+			body:        `{"errors": {"value": "multiple decimal separators"}}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			want:        `{"errors":{"value":"multiple decimal separators"}}` + "\n",
+		},
+		{
+			name:        "plain text unchanged",
+			contentType: "text/plain",
+			body:        "Bad request\n",
+			wantStatus:  http.StatusBadRequest,
+			want:        "Bad request\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			w := &numericAmountsWriter{ResponseWriter: recorder}
+			w.Header().Set("Content-Type", tt.contentType)
+			if tt.wantStatus != http.StatusOK {
+				w.WriteHeader(tt.wantStatus)
+			}
+			w.Write([]byte(tt.body))
+			w.finish()
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericAmountsWriterFlush(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := &numericAmountsWriter{ResponseWriter: recorder}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"transactions":[{"amount":-12.5}`))
+	w.Flush()
+	w.Write([]byte(`]}`))
+	w.finish()
+
+	if !recorder.Flushed {
+		t.Error("response not flushed")
+	}
+	if got, want := recorder.Body.String(), `{"transactions":[{"amount":-12.5}]}`; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,702 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/buildinfo"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// rpcIDSchema describes the optional JSON-RPC id, echoed in the response as sent
+var rpcIDSchema = map[string]any{
+	"oneOf":       []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}},
+	"description": "Optional JSON-RPC id, echoed in the response with the same JSON type",
+}
+
+// handleOpenAPI handles GET /openapi.json
+func (h *HTTPHandler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	statusCode := http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(openAPIDocument())
+	h.logRequest(r.Method, r.URL.Path, statusCode)
+}
+
+// openAPIDocument builds the OpenAPI 3 description of the proxy's routes
+func openAPIDocument() map[string]any {
+	transactionParams := schemaOf(reflect.TypeOf(domain.TransactionParams{}))
+	transactionParams["required"] = []string{"account", "merchant", "value", "date"}
+	accountParams := schemaOf(reflect.TypeOf(domain.AccountParams{}))
+	accountParams["required"] = []string{"name", "currency", "type"}
+	// Pending items are kept with a job but never shown
+	job := schemaOf(reflect.TypeOf(domain.Job{}))
+	delete(job["properties"].(map[string]any), "pending")
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "PocketSmith Proxy",
+			"description": "JSON-RPC proxy for adding transactions to PocketSmith",
+			"version":     "0.1.0",
+		},
+		"security": []any{
+			map[string]any{"bearerAuth": []string{}},
+		},
+		"paths": map[string]any{
+			"/api/v1/transactions/append": map[string]any{
+				"post": map[string]any{
+					"summary": "Add a transaction (JSON-RPC method transactions.add), several with transactions.addBatch or, as a job polled at /api/v1/jobs/{id}, transactions.addAsync, adjust an account's balance with transactions.adjustBalance, or import categories with categories.import",
+					"requestBody": map[string]any{
+						"required": true,
+						"content": jsonContent(map[string]any{
+							"oneOf": []any{ref("TransactionsAddRequest"), ref("TransactionsAddBatchRequest"), ref("TransactionsAddAsyncRequest"), ref("TransactionsAdjustBalanceRequest"), ref("CategoriesImportRequest")},
+						}),
+					},
+					"responses": map[string]any{
+						"200": response("Transaction created, or one result per batch or import item", map[string]any{
+							"oneOf": []any{
+								objectSchema(map[string]any{
+									"result":            map[string]any{"type": "string", "enum": []string{"ok"}},
+									"id":                rpcIDSchema,
+									"transaction_id":    map[string]any{"type": "integer", "description": "PocketSmith transaction ID, omitted when unknown"},
+									"resolved_account":  schemaOf(reflect.TypeOf(domain.ResolvedEntity{})),
+									"resolved_category": schemaOf(reflect.TypeOf(domain.ResolvedEntity{})),
+								}),
+								objectSchema(map[string]any{
+									"results": arraySchema(schemaOf(reflect.TypeOf(domain.BatchItemResult{}))),
+								}),
+								objectSchema(map[string]any{
+									"result":     map[string]any{"type": "string", "enum": []string{"ok", "balanced"}},
+									"message":    map[string]any{"type": "string", "description": "Set when the account was already balanced"},
+									"adjustment": schemaOf(reflect.TypeOf(domain.BalanceAdjustment{})),
+								}),
+							},
+						}),
+						"202": response("transactions.addAsync job created, to be polled at /api/v1/jobs/{id}", ref("Job")),
+						"400": response("Bad request, or account/category not found", ref("Error")),
+						"403": response("Invalid or missing client auth key", nil),
+						"409": response("Identical transaction submitted within dedup_window_seconds", ref("Error")),
+						"422": response("Invalid transaction params", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/categories": map[string]any{
+				"get": map[string]any{
+					"summary": "List category titles, or full categories with ?detail=true",
+					"parameters": []any{
+						map[string]any{
+							"name":   "detail",
+							"in":     "query",
+							"schema": map[string]any{"type": "boolean"},
+						},
+					},
+					"responses": withCSV(listResponses(map[string]any{
+						"oneOf": []any{
+							arraySchema(map[string]any{"type": "string"}),
+							arraySchema(ref("Category")),
+						},
+					})),
+				},
+			},
+			"/api/v1/categories/search": map[string]any{
+				"get": map[string]any{
+					"summary": "Search categories by title, prefix matches first",
+					"parameters": []any{
+						map[string]any{
+							"name":     "q",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":   "limit",
+							"in":     "query",
+							"schema": map[string]any{"type": "integer", "default": defaultSearchLimit},
+						},
+					},
+					"responses": listResponses(arraySchema(ref("CategoryMatch"))),
+				},
+			},
+			"/api/v1/categories/{id}": map[string]any{
+				"get": map[string]any{
+					"summary": "Show a category anywhere in the tree, with the titles of its ancestors",
+					"parameters": []any{
+						map[string]any{
+							"name":     "id",
+							"in":       "path",
+							"required": true,
+							"schema":   map[string]any{"type": "integer"},
+						},
+					},
+					"responses": map[string]any{
+						"200": response("The category", ref("CategoryDetail")),
+						"403": response("Invalid or missing client auth key", nil),
+						"404": response("No category with this ID", ref("Error")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/accounts": map[string]any{
+				"get": map[string]any{
+					"summary": "List transaction accounts, optionally filtered by type, or full accounts with ?full=true",
+					"parameters": []any{
+						map[string]any{
+							"name":        "type",
+							"in":          "query",
+							"description": "Comma-separated account types (case-insensitive), e.g. bank,credit",
+							"schema":      map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":   "full",
+							"in":     "query",
+							"schema": map[string]any{"type": "boolean"},
+						},
+					},
+					"responses": withCSV(listResponses(map[string]any{
+						"oneOf": []any{
+							arraySchema(ref("AccountInfo")),
+							arraySchema(ref("TransactionAccount")),
+						},
+					})),
+				},
+				"post": map[string]any{
+					"summary": "Create a transaction account, in a new institution unless institution_id is given",
+					"requestBody": map[string]any{
+						"required": true,
+						"content":  jsonContent(ref("AccountParams")),
+					},
+					"responses": map[string]any{
+						"201": response("Account created", objectSchema(map[string]any{
+							"id": map[string]any{"type": "integer", "description": "Transaction account ID"},
+						})),
+						"400": response("Bad request", ref("Error")),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("Invalid account params", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/transactions": map[string]any{
+				"get": map[string]any{
+					"summary": "List an account's transactions between two dates, streamed page by page",
+					"parameters": []any{
+						map[string]any{
+							"name":     "account",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":     "start_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+						map[string]any{
+							"name":     "end_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+					},
+					"responses": map[string]any{
+						"200": response("Transactions, with an error trailer if the stream failed part-way", objectSchema(map[string]any{
+							"items": arraySchema(ref("Transaction")),
+							"data":  arraySchema(ref("Transaction")),
+							"error": map[string]any{"type": "string"},
+						})),
+						"400": response("Account not found", ref("Error")),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("Invalid query params", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/transactions/validate": map[string]any{
+				"post": map[string]any{
+					"summary":    "Validate transaction params like transactions.add, including the account and category lookups, without creating anything",
+					"parameters": []any{numericAmountsParameter},
+					"requestBody": map[string]any{
+						"required": true,
+						"content":  jsonContent(ref("TransactionParams")),
+					},
+					"responses": map[string]any{
+						"200": response("Validation result, with every problem found", schemaOf(reflect.TypeOf(validationResult{}))),
+						"400": response("Bad request", nil),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("Ambiguous param aliases", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/amounts/normalize": map[string]any{
+				"post": map[string]any{
+					"summary":    "Preview how an amount is normalized for transactions.add: symbols stripped, decimal separator, rounding, sign, and warnings",
+					"parameters": []any{numericAmountsParameter},
+					"requestBody": map[string]any{
+						"required": true,
+						"content":  jsonContent(schemaOf(reflect.TypeOf(amountPreviewParams{}))),
+					},
+					"responses": map[string]any{
+						"200": response("The normalized amount", schemaOf(reflect.TypeOf(amountPreview{}))),
+						"400": response("Bad request", nil),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("An amount transactions.add would reject, or an invalid currency or type", ref("FieldErrors")),
+					},
+				},
+			},
+			"/api/v1/transactions/search": map[string]any{
+				"get": map[string]any{
+					"summary": fmt.Sprintf("Find an account's transactions whose payee or note contains q, over at most %d days, streamed page by page", maxSearchRangeDays),
+					"parameters": []any{
+						map[string]any{
+							"name":     "account",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":     "q",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":     "start_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+						map[string]any{
+							"name":     "end_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+					},
+					"responses": map[string]any{
+						"200": response("Matching transactions, with an error trailer if the stream failed part-way", objectSchema(map[string]any{
+							"items": arraySchema(ref("Transaction")),
+							"data":  arraySchema(ref("Transaction")),
+							"error": map[string]any{"type": "string"},
+						})),
+						"400": response("Account not found", ref("Error")),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("Invalid query params, or a date range that is too long", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/summary": map[string]any{
+				"get": map[string]any{
+					"summary": fmt.Sprintf("Total transactions by category per currency, over at most %d days", maxSummaryRangeDays),
+					"parameters": []any{
+						map[string]any{
+							"name":     "start_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+						map[string]any{
+							"name":     "end_date",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]any{"type": "string", "format": "date"},
+						},
+						map[string]any{
+							"name":        "account",
+							"in":          "query",
+							"description": "Summarize only this account (all accounts when omitted)",
+							"schema":      map[string]any{"type": "string"},
+						},
+						map[string]any{
+							"name":        "rollup",
+							"in":          "query",
+							"description": "Count sub-categories under their top-level category",
+							"schema":      map[string]any{"type": "boolean"},
+						},
+					},
+					"responses": map[string]any{
+						"200": response("Totals by category, per currency", ref("Summary")),
+						"400": response("Account not found", ref("Error")),
+						"403": response("Invalid or missing client auth key", nil),
+						"422": response("Invalid query params, or a date range that is too long", ref("FieldErrors")),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/shortcut_entities": map[string]any{
+				"get": map[string]any{
+					"summary":   "List accounts and categories in one call",
+					"responses": listResponses(ref("ShortcutEntities")),
+				},
+			},
+			"/api/v1/cache/refresh": map[string]any{
+				"post": map[string]any{
+					"summary": "Refetch accounts and categories from PocketSmith and overwrite the cache, only when both fetches succeed. Meant to be called on a schedule",
+					"responses": map[string]any{
+						"200": response("Cache refreshed", objectSchema(map[string]any{
+							"result": map[string]any{"type": "string", "enum": []string{"ok"}},
+						})),
+						"403": response("Invalid or missing client auth key", nil),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/cache/all": map[string]any{
+				"delete": map[string]any{
+					"summary": "Delete every cached entry under the cache namespace",
+					"responses": map[string]any{
+						"200": response("Cache flushed", objectSchema(map[string]any{
+							"deleted": map[string]any{"type": "integer", "description": "Number of cache keys removed"},
+						})),
+						"403": response("Invalid or missing client auth key", nil),
+						"500": response("Internal server error", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/cache/stats": map[string]any{
+				"get": map[string]any{
+					"summary": "Show the cumulative cache hits and misses per entity, counted across instances (redis cache backend only)",
+					"responses": map[string]any{
+						"200": response("Lookups per entity (user, and entities for the accounts and categories)", map[string]any{
+							"type":                 "object",
+							"additionalProperties": schemaOf(reflect.TypeOf(domain.CacheCounter{})),
+						}),
+						"403": response("Invalid or missing client auth key", nil),
+						"500": response("Internal server error", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/jobs/{id}": map[string]any{
+				"get": map[string]any{
+					"summary": fmt.Sprintf("Process the next %d pending items of a transactions.addAsync job, then show its progress and the results so far", service.JobStepSize),
+					"parameters": []any{
+						map[string]any{
+							"name":     "id",
+							"in":       "path",
+							"required": true,
+							"schema":   map[string]any{"type": "string"},
+						},
+					},
+					"responses": map[string]any{
+						"200": response("The job", ref("Job")),
+						"403": response("Invalid or missing client auth key", nil),
+						"404": response("No such job, or it expired", ref("Error")),
+						"500": response("Internal server error", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/whoami": map[string]any{
+				"get": map[string]any{
+					"summary": "Show the PocketSmith user the configured API key belongs to",
+					"responses": map[string]any{
+						"200": response("PocketSmith user, fetched fresh", schemaOf(reflect.TypeOf(domain.User{}))),
+						"403": response("Invalid or missing client auth key", nil),
+						"429": rateLimitedResponse,
+						"503": unavailableResponse,
+						"500": response("Internal server error", ref("Error")),
+						"504": response("Request timed out", ref("Error")),
+					},
+				},
+			},
+			"/api/v1/config": map[string]any{
+				"get": map[string]any{
+					"summary": "Show the effective configuration, keyed by variable name, with secrets redacted",
+					"responses": map[string]any{
+						"200": response("Effective configuration", map[string]any{"type": "object"}),
+						"403": response("Invalid or missing client auth key", nil),
+					},
+				},
+			},
+			"/version": map[string]any{
+				"get": map[string]any{
+					"summary":  "Show build information",
+					"security": []any{},
+					"responses": map[string]any{
+						"200": response("Build information", schemaOf(reflect.TypeOf(buildinfo.Info{}))),
+					},
+				},
+			},
+			"/": map[string]any{
+				"get": map[string]any{
+					"summary":  "Describe the service: its name, version, and endpoints",
+					"security": []any{},
+					"responses": map[string]any{
+						"200": response("Service descriptor", schemaOf(reflect.TypeOf(serviceDescriptor{}))),
+					},
+				},
+			},
+		},
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+			},
+			"schemas": map[string]any{
+				"TransactionsAddRequest": objectSchema(map[string]any{
+					"id":     rpcIDSchema,
+					"method": map[string]any{"type": "string", "enum": []string{"transactions.add"}},
+					"params": ref("TransactionParams"),
+				}),
+				"TransactionsAddBatchRequest": objectSchema(map[string]any{
+					"id":     rpcIDSchema,
+					"method": map[string]any{"type": "string", "enum": []string{"transactions.addBatch"}},
+					"params": objectSchema(map[string]any{
+						"transactions": map[string]any{
+							"type":     "array",
+							"items":    ref("TransactionParams"),
+							"maxItems": maxBatchSize,
+						},
+					}),
+				}),
+				"TransactionsAddAsyncRequest": objectSchema(map[string]any{
+					"id":     rpcIDSchema,
+					"method": map[string]any{"type": "string", "enum": []string{"transactions.addAsync"}},
+					"params": objectSchema(map[string]any{
+						"transactions": map[string]any{
+							"type":     "array",
+							"items":    ref("TransactionParams"),
+							"maxItems": maxJobSize,
+						},
+					}),
+				}),
+				"Job": job,
+				"TransactionsAdjustBalanceRequest": objectSchema(map[string]any{
+					"id":     rpcIDSchema,
+					"method": map[string]any{"type": "string", "enum": []string{"transactions.adjustBalance"}},
+					"params": objectSchema(map[string]any{
+						"account":  map[string]any{"type": "string"},
+						"balance":  map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}, "description": "Target balance, in the same formats as a transaction's value"},
+						"currency": map[string]any{"type": "string", "description": "Picks one of several accounts sharing the name"},
+					}),
+				}),
+				"CategoriesImportRequest": objectSchema(map[string]any{
+					"id":     rpcIDSchema,
+					"method": map[string]any{"type": "string", "enum": []string{"categories.import"}},
+					"params": objectSchema(map[string]any{
+						"categories": map[string]any{
+							"type":        "array",
+							"items":       map[string]any{"type": "string"},
+							"maxItems":    maxCategoryImportSize,
+							"description": "Category titles, or paths of titles separated by \">\" to nest under parents, e.g. \"Food > Groceries\"",
+						},
+					}),
+				}),
+				"TransactionParams":  transactionParams,
+				"AccountInfo":        schemaOf(reflect.TypeOf(domain.AccountInfo{})),
+				"AccountParams":      accountParams,
+				"TransactionAccount": schemaOf(reflect.TypeOf(domain.TransactionAccount{})),
+				"Category":           schemaOf(reflect.TypeOf(domain.Category{})),
+				"CategoryMatch":      schemaOf(reflect.TypeOf(domain.CategoryMatch{})),
+				"CategoryDetail":     schemaOf(reflect.TypeOf(domain.CategoryDetail{})),
+				"ShortcutEntities":   schemaOf(reflect.TypeOf(domain.ShortcutEntities{})),
+				"Summary":            schemaOf(reflect.TypeOf(domain.Summary{})),
+				"Transaction":        schemaOf(reflect.TypeOf(domain.TransactionRecord{})),
+				"Error": objectSchema(map[string]any{
+					"error": map[string]any{"type": "string"},
+					"retryable": map[string]any{
+						"type":        "boolean",
+						"description": "Whether the same request may succeed when retried: true for timeouts, rate limits and PocketSmith server or connection errors, false for lookup errors and requests PocketSmith rejected",
+					},
+					"code": map[string]any{
+						"type":        "string",
+						"description": "Why a lookup failed (400, or 404 for an unknown category ID)",
+						"enum": []service.LookupErrorCode{
+							service.CodeNoAccounts, service.CodeNoCategories, service.CodeAccountNotFound, service.CodeAccountAmbiguous,
+							service.CodeCurrencyMismatch, service.CodeCategoryNotFound, service.CodeNoMerchantRule,
+							service.CodeCategorySignMismatch,
+						},
+					},
+				}),
+				"FieldErrors": objectSchema(map[string]any{
+					"errors": map[string]any{
+						"type":                 "object",
+						"additionalProperties": map[string]any{"type": "string"},
+					},
+				}),
+			},
+		},
+	}
+}
+
+// listResponses describes the responses shared by the list endpoints
+func listResponses(payload map[string]any) map[string]any {
+	return map[string]any{
+		"200": withHeaders(response("Legacy envelope (items or data), or {\"data\": ...} with Accept: "+mediaTypeV2, objectSchema(map[string]any{
+			"items":     payload,
+			"data":      payload,
+			"truncated": map[string]any{"type": "boolean", "description": "Present when the list reached max_entities and may be incomplete"},
+		})), map[string]any{
+			"X-Cache": map[string]any{
+				"description": "Whether the data came from the proxy's cache",
+				"schema":      map[string]any{"type": "string", "enum": []string{"HIT", "MISS", "PARTIAL"}},
+			},
+		}),
+		"403": response("Invalid or missing client auth key", nil),
+		"429": rateLimitedResponse,
+		"503": unavailableResponse,
+		"500": response("Internal server error", ref("Error")),
+		"504": response("Request timed out", ref("Error")),
+	}
+}
+
+// unavailableResponse documents the 503 sent when PocketSmith answers with an error status and
+// something other than JSON, such as an outage page
+var unavailableResponse = response("PocketSmith is unavailable, retry later", ref("Error"))
+
+// numericAmountsParameter documents the query param for amounts as JSON numbers, listed on
+// the endpoints that would otherwise echo amounts as strings
+var numericAmountsParameter = map[string]any{
+	"name":        numericAmountsParam,
+	"in":          "query",
+	"description": "Echo decimal amounts as JSON numbers rather than strings, keeping their exact digits",
+	"schema":      map[string]any{"type": "boolean"},
+}
+
+// rateLimitedResponse documents the 429 sent when PocketSmith rate-limits the proxy
+var rateLimitedResponse = withHeaders(response("PocketSmith is rate-limiting the proxy, back off", ref("Error")), map[string]any{
+	"Retry-After": map[string]any{
+		"description": "Seconds to wait before retrying",
+		"schema":      map[string]any{"type": "integer"},
+	},
+})
+
+// withCSV documents the CSV form of a list's 200 response, sent with Accept: text/csv
+func withCSV(responses map[string]any) map[string]any {
+	content := responses["200"].(map[string]any)["content"].(map[string]any)
+	content[mediaTypeCSV] = map[string]any{
+		"schema": map[string]any{"type": "string", "description": "CSV with a header row"},
+	}
+	return responses
+}
+
+// response describes a response, with a JSON body when schema is not nil
+func response(description string, schema map[string]any) map[string]any {
+	resp := map[string]any{"description": description}
+	if schema != nil {
+		resp["content"] = jsonContent(schema)
+	}
+	return resp
+}
+
+// withHeaders documents the headers sent with a response
+func withHeaders(resp map[string]any, headers map[string]any) map[string]any {
+	resp["headers"] = headers
+	return resp
+}
+
+// jsonContent wraps a schema as application/json content
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{"schema": schema},
+	}
+}
+
+// ref references a schema under components
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// objectSchema describes an object with the given properties
+func objectSchema(properties map[string]any) map[string]any {
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// arraySchema describes an array of items
+func arraySchema(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+// schemaOf derives a JSON schema from a Go type using its json struct tags,
+// so the document stays in sync with the domain types
+func schemaOf(t reflect.Type) map[string]any {
+	return schemaOfType(t, make(map[reflect.Type]bool))
+}
+
+// schemaOfType derives a schema, referencing a struct by name when it contains itself
+func schemaOfType(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	if t == reflect.TypeOf(domain.Amount("")) {
+		return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaOfType(t.Elem(), visiting)
+		schema["nullable"] = true
+		return schema
+	case reflect.Struct:
+		if visiting[t] {
+			return ref(t.Name())
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				// Embedded structs are flattened by encoding/json, so their fields are too
+				embedded := schemaOfType(field.Type, visiting)
+				if embeddedProperties, ok := embedded["properties"].(map[string]any); ok {
+					for embeddedName, schema := range embeddedProperties {
+						properties[embeddedName] = schema
+					}
+				}
+				continue
+			}
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			properties[name] = schemaOfType(field.Type, visiting)
+		}
+		return objectSchema(properties)
+	case reflect.Slice, reflect.Array:
+		return arraySchema(schemaOfType(t.Elem(), visiting))
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOfType(t.Elem(), visiting)}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	default:
+		return map[string]any{}
+	}
+}
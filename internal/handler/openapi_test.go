@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serveOpenAPI fetches and decodes the OpenAPI document
+func serveOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+	h := NewHTTPHandler(nil, "key", Options{})
+	recorder := httptest.NewRecorder()
+	h.handleOpenAPI(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	var document map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("document is not JSON: %v", err)
+	}
+	return document
+}
+
+// lookup walks a decoded JSON document down the given keys, returning nil when one is missing
+func lookup(document any, keys ...string) any {
+	for _, key := range keys {
+		object, ok := document.(map[string]any)
+		if !ok {
+			return nil
+		}
+		document = object[key]
+	}
+	return document
+}
+
+func TestOpenAPIPaths(t *testing.T) {
+	document := serveOpenAPI(t)
+	if version := lookup(document, "openapi"); version != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", version)
+	}
+
+	tests := []struct {
+		path   string
+		method string
+	}{
+		{path: "/api/v1/transactions/append", method: "post"},
+		{path: "/api/v1/categories", method: "get"},
+		{path: "/api/v1/categories/search", method: "get"},
+		{path: "/api/v1/categories/{id}", method: "get"},
+		{path: "/api/v1/accounts", method: "get"},
+		{path: "/api/v1/accounts", method: "post"},
+		{path: "/api/v1/transactions", method: "get"},
+		{path: "/api/v1/transactions/validate", method: "post"},
+		{path: "/api/v1/transactions/search", method: "get"},
+		{path: "/api/v1/summary", method: "get"},
+		{path: "/api/v1/shortcut_entities", method: "get"},
+		{path: "/api/v1/cache/refresh", method: "post"},
+		{path: "/api/v1/cache/all", method: "delete"},
+		{path: "/api/v1/cache/stats", method: "get"},
+		{path: "/api/v1/jobs/{id}", method: "get"},
+		{path: "/api/v1/whoami", method: "get"},
+		{path: "/version", method: "get"},
+		{path: "/", method: "get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			operation, ok := lookup(document, "paths", tt.path, tt.method).(map[string]any)
+			if !ok {
+				t.Fatalf("no %s %s operation", tt.method, tt.path)
+			}
+			if _, ok := operation["responses"].(map[string]any); !ok {
+				t.Errorf("%s %s has no responses", tt.method, tt.path)
+			}
+		})
+	}
+}
+
+func TestOpenAPITransactionsAddSchema(t *testing.T) {
+	document := serveOpenAPI(t)
+	schemas := lookup(document, "components", "schemas")
+
+	if method := lookup(schemas, "TransactionsAddRequest", "properties", "method", "enum"); !reflect.DeepEqual(method, []any{"transactions.add"}) {
+		t.Errorf("transactions.add method enum = %v", method)
+	}
+	if params := lookup(schemas, "TransactionsAddRequest", "properties", "params", "$ref"); params != "#/components/schemas/TransactionParams" {
+		t.Errorf("transactions.add params = %v, want the TransactionParams schema", params)
+	}
+
+	params := lookup(schemas, "TransactionParams")
+	for _, name := range []string{"account", "category", "category_id", "merchant", "value", "date", "currency", "foreign_amount", "source"} {
+		if lookup(params, "properties", name) == nil {
+			t.Errorf("TransactionParams has no %s property", name)
+		}
+	}
+	if required := lookup(params, "required"); !reflect.DeepEqual(required, []any{"account", "merchant", "value", "date"}) {
+		t.Errorf("TransactionParams required = %v", required)
+	}
+
+	if scheme := lookup(document, "components", "securitySchemes", "bearerAuth", "scheme"); scheme != "bearer" {
+		t.Errorf("bearerAuth scheme = %v, want bearer", scheme)
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	document := serveOpenAPI(t)
+	schemas, _ := lookup(document, "components", "schemas").(map[string]any)
+
+	var check func(node any)
+	check = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			if target, ok := node["$ref"].(string); ok {
+				name := strings.TrimPrefix(target, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Errorf("$ref %s has no schema", target)
+				}
+			}
+			for _, child := range node {
+				check(child)
+			}
+		case []any:
+			for _, child := range node {
+				check(child)
+			}
+		}
+	}
+	check(document)
+}
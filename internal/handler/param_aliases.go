@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// ParseParamAliases parses a comma-separated list of alias:field pairs renaming
+// transaction params, e.g. "amount:value,name:merchant,cat:category".
+// Each field must be a transaction param, and an alias must not shadow one.
+func ParseParamAliases(value string) (map[string]string, error) {
+	fields := transactionParamFields()
+
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		alias, field, ok := strings.Cut(pair, ":")
+		alias, field = strings.TrimSpace(alias), strings.TrimSpace(field)
+		if !ok || alias == "" || field == "" {
+			return nil, fmt.Errorf("invalid param alias %q: expected alias:param", pair)
+		}
+		if !fields[field] {
+			return nil, fmt.Errorf("invalid param alias %q: unknown param %q", pair, field)
+		}
+		if fields[alias] {
+			return nil, fmt.Errorf("invalid param alias %q: %q is already a param", pair, alias)
+		}
+		if _, ok := aliases[alias]; ok {
+			return nil, fmt.Errorf("duplicate param alias %q", alias)
+		}
+		aliases[alias] = field
+	}
+	return aliases, nil
+}
+
+// transactionParamFields returns the JSON field names of TransactionParams
+func transactionParamFields() map[string]bool {
+	t := reflect.TypeOf(domain.TransactionParams{})
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		fields[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = true
+	}
+	return fields
+}
+
+// applyParamAliases returns params with aliased fields renamed to the params they stand for.
+// Sending both an alias and its param (or two aliases of one param) is ambiguous
+// and reported as a field error.
+func applyParamAliases(params map[string]any, aliases map[string]string) (map[string]any, map[string]string) {
+	if len(aliases) == 0 {
+		return params, nil
+	}
+
+	renamed := make(map[string]any, len(params))
+	fieldErrors := make(map[string]string)
+	for name, value := range params {
+		field, ok := aliases[name]
+		if !ok {
+			renamed[name] = value
+			continue
+		}
+		if _, ok := params[field]; ok {
+			fieldErrors[name] = fmt.Sprintf("alias of %s, which was also sent", field)
+			continue
+		}
+		if _, ok := renamed[field]; ok {
+			fieldErrors[name] = fmt.Sprintf("alias of %s, which was also sent under another alias", field)
+			continue
+		}
+		renamed[field] = value
+	}
+	return renamed, fieldErrors
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseParamAliases(t *testing.T) {
+	aliases, err := ParseParamAliases(" amount:value, name : merchant,")
+	if err != nil {
+		t.Fatalf("ParseParamAliases() error = %v", err)
+	}
+	if want := map[string]string{"amount": "value", "name": "merchant"}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("ParseParamAliases() = %v, want %v", aliases, want)
+	}
+
+	for _, value := range []string{"amount", "amount:", "amount:total", "merchant:payee", "amount:value,amount:merchant"} {
+		if _, err := ParseParamAliases(value); err == nil {
+			t.Errorf("ParseParamAliases(%q) error = nil", value)
+		}
+	}
+}
+
+func TestApplyParamAliases(t *testing.T) {
+	aliases := map[string]string{"amount": "value", "total": "value", "name": "merchant"}
+	tests := []struct {
+		name       string
+		params     map[string]any
+		want       map[string]any
+		wantErrors map[string]string
+	}{
+		{
+			name:       "renamed",
+			params:     map[string]any{"amount": "1", "name": "Shop", "date": "2025-01-13"},
+			want:       map[string]any{"value": "1", "merchant": "Shop", "date": "2025-01-13"},
+			wantErrors: map[string]string{},
+		},
+		{
+			name:       "alias and param both sent",
+			params:     map[string]any{"amount": "1", "value": "2"},
+			want:       map[string]any{"value": "2"},
+			wantErrors: map[string]string{"amount": "alias of value, which was also sent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fieldErrors := applyParamAliases(tt.params, aliases)
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(fieldErrors, tt.wantErrors) {
+				t.Errorf("applyParamAliases() = %v, %v, want %v, %v", got, fieldErrors, tt.want, tt.wantErrors)
+			}
+		})
+	}
+
+	// Two aliases of one param: whichever comes second is the ambiguous one
+	_, fieldErrors := applyParamAliases(map[string]any{"amount": "1", "total": "2"}, aliases)
+	if len(fieldErrors) != 1 {
+		t.Errorf("applyParamAliases() field errors = %v, want one", fieldErrors)
+	}
+}
+
+func TestCheckParamTypes(t *testing.T) {
+	params := map[string]any{
+		"account":     "Everyday",
+		"value":       json.Number("12.5"),
+		"category_id": "12",
+		"upsert":      "yes",
+		"merchant":    nil,
+		"unknown":     json.Number("1"),
+	}
+	checked, fieldErrors := checkParamTypes(params, transactionParamSchema)
+	if want := map[string]any{"account": "Everyday", "value": json.Number("12.5"), "merchant": nil, "unknown": json.Number("1")}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checkParamTypes() params = %v, want %v", checked, want)
+	}
+	for _, name := range []string{"category_id", "upsert"} {
+		if fieldErrors[name] == "" {
+			t.Errorf("checkParamTypes() field errors = %v, want %s reported", fieldErrors, name)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"/":                     "/",
+		"//":                    "/",
+		"/api/v1/accounts/":     "/api/v1/accounts",
+		"//api//v1///accounts":  "/api/v1/accounts",
+		"/api/v1/categories/12": "/api/v1/categories/12",
+	}
+	for path, want := range tests {
+		if got := normalizePath(path); got != want {
+			t.Errorf("normalizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
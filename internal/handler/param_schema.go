@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// paramType is the JSON type a param must have
+type paramType string
+
+const (
+	paramString  paramType = "string"
+	paramInteger paramType = "integer"
+	paramBoolean paramType = "boolean"
+	// paramAmount accepts a string or a number
+	paramAmount paramType = "string or number"
+)
+
+// transactionParamSchema maps each transaction param to its JSON type,
+// derived from the fields of TransactionParams so the two can't drift apart
+var transactionParamSchema = paramSchemaOf(reflect.TypeOf(domain.TransactionParams{}))
+
+// paramSchemaOf builds the schema of a params struct from its JSON field names and Go types
+func paramSchemaOf(t reflect.Type) map[string]paramType {
+	schema := make(map[string]paramType, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		kind := field.Type.Kind()
+		if kind == reflect.Pointer {
+			kind = field.Type.Elem().Kind()
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Type == reflect.TypeOf(domain.Amount("")) {
+			schema[name] = paramAmount
+			continue
+		}
+		switch kind {
+		case reflect.String:
+			schema[name] = paramString
+		case reflect.Int, reflect.Int64:
+			schema[name] = paramInteger
+		case reflect.Bool:
+			schema[name] = paramBoolean
+		}
+	}
+	return schema
+}
+
+// checkParamTypes checks each param against its type in schema before the params are
+// decoded, so a mistyped param is reported as a field error rather than failing the
+// whole request. It returns the params without the mistyped ones, and the field errors.
+// Null counts as omitted, and params not in the schema are left to the decoder.
+func checkParamTypes(params map[string]any, schema map[string]paramType) (map[string]any, map[string]string) {
+	fieldErrors := make(map[string]string)
+	for name, value := range params {
+		want, ok := schema[name]
+		if !ok || value == nil {
+			continue
+		}
+		if got := jsonType(value); !matchesParamType(value, want) {
+			fieldErrors[name] = fmt.Sprintf("must be %s %s, got %s", article(want), want, got)
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return params, nil
+	}
+
+	checked := make(map[string]any, len(params))
+	for name, value := range params {
+		if _, mistyped := fieldErrors[name]; !mistyped {
+			checked[name] = value
+		}
+	}
+	return checked, fieldErrors
+}
+
+// matchesParamType reports whether a decoded JSON value has the wanted type
+func matchesParamType(value any, want paramType) bool {
+	switch want {
+	case paramString:
+		_, ok := value.(string)
+		return ok
+	case paramInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := strconv.ParseInt(number.String(), 10, 32)
+		return err == nil
+	case paramAmount:
+		switch value.(type) {
+		case string, json.Number:
+			return true
+		}
+		return false
+	case paramBoolean:
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "null"
+}
+
+// article returns the indefinite article for a param type
+func article(t paramType) string {
+	if t == paramInteger {
+		return "an"
+	}
+	return "a"
+}
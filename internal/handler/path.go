@@ -0,0 +1,15 @@
+package handler
+
+import "strings"
+
+// normalizePath collapses repeated slashes and strips a trailing slash, so
+// "//api/v1/accounts" and "/api/v1/categories/" route like their exact paths
+func normalizePath(path string) string {
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// defaultRetryAfter is advised when PocketSmith rate-limits the proxy without saying for how long
+const defaultRetryAfter = time.Minute
+
+// rateLimitWriter adds the latest PocketSmith rate-limit values to the response headers
+// just before they are written, so they reflect every PocketSmith call made for the request
+type rateLimitWriter struct {
+	http.ResponseWriter
+	service     service.TransactionService
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *rateLimitWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		rateLimit := w.service.RateLimit()
+		setHeaderIfPresent(w.Header(), "X-PocketSmith-RateLimit-Limit", rateLimit.Limit)
+		setHeaderIfPresent(w.Header(), "X-PocketSmith-RateLimit-Remaining", rateLimit.Remaining)
+		setHeaderIfPresent(w.Header(), "X-PocketSmith-RateLimit-Reset", rateLimit.Reset)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter
+func (w *rateLimitWriter) Write(body []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(body)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it
+func (w *rateLimitWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// setHeaderIfPresent sets a header only when the value is not empty
+func setHeaderIfPresent(header http.Header, key, value string) {
+	if value != "" {
+		header.Set(key, value)
+	}
+}
+
+// setRetryAfter advises the client when to retry after PocketSmith rate-limited the proxy,
+// passing on PocketSmith's Retry-After (rounded up to whole seconds) when it sent one
+func setRetryAfter(w http.ResponseWriter, err error) {
+	if !service.IsRateLimited(err) {
+		return
+	}
+	retryAfter := service.RetryAfter(err)
+	if retryAfter <= 0 {
+		retryAfter = defaultRetryAfter
+	}
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
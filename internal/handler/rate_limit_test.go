@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// failingService fails every call it overrides with err.
+// Methods it doesn't override panic through the nil embedded service.
+type failingService struct {
+	service.TransactionService
+	err error
+}
+
+func (s *failingService) FlushCache(ctx context.Context) (int, error) {
+	return 0, s.err
+}
+
+func (s *failingService) GetCacheStats(ctx context.Context) (domain.CacheStats, error) {
+	return nil, s.err
+}
+
+func (s *failingService) GetAccounts(ctx context.Context, types []string) ([]domain.AccountInfo, error) {
+	return nil, s.err
+}
+
+func TestCacheHandlersRetryAfter(t *testing.T) {
+	handlers := []struct {
+		name   string
+		method string
+		path   string
+		handle func(h *HTTPHandler, ctx context.Context, w http.ResponseWriter, r *http.Request)
+	}{
+		{name: "flush", method: http.MethodDelete, path: "/api/v1/cache/all", handle: (*HTTPHandler).handleFlushCache},
+		{name: "stats", method: http.MethodGet, path: "/api/v1/cache/stats", handle: (*HTTPHandler).handleCacheStats},
+	}
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryAfter string
+	}{
+		{name: "rate limited with a wait", err: &api.RateLimitedError{RetryAfter: 1500 * time.Millisecond}, wantRetryAfter: "2"},
+		{name: "rate limited without a wait", err: &api.RateLimitedError{}, wantRetryAfter: "60"},
+		{name: "other failure", err: &api.StatusError{StatusCode: http.StatusInternalServerError}},
+	}
+	for _, handler := range handlers {
+		for _, tt := range tests {
+			t.Run(handler.name+" "+tt.name, func(t *testing.T) {
+				h := NewHTTPHandler(&failingService{err: tt.err}, "key", Options{})
+				req := httptest.NewRequest(handler.method, handler.path, nil)
+				req.Header.Set("Authorization", "Bearer key")
+				recorder := httptest.NewRecorder()
+
+				handler.handle(h, context.Background(), recorder, req)
+
+				if recorder.Code == http.StatusOK {
+					t.Fatalf("status = %d, want a failure", recorder.Code)
+				}
+				if got := recorder.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+					t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+				}
+			})
+		}
+	}
+}
+
+// meteredService reports rateLimit as PocketSmith's latest rate-limit values.
+// Methods it doesn't override panic through the nil embedded service.
+type meteredService struct {
+	service.TransactionService
+	rateLimit domain.RateLimit
+}
+
+func (s *meteredService) RateLimit() domain.RateLimit {
+	return s.rateLimit
+}
+
+func TestRateLimitWriterEchoesHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		rateLimit domain.RateLimit
+		// writeHeader writes the status explicitly rather than through the first Write
+		writeHeader bool
+		want        map[string]string
+	}{
+		{
+			name:      "all values",
+			rateLimit: domain.RateLimit{Limit: "100", Remaining: "57", Reset: "1736755200"},
+			want: map[string]string{
+				"X-PocketSmith-RateLimit-Limit":     "100",
+				"X-PocketSmith-RateLimit-Remaining": "57",
+				"X-PocketSmith-RateLimit-Reset":     "1736755200",
+			},
+		},
+		{
+			name:        "all values with an explicit status",
+			rateLimit:   domain.RateLimit{Limit: "100", Remaining: "57", Reset: "1736755200"},
+			writeHeader: true,
+			want: map[string]string{
+				"X-PocketSmith-RateLimit-Limit":     "100",
+				"X-PocketSmith-RateLimit-Remaining": "57",
+				"X-PocketSmith-RateLimit-Reset":     "1736755200",
+			},
+		},
+		{
+			name:      "remaining only",
+			rateLimit: domain.RateLimit{Remaining: "3"},
+			want: map[string]string{
+				"X-PocketSmith-RateLimit-Limit":     "",
+				"X-PocketSmith-RateLimit-Remaining": "3",
+				"X-PocketSmith-RateLimit-Reset":     "",
+			},
+		},
+		{
+			name: "no PocketSmith calls yet",
+			want: map[string]string{
+				"X-PocketSmith-RateLimit-Limit":     "",
+				"X-PocketSmith-RateLimit-Remaining": "",
+				"X-PocketSmith-RateLimit-Reset":     "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			w := &rateLimitWriter{ResponseWriter: recorder, service: &meteredService{rateLimit: tt.rateLimit}}
+
+			if tt.writeHeader {
+				w.WriteHeader(http.StatusCreated)
+			}
+			w.Write([]byte(`{}`))
+
+			for name, want := range tt.want {
+				if got := recorder.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitedResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+		wantRetryable  bool
+	}{
+		{name: "rate limited", err: fmt.Errorf("failed to get transaction accounts: %w", &api.RateLimitedError{RetryAfter: 30 * time.Second}),
+			wantStatus: http.StatusTooManyRequests, wantRetryAfter: "30", wantRetryable: true},
+		{name: "rate limited without a wait", err: &api.RateLimitedError{},
+			wantStatus: http.StatusTooManyRequests, wantRetryAfter: "60", wantRetryable: true},
+		{name: "other PocketSmith failure", err: &api.StatusError{StatusCode: http.StatusBadRequest},
+			wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&failingService{err: tt.err}, "key", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+			req.Header.Set("Authorization", "Bearer key")
+			recorder := httptest.NewRecorder()
+
+			h.handleGetAccounts(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			var body struct {
+				Error     string `json:"error"`
+				Retryable bool   `json:"retryable"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			if body.Retryable != tt.wantRetryable {
+				t.Errorf("retryable = %v, want %v", body.Retryable, tt.wantRetryable)
+			}
+			if tt.wantRetryable && !strings.Contains(body.Error, "back off") {
+				t.Errorf("error = %q, want it to tell the client to back off", body.Error)
+			}
+		})
+	}
+}
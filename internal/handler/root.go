@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pocketsmith-proxy/internal/buildinfo"
+)
+
+// DefaultServiceName names the service in the root descriptor when no name is configured
+const DefaultServiceName = "pocketsmith-proxy"
+
+// serviceDescriptor is the body of GET /, telling whoever opens the root what is running
+type serviceDescriptor struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Endpoints are the known routes as "METHOD /path"
+	Endpoints []string `json:"endpoints"`
+}
+
+// handleRoot handles GET /
+func (h *HTTPHandler) handleRoot(w http.ResponseWriter, r *http.Request) {
+	name := h.options.ServiceName
+	if name == "" {
+		name = DefaultServiceName
+	}
+
+	statusCode := http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(serviceDescriptor{
+		Name:      name,
+		Version:   buildinfo.Get().Version,
+		Endpoints: knownRoutes(),
+	})
+	h.logRequest(r.Method, r.URL.Path, statusCode)
+}
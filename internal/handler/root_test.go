@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/buildinfo"
+)
+
+func TestHandleRoot(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceName string
+		wantName    string
+	}{
+		{name: "default name", wantName: DefaultServiceName},
+		{name: "configured name", serviceName: "family-finances", wantName: "family-finances"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No client key is sent, since the root is open
+			h := NewHTTPHandler(&idleService{}, "key", Options{ServiceName: tt.serviceName})
+			recorder := httptest.NewRecorder()
+			h.Handle(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", recorder.Code)
+			}
+			var got serviceDescriptor
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			want := serviceDescriptor{Name: tt.wantName, Version: buildinfo.Get().Version, Endpoints: knownRoutes()}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("descriptor = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	// Only GET serves the descriptor
+	h := NewHTTPHandler(&idleService{}, "key", Options{})
+	recorder := httptest.NewRecorder()
+	h.Handle(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("POST / status = %d, want 404", recorder.Code)
+	}
+}
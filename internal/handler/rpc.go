@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// rpcMethodHandler handles one JSON-RPC method, writes its response, and returns the status written
+type rpcMethodHandler func(h *HTTPHandler, ctx context.Context, w http.ResponseWriter, params map[string]any) int
+
+// rpcMethods maps each supported JSON-RPC method name to its handler.
+// New methods only need to be registered here.
+var rpcMethods = map[string]rpcMethodHandler{
+	"transactions.add":           (*HTTPHandler).rpcAddTransaction,
+	"transactions.addBatch":      (*HTTPHandler).rpcAddBatch,
+	"transactions.addAsync":      (*HTTPHandler).rpcAddAsync,
+	"transactions.adjustBalance": (*HTTPHandler).rpcAdjustBalance,
+	"categories.import":          (*HTTPHandler).rpcImportCategories,
+}
+
+// rpcMethodAliases maps lowercase alternative method names to the supported method
+var rpcMethodAliases = map[string]string{
+	"transaction.add":     "transactions.add",
+	"transactions.create": "transactions.add",
+}
+
+// resolveRPCMethod returns the supported method a requested name refers to.
+// Names match case-insensitively, and a few aliases are accepted.
+func resolveRPCMethod(name string) (string, bool) {
+	if _, ok := rpcMethods[name]; ok {
+		return name, true
+	}
+	for method := range rpcMethods {
+		if strings.EqualFold(method, name) {
+			return method, true
+		}
+	}
+	method, ok := rpcMethodAliases[strings.ToLower(name)]
+	return method, ok
+}
+
+// supportedRPCMethods returns the supported method names, sorted
+func supportedRPCMethods() []string {
+	methods := make([]string, 0, len(rpcMethods))
+	for method := range rpcMethods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// handleRPC handles POST /api/v1/transactions/append, dispatching on the JSON-RPC method
+func (h *HTTPHandler) handleRPC(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+
+	rpcReq, reqErr := h.parseRPCRequest(r)
+	if reqErr != nil {
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, reqErr.statusCode)
+		return
+	}
+
+	// Echo the request's id in the response, as JSON-RPC 2.0 clients expect
+	if rpcReq.ID != nil {
+		if !validRPCID(rpcReq.ID) {
+			reqErr = &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{"id": "must be a string or number"}}
+			writeRequestError(w, reqErr)
+			h.logRequest(method, path, reqErr.statusCode)
+			return
+		}
+		idWriter := &rpcIDWriter{ResponseWriter: w, id: rpcReq.ID}
+		defer idWriter.finish()
+		w = idWriter
+	}
+
+	rpcMethod, ok := resolveRPCMethod(rpcReq.Method)
+	if !ok {
+		reason := "required"
+		if rpcReq.Method != "" {
+			reason = fmt.Sprintf("unknown method %q, supported methods: %s", rpcReq.Method, strings.Join(supportedRPCMethods(), ", "))
+		}
+		reqErr = &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{"method": reason}}
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, reqErr.statusCode)
+		return
+	}
+
+	// Every method takes params
+	if rpcReq.Params == nil {
+		reqErr = newRequestError(http.StatusBadRequest, "Bad request")
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, reqErr.statusCode)
+		return
+	}
+
+	statusCode := rpcMethods[rpcMethod](h, ctx, w, rpcReq.Params)
+	h.logRequest(method, path, statusCode)
+}
+
+// parseRPCRequest validates the HTTP request and decodes its JSON-RPC envelope
+func (h *HTTPHandler) parseRPCRequest(r *http.Request) (*domain.RPCRequest, *requestError) {
+	// Validate HTTP method is POST
+	if r.Method != http.MethodPost {
+		return nil, newRequestError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+
+	// Validate Content-Type is application/json
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		return nil, newRequestError(http.StatusBadRequest, "Bad request")
+	}
+
+	// Validate client auth key
+	if !h.validateAuth(r) {
+		return nil, newRequestError(http.StatusForbidden, "Forbidden")
+	}
+
+	// Read request body
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Error reading request body")
+	}
+	defer r.Body.Close()
+	if reqErr := checkBodyNotEmpty(body); reqErr != nil {
+		return nil, reqErr
+	}
+	if reqErr := h.checkDuplicateKeys(body); reqErr != nil {
+		return nil, reqErr
+	}
+
+	// Decode JSON body into RPCRequest, keeping numbers as written so amounts stay exact
+	var rpcReq domain.RPCRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rpcReq); err != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Bad request")
+	}
+
+	return &rpcReq, nil
+}
+
+// decodeJSONBody decodes a JSON request body into a params struct, like decodeParams
+func (h *HTTPHandler) decodeJSONBody(r *http.Request, out any) *requestError {
+	params, reqErr := h.readJSONObject(r)
+	if reqErr != nil {
+		return reqErr
+	}
+	return h.decodeParams(params, out)
+}
+
+// readJSONObject reads a JSON object request body, keeping numbers as written
+func (h *HTTPHandler) readJSONObject(r *http.Request) (map[string]any, *requestError) {
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		return nil, newRequestError(http.StatusBadRequest, "Bad request")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Error reading request body")
+	}
+	defer r.Body.Close()
+	if reqErr := checkBodyNotEmpty(body); reqErr != nil {
+		return nil, reqErr
+	}
+	if reqErr := h.checkDuplicateKeys(body); reqErr != nil {
+		return nil, reqErr
+	}
+
+	var params map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil || params == nil {
+		return nil, newRequestError(http.StatusBadRequest, "Bad request")
+	}
+	return params, nil
+}
+
+// isJSONContentType reports whether a Content-Type header is application/json, whatever its
+// parameters, e.g. "application/json; charset=utf-8"
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// checkBodyNotEmpty rejects a body that is empty or only whitespace with a message saying so,
+// rather than the "Bad request" of malformed JSON
+func checkBodyNotEmpty(body []byte) *requestError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return newRequestError(http.StatusBadRequest, "Empty request body")
+	}
+	return nil
+}
+
+// decodeParams decodes JSON-RPC params into a method's params struct.
+// In strict mode, typos like "merchnt" are rejected instead of silently ignored.
+func (h *HTTPHandler) decodeParams(params map[string]any, out any) *requestError {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return newRequestError(http.StatusBadRequest, "Bad request")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(paramsJSON))
+	decoder.UseNumber()
+	if h.options.StrictParams {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(out); err != nil {
+		if field, ok := unknownField(err); ok {
+			return &requestError{statusCode: http.StatusUnprocessableEntity, fields: map[string]string{field: "unknown field"}}
+		}
+		return newRequestError(http.StatusBadRequest, "Bad request")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// rpcIDWriter buffers a JSON-RPC response so the request's id can be added to it,
+// letting clients correlate responses with their requests
+type rpcIDWriter struct {
+	http.ResponseWriter
+	id         json.RawMessage
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *rpcIDWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// Write implements http.ResponseWriter
+func (w *rpcIDWriter) Write(body []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.body.Write(body)
+}
+
+// finish writes the buffered response, with the id added when the body is a JSON object.
+// Other bodies, such as plain-text errors, are written unchanged.
+func (w *rpcIDWriter) finish() {
+	body := w.body.Bytes()
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err == nil && fields != nil {
+			fields["id"] = w.id
+			if withID, err := json.Marshal(fields); err == nil {
+				body = append(withID, '\n')
+			}
+		}
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	w.ResponseWriter.Write(body)
+}
+
+// validRPCID reports whether a JSON-RPC id is a string, a number, or null
+func validRPCID(id json.RawMessage) bool {
+	var value any
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case string, float64, nil:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRPCIDWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		contentType string
+		body        string
+		wantStatus  int
+		want        string
+	}{
+		{
+			name:        "numeric id kept alongside the transaction id",
+			id:          `7`,
+			contentType: "application/json",
+			body:        `{"result": "ok", "transaction_id": 1234567}`,
+			wantStatus:  http.StatusOK,
+			want:        `{"id":7,"result":"ok","transaction_id":1234567}` + "\n",
+		},
+		{
+			name:        "string id on an error",
+			id:          `"req-7"`,
+			contentType: "application/json",
+			body:        `{"error": "not found"}`,
+			wantStatus:  http.StatusBadRequest,
+			want:        `{"error":"not found","id":"req-7"}` + "\n",
+		},
+		{
+			name:        "plain text unchanged",
+			id:          `"req-7"`,
+			contentType: "text/plain",
+			body:        "Bad request\n",
+			wantStatus:  http.StatusBadRequest,
+			want:        "Bad request\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			w := &rpcIDWriter{ResponseWriter: recorder, id: json.RawMessage(tt.id)}
+			w.Header().Set("Content-Type", tt.contentType)
+			if tt.wantStatus != http.StatusOK {
+				w.WriteHeader(tt.wantStatus)
+			}
+			w.Write([]byte(tt.body))
+			w.finish()
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidRPCID(t *testing.T) {
+	tests := map[string]bool{`1`: true, `"a"`: true, `null`: true, `1.5`: true, `{}`: false, `[1]`: false, `true`: false}
+	for id, want := range tests {
+		if got := validRPCID(json.RawMessage(id)); got != want {
+			t.Errorf("validRPCID(%s) = %v, want %v", id, got, want)
+		}
+	}
+}
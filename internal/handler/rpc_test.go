@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveRPCMethod(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		want   string
+		wantOK bool
+	}{
+		{name: "exact", method: "transactions.add", want: "transactions.add", wantOK: true},
+		{name: "wrong casing", method: "Transactions.Add", want: "transactions.add", wantOK: true},
+		{name: "wrong casing of a camel-case method", method: "transactions.addbatch", want: "transactions.addBatch", wantOK: true},
+		{name: "alias", method: "transaction.add", want: "transactions.add", wantOK: true},
+		{name: "alias in any case", method: "Transactions.Create", want: "transactions.add", wantOK: true},
+		{name: "unknown", method: "transactions.delete"},
+		{name: "empty", method: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveRPCMethod(tt.method)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveRPCMethod(%q) = %q, %v, want %q, %v", tt.method, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestHandleRPCUnknownMethod(t *testing.T) {
+	h := NewHTTPHandler(nil, "key", Options{})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/append", strings.NewReader(`{"method": "transactions.delete", "params": {}}`))
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	h.handleRPC(context.Background(), recorder, req)
+
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnprocessableEntity)
+	}
+	for _, want := range append([]string{`unknown method \"transactions.delete\"`}, supportedRPCMethods()...) {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("body = %s, want it to mention %s", recorder.Body.String(), want)
+		}
+	}
+}
+
+func TestHandleRPCEmptyBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "empty", body: "", wantStatus: http.StatusBadRequest, wantMessage: "Empty request body"},
+		{name: "whitespace only", body: " \n\t ", wantStatus: http.StatusBadRequest, wantMessage: "Empty request body"},
+		{name: "malformed", body: `{"method":`, wantStatus: http.StatusBadRequest, wantMessage: "Bad request"},
+		{name: "empty object", body: "{}", wantStatus: http.StatusUnprocessableEntity, wantMessage: `"method":"required"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "key", Options{})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/append", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			h.handleRPC(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantMessage) {
+				t.Errorf("body = %s, want it to say %s", recorder.Body, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandleNormalizeAmountEmptyBody(t *testing.T) {
+	for _, body := range []string{"", " \n\t "} {
+		h := NewHTTPHandler(nil, "key", Options{})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/amounts/normalize", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+
+		h.handleNormalizeAmount(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "Empty request body") {
+			t.Errorf("body %q: status = %d, body = %s, want 400 Empty request body", body, recorder.Code, recorder.Body)
+		}
+	}
+}
+
+func TestParseRPCRequestContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantOK      bool
+	}{
+		{contentType: "application/json", wantOK: true},
+		{contentType: "application/json; charset=utf-8", wantOK: true},
+		{contentType: "Application/JSON; charset=UTF-8", wantOK: true},
+		{contentType: "text/plain"},
+		{contentType: "application/json-patch+json"},
+		{contentType: "application/json; charset"},
+		{contentType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "key", Options{})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/append", strings.NewReader(`{"method": "transactions.add", "params": {}}`))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("Content-Type", tt.contentType)
+
+			_, reqErr := h.parseRPCRequest(req)
+			if tt.wantOK {
+				if reqErr != nil {
+					t.Errorf("parseRPCRequest() error = %+v, want the body accepted", reqErr)
+				}
+				return
+			}
+			if reqErr == nil || reqErr.statusCode != http.StatusBadRequest {
+				t.Errorf("parseRPCRequest() error = %+v, want a 400", reqErr)
+			}
+		})
+	}
+}
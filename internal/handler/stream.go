@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// listStream writes a list envelope item by item, so long lists are never buffered whole.
+// The status and opening of the envelope are only written with the first page, which lets
+// errors before that still get a regular error response.
+type listStream struct {
+	w           http.ResponseWriter
+	key         string
+	contentType string
+	encoder     *json.Encoder
+	started     bool
+	count       int
+}
+
+// newListStream prepares a streamed list response in the envelope the client asked for
+func newListStream(w http.ResponseWriter, r *http.Request, legacyKey string) *listStream {
+	stream := &listStream{
+		w:           w,
+		key:         legacyKey,
+		contentType: "application/json",
+		encoder:     json.NewEncoder(w),
+	}
+	if wantsV2Envelope(r) {
+		stream.key = "data"
+		stream.contentType = mediaTypeV2
+	}
+	return stream
+}
+
+// start writes the status and the opening of the envelope
+func (s *listStream) start() error {
+	s.started = true
+	s.w.Header().Set("Content-Type", s.contentType)
+	s.w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprintf(s.w, "{%q:[", s.key)
+	return err
+}
+
+// writePage encodes each item of a page and flushes it to the client
+func writePage[T any](s *listStream, page []T) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	for _, item := range page {
+		if s.count > 0 {
+			if _, err := io.WriteString(s.w, ","); err != nil {
+				return err
+			}
+		}
+		if err := s.encoder.Encode(item); err != nil {
+			return err
+		}
+		s.count++
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// close ends the envelope, writing an empty list if no page was written
+func (s *listStream) close() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(s.w, "]}\n")
+	return err
+}
+
+// abort ends a started envelope with an error trailer, keeping the body valid JSON:
+// {"items":[...],"error":"..."}
+func (s *listStream) abort(cause error) error {
+	message, err := json.Marshal(cause.Error())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "],\"error\":%s}\n", message)
+	return err
+}
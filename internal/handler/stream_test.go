@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// pagingService lists fixed pages of transactions, then fails with err when it is set.
+// Methods it doesn't override panic through the nil embedded service.
+type pagingService struct {
+	service.TransactionService
+	pages [][]domain.TransactionRecord
+	err   error
+}
+
+func (s *pagingService) ListTransactions(ctx context.Context, account, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	for _, page := range s.pages {
+		if err := visit(page); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestListTransactionsStream(t *testing.T) {
+	pages := [][]domain.TransactionRecord{{{ID: 1}, {ID: 2}}, {{ID: 3}}}
+	failure := errors.New("refusing to follow next page link to https://attacker.example")
+	tests := []struct {
+		name       string
+		pages      [][]domain.TransactionRecord
+		err        error
+		accept     string
+		wantStatus int
+		wantKey    string
+		wantItems  int
+		wantError  bool
+	}{
+		{name: "every page", pages: pages, wantStatus: http.StatusOK, wantKey: "items", wantItems: 3},
+		{name: "no transactions", wantStatus: http.StatusOK, wantKey: "items"},
+		{name: "v2 envelope", pages: pages, accept: mediaTypeV2, wantStatus: http.StatusOK, wantKey: "data", wantItems: 3},
+		{name: "failure after a page", pages: pages[:1], err: failure, wantStatus: http.StatusOK, wantKey: "items", wantItems: 2, wantError: true},
+		{name: "failure before any page", err: failure, wantStatus: http.StatusInternalServerError, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&pagingService{pages: tt.pages, err: tt.err}, "key", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions?account=Everyday&start_date=2025-01-01&end_date=2025-01-31", nil)
+			req.Header.Set("Authorization", "Bearer key")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+
+			h.handleListTransactions(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not valid JSON: %v", recorder.Body, err)
+			}
+			if _, ok := body["error"]; ok != tt.wantError {
+				t.Errorf("body %s has an error: %v, want %v", recorder.Body, ok, tt.wantError)
+			}
+			if tt.wantKey == "" {
+				return
+			}
+			var items []domain.TransactionRecord
+			if err := json.Unmarshal(body[tt.wantKey], &items); err != nil {
+				t.Fatalf("body %s has no %q list: %v", recorder.Body, tt.wantKey, err)
+			}
+			if len(items) != tt.wantItems {
+				t.Errorf("streamed %d transactions, want %d", len(items), tt.wantItems)
+			}
+		})
+	}
+}
+
+func (s *pagingService) SearchTransactions(ctx context.Context, account, query, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	return s.ListTransactions(ctx, account, startDate, endDate, visit)
+}
+
+func TestSearchTransactionsRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFields []string
+	}{
+		{name: "within the cap", query: "?account=Everyday&q=corner&start_date=2025-01-01&end_date=2026-01-01", wantStatus: http.StatusOK},
+		{name: "over the cap", query: "?account=Everyday&q=corner&start_date=2024-01-01&end_date=2025-01-01", wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"end_date"}},
+		{name: "reversed", query: "?account=Everyday&q=corner&start_date=2025-02-01&end_date=2025-01-01", wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"end_date"}},
+		{name: "no query", query: "?account=Everyday&start_date=2025-01-01&end_date=2025-01-31", wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"q"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&pagingService{pages: [][]domain.TransactionRecord{{{ID: 1}}}}, "key", Options{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/search"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer key")
+			recorder := httptest.NewRecorder()
+
+			h.handleSearchTransactions(context.Background(), recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			var body struct {
+				Errors map[string]string `json:"errors"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			for _, field := range tt.wantFields {
+				if body.Errors[field] == "" {
+					t.Errorf("errors = %v, want one for %s", body.Errors, field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxSummaryRangeDays caps the date range of a summary, since every transaction of
+// every summarized account in the range is fetched from PocketSmith
+const maxSummaryRangeDays = 366
+
+// handleSummary handles GET /api/v1/summary
+func (h *HTTPHandler) handleSummary(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Validate query params
+	query := r.URL.Query()
+	startDate, endDate, fieldErrors := parseDateRange(query, maxSummaryRangeDays)
+	rollup := false
+	if value := query.Get("rollup"); value != "" {
+		var err error
+		if rollup, err = strconv.ParseBool(value); err != nil {
+			fieldErrors["rollup"] = "invalid boolean"
+		}
+	}
+	if len(fieldErrors) > 0 {
+		statusCode = http.StatusUnprocessableEntity
+		writeRequestError(w, &requestError{statusCode: statusCode, fields: fieldErrors})
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	summary, err := h.service.Summarize(ctx, strings.TrimSpace(query.Get("account")), startDate, endDate, rollup)
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(summary)
+	h.logRequest(method, path, statusCode)
+}
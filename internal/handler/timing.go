@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// statusRecorder remembers the status code written, for logging after the response is done
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter
+func (w *statusRecorder) Write(body []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(body)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// logSlowRequest warns when handling a request took longer than the slow-request threshold
+func (h *HTTPHandler) logSlowRequest(method, path string, w *statusRecorder, start time.Time) {
+	threshold := h.options.SlowRequestThreshold
+	if threshold <= 0 {
+		return
+	}
+	if elapsed := h.options.Clock.Now().Sub(start); elapsed > threshold {
+		log.Printf("WARNING: Slow request: %s %s returned %d after %v (threshold %v)", method, path, w.statusCode, elapsed.Round(time.Millisecond), threshold)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// maxSearchRangeDays caps the date range of a transaction search, since every
+// transaction in the range is fetched from PocketSmith to be filtered
+const maxSearchRangeDays = 366
+
+// handleSearchTransactions handles GET /api/v1/transactions/search.
+// Matches are streamed to the client page by page, like the transaction list.
+func (h *HTTPHandler) handleSearchTransactions(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Validate query params
+	query := r.URL.Query()
+	account, startDate, endDate, fieldErrors := parseTransactionRange(query, maxSearchRangeDays)
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		fieldErrors["q"] = "required"
+	}
+	if len(fieldErrors) > 0 {
+		statusCode = http.StatusUnprocessableEntity
+		writeRequestError(w, &requestError{statusCode: statusCode, fields: fieldErrors})
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// Stream the matches of each page as it is fetched
+	stream := newListStream(w, r, "items")
+	err := h.service.SearchTransactions(ctx, account, q, startDate, endDate, func(matches []domain.TransactionRecord) error {
+		return writePage(stream, matches)
+	})
+	if err != nil && !stream.started {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	// The status is already sent, so a failure mid-stream can only be reported in the body
+	statusCode = http.StatusOK
+	if err != nil {
+		log.Printf("ERROR: Transaction search for account '%s' failed after %d matches: %v", account, stream.count, err)
+		stream.abort(err)
+	} else {
+		stream.close()
+	}
+	h.logRequest(method, path, statusCode)
+}
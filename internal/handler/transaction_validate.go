@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// validationProblem is one problem with a validated transaction payload
+type validationProblem struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+	// Code is set for account and category lookup failures
+	Code string `json:"code,omitempty"`
+}
+
+// validationResult is the response of POST /api/v1/transactions/validate
+type validationResult struct {
+	Valid bool `json:"valid"`
+	// Transaction is the normalized transaction, omitted when any param is invalid
+	Transaction *domain.Transaction         `json:"transaction,omitempty"`
+	Entities    *domain.TransactionEntities `json:"resolved"`
+	Problems    []validationProblem         `json:"problems"`
+}
+
+// handleValidateTransaction handles POST /api/v1/transactions/validate, running the same
+// checks as transactions.add, including the account and category lookups, without
+// creating anything. Problems are reported in a 200 response rather than as errors.
+func (h *HTTPHandler) handleValidateTransaction(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	path := r.URL.Path
+	var statusCode int
+
+	// Validate auth
+	if !h.validateAuth(r) {
+		statusCode = http.StatusForbidden
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, "Forbidden")
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	params, reqErr := h.readJSONObject(r)
+	if reqErr == nil {
+		var fieldErrors map[string]string
+		params, fieldErrors = applyParamAliases(params, h.options.ParamAliases)
+		if len(fieldErrors) > 0 {
+			reqErr = &requestError{statusCode: http.StatusUnprocessableEntity, fields: fieldErrors}
+		}
+	}
+	var typeErrors map[string]string
+	var txParams domain.TransactionParams
+	if reqErr == nil {
+		params, typeErrors = checkParamTypes(params, transactionParamSchema)
+		reqErr = h.decodeParams(params, &txParams)
+	}
+	if reqErr != nil {
+		statusCode = reqErr.statusCode
+		writeRequestError(w, reqErr)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	tx, fieldErrors := h.validateCheckedParams(ctx, txParams, typeErrors)
+
+	// Look up whichever of the account and category were valid
+	lookup := tx
+	if lookup == nil {
+		lookup = lookupFields(txParams, fieldErrors, h.options.TitleCaseMerchant)
+	}
+	entities, lookupErrors, err := h.service.ResolveEntities(ctx, lookup)
+	if err != nil {
+		statusCode = statusForError(err)
+		w.Header().Set("Content-Type", "application/json")
+		setRetryAfter(w, err)
+		w.WriteHeader(statusCode)
+		errorResponse := errorBody(err)
+		json.NewEncoder(w).Encode(errorResponse)
+		h.logRequest(method, path, statusCode)
+		return
+	}
+
+	result := validationResult{Entities: entities, Problems: []validationProblem{}}
+	for field, reason := range fieldErrors {
+		result.Problems = append(result.Problems, validationProblem{Field: field, Error: reason})
+	}
+	for field, lookupErr := range lookupErrors {
+		result.Problems = append(result.Problems, validationProblem{Field: field, Error: lookupErr.Error(), Code: string(service.ErrorCode(lookupErr))})
+	}
+	sort.Slice(result.Problems, func(i, j int) bool {
+		return result.Problems[i].Field < result.Problems[j].Field
+	})
+	result.Valid = len(result.Problems) == 0
+	if len(fieldErrors) == 0 {
+		result.Transaction = tx
+	}
+
+	// Success
+	statusCode = http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(result)
+	h.logRequest(method, path, statusCode)
+}
+
+// lookupFields returns the params needed for the account and category lookups of a
+// payload with field errors, leaving out those that were invalid
+func lookupFields(txParams domain.TransactionParams, fieldErrors map[string]string, titleCase bool) *domain.Transaction {
+	lookup := &domain.Transaction{}
+	if _, ok := fieldErrors["account"]; !ok {
+		lookup.Account = txParams.Account
+		if _, ok := fieldErrors["account_currency"]; !ok {
+			lookup.AccountCurrency = strings.ToUpper(strings.TrimSpace(txParams.AccountCurrency))
+		}
+	}
+	_, categoryErr := fieldErrors["category"]
+	_, categoryIDErr := fieldErrors["category_id"]
+	if !categoryErr && !categoryIDErr {
+		lookup.Category = txParams.Category
+		lookup.CategoryID = txParams.CategoryID
+		if _, ok := fieldErrors["merchant"]; !ok {
+			lookup.Merchant = normalizeMerchant(txParams.Merchant, titleCase)
+		}
+	}
+	return lookup
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// resolvingService resolves every lookup to fixed entities, recording what it was asked.
+// Methods it doesn't override panic through the nil embedded service.
+type resolvingService struct {
+	service.TransactionService
+	lookup *domain.Transaction
+}
+
+func (s *resolvingService) ResolveEntities(ctx context.Context, tx *domain.Transaction) (*domain.TransactionEntities, map[string]error, error) {
+	s.lookup = tx
+	return &domain.TransactionEntities{AccountID: 10, Account: tx.Account}, nil, nil
+}
+
+func TestHandleValidateTransaction(t *testing.T) {
+	date := time.Now().Format(dateLayout)
+	tests := []struct {
+		name         string
+		body         string
+		wantValid    bool
+		wantProblems []validationProblem
+	}{
+		{
+			name:      "valid",
+			body:      `{"account": "Everyday", "category": "Groceries", "merchant": "Shop", "value": "-5", "date": "` + date + `"}`,
+			wantValid: true,
+		},
+		{
+			name:         "mistyped optional param with every other param valid",
+			body:         `{"account": "Everyday", "category": "Groceries", "merchant": "Shop", "value": "-5", "date": "` + date + `", "needs_review": "yes"}`,
+			wantProblems: []validationProblem{{Field: "needs_review", Error: "must be a boolean, got string"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &resolvingService{}
+			h := NewHTTPHandler(svc, "key", Options{})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/validate", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			h.handleValidateTransaction(context.Background(), recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+			}
+			var result validationResult
+			if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if result.Valid != tt.wantValid || (result.Transaction != nil) != tt.wantValid {
+				t.Errorf("valid = %v, transaction = %+v, want valid %v", result.Valid, result.Transaction, tt.wantValid)
+			}
+			if len(result.Problems) > 0 || len(tt.wantProblems) > 0 {
+				if !reflect.DeepEqual(result.Problems, tt.wantProblems) {
+					t.Errorf("problems = %+v, want %+v", result.Problems, tt.wantProblems)
+				}
+			}
+			if svc.lookup == nil || svc.lookup.Account != "Everyday" || svc.lookup.Category != "Groceries" {
+				t.Errorf("looked up %+v, want the account and category", svc.lookup)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// dateLayout is the date format expected in transaction params
+const dateLayout = "2006-01-02"
+
+// Date-time layouts also accepted in the date param: ISO 8601 with a UTC offset (or Z),
+// and naive local times without one. Seconds are optional.
+var (
+	dateTimeOffsetLayouts = []string{time.RFC3339, "2006-01-02T15:04Z07:00"}
+	dateTimeNaiveLayouts  = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+)
+
+// naiveDateTimeLayout is how a date-time without a UTC offset is passed on
+const naiveDateTimeLayout = "2006-01-02T15:04:05"
+
+// plainDecimalPattern matches an optionally signed decimal number without an exponent,
+// so forms strconv.ParseFloat also accepts, like "NaN", "Inf", "1e5" and "0x1p4", are rejected
+var plainDecimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// parseTransactionDate parses the date param, a plain date or an ISO 8601 date-time.
+// It returns the calendar day as written, and the normalized date-time ("" for a plain date).
+func parseTransactionDate(value string) (day time.Time, dateTime string, ok bool) {
+	if day, err := time.Parse(dateLayout, value); err == nil {
+		return day, "", true
+	}
+	for _, layout := range dateTimeOffsetLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return startOfDay(t), t.Format(time.RFC3339), true
+		}
+	}
+	for _, layout := range dateTimeNaiveLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return startOfDay(t), t.Format(naiveDateTimeLayout), true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// startOfDay returns midnight UTC on the calendar day t falls on in its own location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// requestError describes why an incoming request was rejected
+type requestError struct {
+	statusCode int
+	message    string
+	// fields maps each offending param to the reason it was rejected
+	fields map[string]string
+}
+
+// newRequestError creates a request error with a plain-text message
+func newRequestError(statusCode int, message string) *requestError {
+	return &requestError{statusCode: statusCode, message: message}
+}
+
+// writeRequestError writes a request error, as JSON when it carries field errors
+func writeRequestError(w http.ResponseWriter, reqErr *requestError) {
+	if len(reqErr.fields) == 0 {
+		w.WriteHeader(reqErr.statusCode)
+		fmt.Fprintln(w, reqErr.message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reqErr.statusCode)
+	json.NewEncoder(w).Encode(map[string]any{
+		"errors": reqErr.fields,
+	})
+}
+
+// unknownField extracts the field name from a decoder's unknown-field error
+func unknownField(err error) (string, bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	field, unquoteErr := strconv.Unquote(quoted)
+	if unquoteErr != nil {
+		return quoted, true
+	}
+	return field, true
+}
+
+// validateTransactionParams validates and normalizes every transaction field.
+// All problems are collected rather than stopping at the first one.
+func (h *HTTPHandler) validateTransactionParams(ctx context.Context, txParams domain.TransactionParams) (*domain.Transaction, map[string]string) {
+	fieldErrors := make(map[string]string)
+
+	if txParams.Account == "" {
+		fieldErrors["account"] = "required"
+	}
+
+	// A category ID can stand in for the category title
+	if txParams.CategoryID != nil {
+		if *txParams.CategoryID <= 0 {
+			fieldErrors["category_id"] = "must be a positive integer"
+		}
+	} else if txParams.Category == "" && !h.options.CategoryOptional {
+		fieldErrors["category"] = "required"
+	}
+
+	// Normalize the merchant name
+	merchant := normalizeMerchant(txParams.Merchant, h.options.TitleCaseMerchant)
+	if txParams.Merchant == "" {
+		fieldErrors["merchant"] = "required"
+	} else if merchant == "" {
+		fieldErrors["merchant"] = "must not be blank"
+	}
+
+	// The payee is optional, normalized like the merchant it stands in for
+	payee := normalizeMerchant(txParams.Payee, h.options.TitleCaseMerchant)
+	if txParams.Payee != "" && payee == "" {
+		fieldErrors["payee"] = "must not be blank"
+	}
+
+	amount, reason := normalizeAmount(string(txParams.Value), h.options.CurrencySymbols)
+	if reason == "" {
+		amount, reason = h.checkAmountDecimals(amount)
+	}
+	if reason != "" {
+		fieldErrors["value"] = reason
+	}
+
+	// Currency is optional on its own, but a foreign amount needs one
+	currency := strings.ToUpper(strings.TrimSpace(txParams.Currency))
+	if currency != "" {
+		if reason := h.checkCurrency(currency); reason != "" {
+			fieldErrors["currency"] = reason
+		}
+	}
+
+	var foreignAmount string
+	if txParams.ForeignAmount != "" {
+		foreignAmount, reason = normalizeAmount(string(txParams.ForeignAmount), h.options.CurrencySymbols)
+		if reason == "" {
+			foreignAmount, reason = h.checkAmountDecimals(foreignAmount)
+		}
+		if reason != "" {
+			fieldErrors["foreign_amount"] = reason
+		} else if currency == "" {
+			fieldErrors["currency"] = "required with foreign_amount"
+		}
+	}
+
+	// A currency symbol in the amount given in currency should belong to it: that's
+	// foreign_amount when set, otherwise value
+	if currency != "" && fieldErrors["currency"] == "" {
+		field, raw := "value", string(txParams.Value)
+		if txParams.ForeignAmount != "" {
+			field, raw = "foreign_amount", string(txParams.ForeignAmount)
+		}
+		if fieldErrors[field] == "" {
+			if reason := h.checkSymbolMismatch(raw, currency); reason != "" {
+				fieldErrors[field] = reason
+			}
+		}
+	}
+
+	// The account currency only picks between accounts sharing a name, so any code will do
+	accountCurrency := strings.ToUpper(strings.TrimSpace(txParams.AccountCurrency))
+	if accountCurrency != "" && !isCurrencyCode(accountCurrency) {
+		fieldErrors["account_currency"] = "invalid currency code, expected 3 letters"
+	}
+
+	var day time.Time
+	var dateTime string
+	if txParams.Date == "" {
+		fieldErrors["date"] = "required"
+	} else if parsed, normalized, ok := parseTransactionDate(txParams.Date); !ok {
+		fieldErrors["date"] = "invalid date, expected YYYY-MM-DD or an ISO 8601 date-time"
+	} else if reason := h.checkDateRange(ctx, parsed); reason != "" {
+		fieldErrors["date"] = reason
+	} else {
+		day, dateTime = parsed, normalized
+	}
+
+	// The source may become a label, and labels are comma-separated
+	source := strings.TrimSpace(txParams.Source)
+	if strings.Contains(source, ",") {
+		fieldErrors["source"] = "must not contain commas"
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	return &domain.Transaction{
+		Account:         txParams.Account,
+		Category:        txParams.Category,
+		Merchant:        merchant,
+		Payee:           payee,
+		Amount:          amount,
+		Date:            day.Format(dateLayout),
+		DateTime:        dateTime,
+		Currency:        currency,
+		ForeignAmount:   foreignAmount,
+		AccountCurrency: accountCurrency,
+		CategoryID:      txParams.CategoryID,
+		Upsert:          txParams.Upsert,
+		NeedsReview:     txParams.NeedsReview,
+		IsTransfer:      txParams.IsTransfer,
+		Source:          source,
+	}, nil
+}
+
+// validateCheckedParams validates transaction params like validateTransactionParams, adding
+// the type errors checkParamTypes reported before they were decoded. A mistyped param's
+// type error replaces any error from it looking omitted, and the transaction is nil when
+// there is any field error.
+func (h *HTTPHandler) validateCheckedParams(ctx context.Context, txParams domain.TransactionParams, typeErrors map[string]string) (*domain.Transaction, map[string]string) {
+	tx, fieldErrors := h.validateTransactionParams(ctx, txParams)
+	if len(typeErrors) == 0 {
+		return tx, fieldErrors
+	}
+
+	// The other params may all be valid, leaving no field errors yet
+	if fieldErrors == nil {
+		fieldErrors = make(map[string]string, len(typeErrors))
+	}
+	for name, reason := range typeErrors {
+		fieldErrors[name] = reason
+	}
+	return nil, fieldErrors
+}
+
+// accountTypes are the PocketSmith account types
+var accountTypes = []string{
+	"bank", "credits", "cash", "loans", "mortgage", "stocks",
+	"vehicle", "property", "insurance", "other_asset", "other_liability",
+}
+
+// validateAccountParams validates and normalizes the params for creating an account.
+// All problems are collected rather than stopping at the first one.
+func (h *HTTPHandler) validateAccountParams(params *domain.AccountParams) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		fieldErrors["name"] = "required"
+	}
+
+	params.Currency = strings.ToUpper(strings.TrimSpace(params.Currency))
+	if params.Currency == "" {
+		fieldErrors["currency"] = "required"
+	} else if reason := h.checkCurrency(params.Currency); reason != "" {
+		fieldErrors["currency"] = reason
+	}
+
+	params.Type = strings.ToLower(strings.TrimSpace(params.Type))
+	if params.Type == "" {
+		fieldErrors["type"] = "required"
+	} else if !slices.Contains(accountTypes, params.Type) {
+		fieldErrors["type"] = "unknown account type, expected one of: " + strings.Join(accountTypes, ", ")
+	}
+
+	if params.InstitutionID != nil && *params.InstitutionID <= 0 {
+		fieldErrors["institution_id"] = "must be a positive integer"
+	}
+
+	return fieldErrors
+}
+
+// checkCurrency checks an uppercase currency code against allowed_currencies and the
+// ISO 4217 format. It returns a reason the code is invalid, or an empty string.
+func (h *HTTPHandler) checkCurrency(currency string) string {
+	switch {
+	case !currencyAllowed(currency, h.options.AllowedCurrencies):
+		return fmt.Sprintf("%s is not an allowed currency, expected one of: %s", currency, strings.Join(h.options.AllowedCurrencies, ", "))
+	case !isCurrencyCode(currency):
+		return "invalid currency code, expected 3 letters"
+	}
+	return ""
+}
+
+// checkDateRange checks a transaction date against the configured window around today.
+// Dates are compared as calendar days, today being the one in the user's time zone (UTC
+// when it isn't known), so a user ahead of UTC can log today's spend before UTC catches up.
+// It returns a reason the date is out of range, or an empty string.
+func (h *HTTPHandler) checkDateRange(ctx context.Context, date time.Time) string {
+	if h.options.MaxDateAgeDays <= 0 && h.options.MaxDateAheadDays <= 0 {
+		return ""
+	}
+	now := h.options.Clock.Now().UTC()
+	if zone := h.service.TimeZone(ctx); zone != nil {
+		now = now.In(zone)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if h.options.MaxDateAgeDays > 0 {
+		earliest := today.AddDate(0, 0, -h.options.MaxDateAgeDays)
+		if date.Before(earliest) {
+			return fmt.Sprintf("must be no more than %d days ago (earliest %s)", h.options.MaxDateAgeDays, earliest.Format(dateLayout))
+		}
+	}
+	if h.options.MaxDateAheadDays > 0 {
+		latest := today.AddDate(0, 0, h.options.MaxDateAheadDays)
+		if date.After(latest) {
+			return fmt.Sprintf("must be no more than %d days ahead (latest %s)", h.options.MaxDateAheadDays, latest.Format(dateLayout))
+		}
+	}
+	return ""
+}
+
+// normalizeAmount strips currency symbols, replaces comma decimal separators with dots,
+// and checks the result is a plain decimal number.
+// It returns the normalized amount, or a reason the amount is invalid.
+func normalizeAmount(raw string, symbols []string) (string, string) {
+	amount := stripCurrencySymbols(raw, symbols)
+	amount = strings.ReplaceAll(amount, ",", ".")
+	switch {
+	case amount == "":
+		return "", "required"
+	case strings.Count(amount, ".") > 1:
+		return "", "multiple decimal separators"
+	}
+	if !plainDecimalPattern.MatchString(amount) {
+		return "", "not a number"
+	}
+	return amount, ""
+}
+
+// checkAmountDecimals limits a normalized amount to MaxAmountDecimals fractional digits,
+// ignoring trailing zeros, when the limit is set. Longer amounts are rounded on their decimal digits with
+// RoundAmount, otherwise rejected with a reason.
+func (h *HTTPHandler) checkAmountDecimals(amount string) (string, string) {
+	limit := h.options.MaxAmountDecimals
+	if limit <= 0 || domain.DecimalPlaces(amount) <= limit {
+		return amount, ""
+	}
+	if h.options.RoundAmount {
+		rounded, _ := domain.RoundDecimal(amount, limit)
+		return rounded, ""
+	}
+	return "", fmt.Sprintf("at most %d decimal places allowed", limit)
+}
+
+// isCurrencyCode reports whether code looks like an uppercase ISO 4217 currency code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeMerchant trims the merchant name and collapses internal whitespace
+func normalizeMerchant(merchant string, titleCase bool) string {
+	words := strings.Fields(merchant)
+	if titleCase {
+		for i, word := range words {
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+	}
+	return strings.Join(words, " ")
+}
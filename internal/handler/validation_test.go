@@ -0,0 +1,489 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+func TestParseTransactionDate(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantDay      string
+		wantDateTime string
+		wantOK       bool
+	}{
+		{value: "2025-01-13", wantDay: "2025-01-13", wantOK: true},
+		{value: "2025-01-13T23:30:00+10:00", wantDay: "2025-01-13", wantDateTime: "2025-01-13T23:30:00+10:00", wantOK: true},
+		{value: "2025-01-13T08:15Z", wantDay: "2025-01-13", wantDateTime: "2025-01-13T08:15:00Z", wantOK: true},
+		{value: "2025-01-13T08:15", wantDay: "2025-01-13", wantDateTime: "2025-01-13T08:15:00", wantOK: true},
+		{value: "13/01/2025"},
+		{value: "2025-02-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			day, dateTime, ok := parseTransactionDate(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseTransactionDate(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := day.Format(dateLayout); got != tt.wantDay || dateTime != tt.wantDateTime {
+				t.Errorf("parseTransactionDate(%q) = %s, %q, want %s, %q", tt.value, got, dateTime, tt.wantDay, tt.wantDateTime)
+			}
+		})
+	}
+}
+
+func TestNormalizeAmount(t *testing.T) {
+	symbols, _ := ParseCurrencySymbols("")
+	tests := []struct {
+		raw        string
+		want       string
+		wantReason string
+	}{
+		{raw: "12.50", want: "12.50"},
+		{raw: "-5,50", want: "-5.50"},
+		{raw: "-$5.50", want: "-5.50"},
+		{raw: "$-5.50", want: "-5.50"},
+		{raw: "R$ 10", want: "10"},
+		{raw: "10 kr", want: "10"},
+		{raw: "", wantReason: "required"},
+		{raw: "$", wantReason: "required"},
+		{raw: "1.000,50", wantReason: "multiple decimal separators"},
+		{raw: "twelve", wantReason: "not a number"},
+		{raw: ".5", want: ".5"},
+		{raw: "+5.", want: "+5."},
+		{raw: "NaN", wantReason: "not a number"},
+		{raw: "-Inf", wantReason: "not a number"},
+		{raw: "infinity", wantReason: "not a number"},
+		{raw: "1e5", wantReason: "not a number"},
+		{raw: "0x1p4", wantReason: "not a number"},
+		{raw: "1_000", wantReason: "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, reason := normalizeAmount(tt.raw, symbols)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("normalizeAmount(%q) = %q, %q, want %q, %q", tt.raw, got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckAmountDecimals(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    Options
+		amount     string
+		want       string
+		wantReason string
+	}{
+		{name: "unlimited", options: Options{}, amount: "1.23456", want: "1.23456"},
+		{name: "within the limit", options: Options{MaxAmountDecimals: 2}, amount: "1.23", want: "1.23"},
+		{name: "trailing zeros ignored", options: Options{MaxAmountDecimals: 2}, amount: "1.2300", want: "1.2300"},
+		{name: "rejected", options: Options{MaxAmountDecimals: 2}, amount: "1.234", wantReason: "at most 2 decimal places allowed"},
+		{name: "rounded", options: Options{MaxAmountDecimals: 2, RoundAmount: true}, amount: "1.236", want: "1.24"},
+		{name: "half rounded on the digits, not a float", options: Options{MaxAmountDecimals: 2, RoundAmount: true}, amount: "2.675", want: "2.68"},
+		{name: "large amount keeps its digits", options: Options{MaxAmountDecimals: 2, RoundAmount: true}, amount: "-98765432109876543.215", want: "-98765432109876543.22"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "", tt.options)
+			got, reason := h.checkAmountDecimals(tt.amount)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("checkAmountDecimals(%q) = %q, %q, want %q, %q", tt.amount, got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := []struct {
+		merchant  string
+		titleCase bool
+		want      string
+	}{
+		{merchant: "  Corner   Store ", want: "Corner Store"},
+		{merchant: "CORNER store", titleCase: true, want: "Corner Store"},
+		{merchant: "éclair café", titleCase: true, want: "Éclair Café"},
+		{merchant: "   ", want: ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeMerchant(tt.merchant, tt.titleCase); got != tt.want {
+			t.Errorf("normalizeMerchant(%q, %v) = %q, want %q", tt.merchant, tt.titleCase, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCurrency(t *testing.T) {
+	h := NewHTTPHandler(nil, "", Options{AllowedCurrencies: []string{"AUD", "EUR"}})
+	tests := map[string]string{
+		"AUD": "",
+		"USD": "USD is not an allowed currency, expected one of: AUD, EUR",
+		"EU":  "EU is not an allowed currency, expected one of: AUD, EUR",
+	}
+	for currency, want := range tests {
+		if got := h.checkCurrency(currency); got != want {
+			t.Errorf("checkCurrency(%q) = %q, want %q", currency, got, want)
+		}
+	}
+	if got := NewHTTPHandler(nil, "", Options{}).checkCurrency("AU1"); got != "invalid currency code, expected 3 letters" {
+		t.Errorf("checkCurrency(AU1) = %q", got)
+	}
+}
+
+func TestValidateTransactionParams(t *testing.T) {
+	now := time.Date(2025, 1, 13, 23, 0, 0, 0, time.UTC)
+	symbols, _ := ParseCurrencySymbols("")
+	h := NewHTTPHandler(&zoneService{}, "", Options{
+		CurrencySymbols:   symbols,
+		MaxDateAgeDays:    30,
+		MaxDateAheadDays:  1,
+		TitleCaseMerchant: true,
+		OnSymbolMismatch:  SymbolMismatchReject,
+		Clock:             clock.NewFake(now),
+	})
+	valid := domain.TransactionParams{
+		Account:  "Everyday",
+		Category: "Groceries",
+		Merchant: "corner  store",
+		Value:    "-12,50",
+		Date:     "2025-01-13",
+	}
+
+	tx, fieldErrors := h.validateTransactionParams(context.Background(), valid)
+	if len(fieldErrors) > 0 {
+		t.Fatalf("validateTransactionParams() field errors = %v", fieldErrors)
+	}
+	if tx.Merchant != "Corner Store" || tx.Amount != "-12.50" || tx.Date != "2025-01-13" {
+		t.Errorf("validateTransactionParams() = %+v", tx)
+	}
+
+	// The account currency is kept apart from the currency of a foreign amount
+	foreign := valid
+	foreign.Currency, foreign.ForeignAmount, foreign.AccountCurrency = "eur", "-11.20", " usd"
+	tx, fieldErrors = h.validateTransactionParams(context.Background(), foreign)
+	if len(fieldErrors) > 0 {
+		t.Fatalf("validateTransactionParams() field errors = %v", fieldErrors)
+	}
+	if tx.Currency != "EUR" || tx.AccountCurrency != "USD" {
+		t.Errorf("validateTransactionParams() currency = %q, account currency = %q, want EUR and USD", tx.Currency, tx.AccountCurrency)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *domain.TransactionParams)
+		want   map[string]string
+	}{
+		{
+			name: "required fields",
+			modify: func(p *domain.TransactionParams) {
+				*p = domain.TransactionParams{}
+			},
+			want: map[string]string{"account": "required", "category": "required", "merchant": "required", "value": "required", "date": "required"},
+		},
+		{
+			name:   "blank merchant",
+			modify: func(p *domain.TransactionParams) { p.Merchant = "   " },
+			want:   map[string]string{"merchant": "must not be blank"},
+		},
+		{
+			name:   "too old",
+			modify: func(p *domain.TransactionParams) { p.Date = "2024-12-13" },
+			want:   map[string]string{"date": "must be no more than 30 days ago (earliest 2024-12-14)"},
+		},
+		{
+			name:   "too far ahead",
+			modify: func(p *domain.TransactionParams) { p.Date = "2025-01-15" },
+			want:   map[string]string{"date": "must be no more than 1 days ahead (latest 2025-01-14)"},
+		},
+		{
+			name:   "foreign amount without currency",
+			modify: func(p *domain.TransactionParams) { p.ForeignAmount = "10" },
+			want:   map[string]string{"currency": "required with foreign_amount"},
+		},
+		{
+			name: "symbol mismatch",
+			modify: func(p *domain.TransactionParams) {
+				p.Value = "$12.50"
+				p.Currency = "eur"
+			},
+			want: map[string]string{"value": "currency symbol $ doesn't match currency EUR"},
+		},
+		{
+			name:   "invalid account currency",
+			modify: func(p *domain.TransactionParams) { p.AccountCurrency = "euro" },
+			want:   map[string]string{"account_currency": "invalid currency code, expected 3 letters"},
+		},
+		{
+			name:   "comma in source",
+			modify: func(p *domain.TransactionParams) { p.Source = "bank,csv" },
+			want:   map[string]string{"source": "must not contain commas"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.modify(&params)
+			tx, fieldErrors := h.validateTransactionParams(context.Background(), params)
+			if tx != nil {
+				t.Errorf("validateTransactionParams() = %+v, want nil", tx)
+			}
+			if !reflect.DeepEqual(fieldErrors, tt.want) {
+				t.Errorf("validateTransactionParams() field errors = %v, want %v", fieldErrors, tt.want)
+			}
+		})
+	}
+}
+
+// zoneService reports a fixed user time zone, nil meaning it isn't known.
+// Methods it doesn't override panic through the nil embedded service.
+type zoneService struct {
+	service.TransactionService
+	zone *time.Location
+}
+
+func (s *zoneService) TimeZone(ctx context.Context) *time.Location {
+	return s.zone
+}
+
+func TestCheckDateRange(t *testing.T) {
+	// 23:00 UTC on the 13th is already noon on the 14th in Auckland
+	now := time.Date(2025, 1, 13, 23, 0, 0, 0, time.UTC)
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		zone *time.Location
+		date string
+		want string
+	}{
+		{name: "unknown zone in window", date: "2025-01-14"},
+		{name: "unknown zone oldest day", date: "2024-12-14"},
+		{name: "unknown zone too old", date: "2024-12-13", want: "must be no more than 30 days ago (earliest 2024-12-14)"},
+		{name: "unknown zone too far ahead", date: "2025-01-15", want: "must be no more than 1 days ahead (latest 2025-01-14)"},
+		{name: "user zone today", zone: auckland, date: "2025-01-14"},
+		{name: "user zone in window", zone: auckland, date: "2025-01-15"},
+		{name: "user zone oldest day", zone: auckland, date: "2024-12-15"},
+		{name: "user zone too old", zone: auckland, date: "2024-12-14", want: "must be no more than 30 days ago (earliest 2024-12-15)"},
+		{name: "user zone too far ahead", zone: auckland, date: "2025-01-16", want: "must be no more than 1 days ahead (latest 2025-01-15)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(&zoneService{zone: tt.zone}, "", Options{
+				MaxDateAgeDays:   30,
+				MaxDateAheadDays: 1,
+				Clock:            clock.NewFake(now),
+			})
+			date, _, ok := parseTransactionDate(tt.date)
+			if !ok {
+				t.Fatalf("parseTransactionDate(%q) failed", tt.date)
+			}
+			if got := h.checkDateRange(context.Background(), date); got != tt.want {
+				t.Errorf("checkDateRange(%s) = %q, want %q", tt.date, got, tt.want)
+			}
+		})
+	}
+
+	// Without a window the user isn't looked up at all, so the nil service isn't called
+	h := NewHTTPHandler(nil, "", Options{Clock: clock.NewFake(now)})
+	if got := h.checkDateRange(context.Background(), now); got != "" {
+		t.Errorf("checkDateRange() without a window = %q, want none", got)
+	}
+}
+
+func TestParseTransactionParams(t *testing.T) {
+	h := NewHTTPHandler(nil, "", Options{})
+	valid := func() map[string]any {
+		return map[string]any{
+			"account":  "Everyday",
+			"category": "Groceries",
+			"merchant": "Corner Store",
+			"value":    "-12.50",
+			"date":     time.Now().Format(dateLayout),
+		}
+	}
+
+	tx, reqErr := h.parseTransactionParams(context.Background(), valid())
+	if reqErr != nil || tx == nil {
+		t.Fatalf("parseTransactionParams() = %+v, %+v, want a transaction", tx, reqErr)
+	}
+
+	tests := []struct {
+		name       string
+		params     map[string]any
+		wantFields map[string]string
+	}{
+		{
+			name:       "mistyped optional param with every other param valid",
+			params:     map[string]any{"upsert": "yes"},
+			wantFields: map[string]string{"upsert": "must be a boolean, got string"},
+		},
+		{
+			name:       "mistyped required param replaces its required error",
+			params:     map[string]any{"merchant": json.Number("12")},
+			wantFields: map[string]string{"merchant": "must be a string, got number"},
+		},
+		{
+			name:       "mistyped and invalid params together",
+			params:     map[string]any{"category_id": true, "date": "yesterday"},
+			wantFields: map[string]string{"category_id": "must be an integer, got boolean", "date": "invalid date, expected YYYY-MM-DD or an ISO 8601 date-time"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid()
+			for name, value := range tt.params {
+				params[name] = value
+			}
+			tx, reqErr := h.parseTransactionParams(context.Background(), params)
+			if tx != nil || reqErr == nil {
+				t.Fatalf("parseTransactionParams() = %+v, %+v, want a request error", tx, reqErr)
+			}
+			if reqErr.statusCode != http.StatusUnprocessableEntity || !reflect.DeepEqual(reqErr.fields, tt.wantFields) {
+				t.Errorf("parseTransactionParams() error = %d %v, want 422 %v", reqErr.statusCode, reqErr.fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestStrictParams(t *testing.T) {
+	valid := map[string]any{"account": "Everyday", "category": "Groceries", "merchant": "Corner Store", "value": "-5.00", "date": "2025-01-13"}
+	typo := map[string]any{"account": "Everyday", "category": "Groceries", "merchnt": "Corner Store", "value": "-5.00", "date": "2025-01-13"}
+	tests := []struct {
+		name       string
+		strict     bool
+		params     map[string]any
+		wantFields map[string]string
+	}{
+		{name: "lenient, valid", params: valid},
+		{name: "lenient, typo", params: typo, wantFields: map[string]string{"merchant": "required"}},
+		{name: "strict, valid", strict: true, params: valid},
+		{name: "strict, typo", strict: true, params: typo, wantFields: map[string]string{"merchnt": "unknown field"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPHandler(nil, "", Options{StrictParams: tt.strict})
+
+			_, reqErr := h.parseTransactionParams(context.Background(), tt.params)
+			if tt.wantFields == nil {
+				if reqErr != nil {
+					t.Fatalf("parseTransactionParams() error = %+v", reqErr)
+				}
+				return
+			}
+			if reqErr == nil || reqErr.statusCode != http.StatusUnprocessableEntity {
+				t.Fatalf("parseTransactionParams() error = %+v, want a 422", reqErr)
+			}
+			if !reflect.DeepEqual(reqErr.fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", reqErr.fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateAccountParams(t *testing.T) {
+	h := NewHTTPHandler(nil, "", Options{AllowedCurrencies: []string{"AUD", "NZD"}})
+	tests := []struct {
+		name       string
+		params     domain.AccountParams
+		wantFields []string
+	}{
+		{name: "valid", params: domain.AccountParams{Name: " Savings ", Currency: "aud", Type: "Bank"}},
+		{name: "missing everything", wantFields: []string{"currency", "name", "type"}},
+		{name: "currency not allowed", params: domain.AccountParams{Name: "Savings", Currency: "USD", Type: "bank"}, wantFields: []string{"currency"}},
+		{name: "unknown type", params: domain.AccountParams{Name: "Savings", Currency: "AUD", Type: "piggy bank"}, wantFields: []string{"type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			fieldErrors := h.validateAccountParams(&params)
+
+			var fields []string
+			for field := range fieldErrors {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("validateAccountParams() = %v, want errors for %v", fieldErrors, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestParseTransactionParamsIsTransfer(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   bool
+	}{
+		{name: "omitted", want: false},
+		{name: "false", params: map[string]any{"is_transfer": false}, want: false},
+		{name: "true", params: map[string]any{"is_transfer": true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]any{"account": "Everyday", "category": "Groceries", "merchant": "Card Payment", "value": "-500.00", "date": "2025-01-13"}
+			for key, value := range tt.params {
+				params[key] = value
+			}
+			h := NewHTTPHandler(nil, "", Options{})
+
+			tx, reqErr := h.parseTransactionParams(context.Background(), params)
+			if reqErr != nil {
+				t.Fatalf("parseTransactionParams() error = %+v", reqErr)
+			}
+			if tx.IsTransfer != tt.want {
+				t.Errorf("IsTransfer = %v, want %v", tx.IsTransfer, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTransactionParamsPayee(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]any
+		want      string
+		wantField bool
+	}{
+		{name: "omitted", want: ""},
+		{name: "sent", params: map[string]any{"payee": "  Amazon "}, want: "Amazon"},
+		{name: "blank", params: map[string]any{"payee": "   "}, wantField: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]any{"account": "Everyday", "category": "Groceries", "merchant": "AMZN Mktp", "value": "-5.00", "date": "2025-01-13"}
+			for key, value := range tt.params {
+				params[key] = value
+			}
+			h := NewHTTPHandler(nil, "", Options{})
+
+			tx, reqErr := h.parseTransactionParams(context.Background(), params)
+			if tt.wantField {
+				if reqErr == nil || reqErr.fields["payee"] == "" {
+					t.Fatalf("parseTransactionParams() error = %+v, want a payee field error", reqErr)
+				}
+				return
+			}
+			if reqErr != nil {
+				t.Fatalf("parseTransactionParams() error = %+v", reqErr)
+			}
+			if tx.Payee != tt.want || tx.Merchant != "AMZN Mktp" {
+				t.Errorf("payee, merchant = %q, %q, want %q, %q", tx.Payee, tx.Merchant, tt.want, "AMZN Mktp")
+			}
+		})
+	}
+}
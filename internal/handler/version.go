@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pocketsmith-proxy/internal/buildinfo"
+)
+
+// handleVersion handles GET /version
+func (h *HTTPHandler) handleVersion(w http.ResponseWriter, r *http.Request) {
+	statusCode := http.StatusOK
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(buildinfo.Get())
+	h.logRequest(r.Method, r.URL.Path, statusCode)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/buildinfo"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// idleService has seen no PocketSmith calls, so it reports no rate limit.
+// Methods it doesn't override panic through the nil embedded service.
+type idleService struct {
+	service.TransactionService
+}
+
+func (s *idleService) RateLimit() domain.RateLimit {
+	return domain.RateLimit{}
+}
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		commit    string
+		buildTime string
+	}{
+		{name: "placeholders", version: "dev", commit: "unknown", buildTime: "unknown"},
+		{name: "stamped", version: "1.4.0", commit: "3f2c1ab", buildTime: "2025-01-13T08:15:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(version, commit, buildTime string) {
+				buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime = version, commit, buildTime
+			}(buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime)
+			buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime = tt.version, tt.commit, tt.buildTime
+
+			// No client key is sent, since the endpoint is open
+			h := NewHTTPHandler(&idleService{}, "key", Options{})
+			recorder := httptest.NewRecorder()
+			h.Handle(recorder, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", recorder.Code)
+			}
+			var info map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+				t.Fatalf("body %s is not JSON: %v", recorder.Body, err)
+			}
+			want := map[string]string{"version": tt.version, "commit": tt.commit, "build_time": tt.buildTime}
+			for field, value := range want {
+				if info[field] != value {
+					t.Errorf("%s = %v, want %q", field, info[field], value)
+				}
+			}
+		})
+	}
+}
@@ -4,127 +4,240 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/fermyon/spin/sdk/go/v2/redis"
+	"github.com/pocketsmith-proxy/internal/clock"
 	"github.com/pocketsmith-proxy/internal/domain"
 )
 
 const (
 	// Cache TTL in seconds (86400 = 24 hours)
 	cacheTTL = 86400
+
+	// flushScanCount is the COUNT hint of each SCAN while flushing the cache
+	flushScanCount = 500
+
+	// JobTTL is how long in seconds a batch job is kept after it was last saved (24 hours)
+	JobTTL = 86400
 )
 
+// cacheStatsEntities are the entities whose cache lookups are counted
+var cacheStatsEntities = []string{"user", "entities"}
+
+// cachedEntities is the cache entry holding a user's transaction accounts and categories.
+// Keeping both in one value means a reader never sees one list refreshed and the other stale.
+type cachedEntities struct {
+	Accounts   []domain.TransactionAccount `json:"accounts"`
+	Categories []domain.Category           `json:"categories"`
+}
+
 // CacheRepository defines the interface for cache operations
 type CacheRepository interface {
-	// User ID operations
-	GetUserID() (int, error)
-	SetUserID(userID int) error
-
-	// Transaction accounts operations
-	GetTransactionAccounts(userID int) ([]domain.TransactionAccount, error)
-	SetTransactionAccounts(userID int, accounts []domain.TransactionAccount) error
+	// User operations, caching the profile of the API key's user
+	GetUser() (*domain.User, error)
+	SetUser(user *domain.User) error
+
+	// Transaction accounts and categories operations. Both are cached in one entry, so
+	// they are always written, expire and are dropped together.
+	GetAccountsAndCategories(userID int) ([]domain.TransactionAccount, []domain.Category, error)
+	SetAccountsAndCategories(userID int, accounts []domain.TransactionAccount, categories []domain.Category) error
+	DeleteAccountsAndCategories(userID int) error
+	// UpdateCachedAccountBalance sets one cached account's current balance without refetching
+	// the accounts, keeping the remaining TTL. It fails on a cache miss or when the account
+	// isn't cached.
+	UpdateCachedAccountBalance(userID, accountID int, balance float64) error
+	// AddCachedCategory adds one new category to the cached tree without refetching it,
+	// keeping the remaining TTL. It fails on a cache miss or when the parent isn't cached.
+	AddCachedCategory(userID int, category domain.Category) error
+
+	// AcquireCooldown starts a named cooldown for a user lasting the given seconds,
+	// reporting false when one is already running
+	AcquireCooldown(userID int, name string, seconds int) (bool, error)
+	// ReleaseCooldown ends a named cooldown early, so it can be acquired again
+	ReleaseCooldown(userID int, name string) error
+
+	// Batch job operations. Jobs aren't cached copies of PocketSmith data: a job missing
+	// after JobTTL or a flush is gone for good.
+	GetJob(userID int, jobID string) (*domain.Job, error)
+	SetJob(userID int, job *domain.Job) error
+
+	// GetTTL returns the remaining TTL in seconds of a user's cached entity
+	GetTTL(userID int, entity domain.CacheEntity) (int, error)
+
+	// Flush deletes every cached entry under the namespace and returns how many keys were
+	// removed. Keys the proxy didn't write are left alone.
+	Flush() (int, error)
+
+	// GetCacheStats returns the cumulative cache hits and misses of the getters per entity,
+	// counted across instances and restarts. Backends that don't count return empty stats.
+	GetCacheStats() (domain.CacheStats, error)
+}
 
-	// Categories operations
-	GetCategories(userID int) ([]domain.Category, error)
-	SetCategories(userID int, categories []domain.Category) error
+// Options holds optional cache behaviors
+type Options struct {
+	// TTLJitter randomly spreads each TTL by up to this fraction (0.1 = ±10%, 0 disables)
+	TTLJitter float64
+	// Namespace prefixes every cache key, so deployments sharing a store don't collide
+	Namespace string
+	// RedisRetryAttempts is how many times a failing Redis operation is tried
+	// (defaults to DefaultRedisRetryAttempts, 1 disables retries)
+	RedisRetryAttempts int
+	// Clock tells the time (defaults to the wall clock)
+	Clock clock.Clock
 }
 
 // RedisCacheRepository implements CacheRepository using Redis
 type RedisCacheRepository struct {
-	client *redis.Client
+	client redisClient
+	// onceClient is the bare client, tried once, for commands that mustn't be repeated when
+	// their reply is lost (SET NX, INCR), and so counting lookups never waits on retries
+	onceClient redisClient
+	// statsFailed is set once counting failed, skipping the counters from then on
+	statsFailed atomic.Bool
+	options     Options
 }
 
 // NewRedisCacheRepository creates a new Redis-based cache repository
-func NewRedisCacheRepository(redisAddress string) CacheRepository {
+func NewRedisCacheRepository(redisAddress string, options Options) CacheRepository {
+	options.Clock = clock.OrReal(options.Clock)
+	client := redis.NewClient(redisAddress)
 	return &RedisCacheRepository{
-		client: redis.NewClient(redisAddress),
+		client:     newRetryingRedisClient(client, options.RedisRetryAttempts, options.Clock),
+		onceClient: client,
+		options:    options,
 	}
 }
 
-// GetUserID retrieves the cached user ID
-func (r *RedisCacheRepository) GetUserID() (int, error) {
-	data, err := r.client.Get("user:id")
-	if err != nil {
-		return 0, fmt.Errorf("redis get user:id: %w", err)
+// cacheKey builds a cache key, prefixed with the namespace when one is configured,
+// e.g. "user:42:entities" becomes "prod:user:42:entities"
+func cacheKey(namespace, format string, args ...any) string {
+	key := fmt.Sprintf(format, args...)
+	if namespace == "" {
+		return key
 	}
+	return namespace + ":" + key
+}
 
-	userID, err := strconv.Atoi(string(data))
-	if err != nil {
-		return 0, fmt.Errorf("parse user ID: %w", err)
+// ttl returns the TTL for a new cache entry, with jitter applied
+func (r *RedisCacheRepository) ttl() int {
+	return jitteredTTL(cacheTTL, r.options.TTLJitter)
+}
+
+// jitteredTTL spreads base uniformly within ±jitter so entries don't all expire at once
+func jitteredTTL(base int, jitter float64) int {
+	spread := int(float64(base) * jitter)
+	if spread <= 0 {
+		return base
 	}
 
-	log.Printf("Cache hit: user:id = %d", userID)
-	return userID, nil
+	ttl := base - spread + rand.Intn(2*spread+1)
+	if ttl < 1 {
+		return 1
+	}
+	return ttl
+}
+
+// GetUser retrieves the cached user
+func (r *RedisCacheRepository) GetUser() (*domain.User, error) {
+	user, err := r.readUser()
+	r.countLookup("user", err == nil)
+	return user, err
 }
 
-// SetUserID stores the user ID in cache with TTL
-func (r *RedisCacheRepository) SetUserID(userID int) error {
-	// Set the user ID
-	err := r.client.Set("user:id", []byte(strconv.Itoa(userID)))
+// readUser reads the cached user without counting the lookup
+func (r *RedisCacheRepository) readUser() (*domain.User, error) {
+	key := cacheKey(r.options.Namespace, "user:me")
+
+	data, err := r.client.Get(key)
 	if err != nil {
-		return fmt.Errorf("redis set user:id: %w", err)
+		if isWrongTypeError(err) {
+			return nil, r.discardMalformed(key, err)
+		}
+		return nil, fmt.Errorf("redis get %s: %w", key, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("cache miss: %s", key)
 	}
 
-	// Set expiration
-	_, err = r.client.Execute("EXPIRE", "user:id", cacheTTL)
-	if err != nil {
-		return fmt.Errorf("redis expire user:id: %w", err)
+	var user domain.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, r.discardMalformed(key, fmt.Errorf("unmarshal user: %w", err))
+	}
+	if user.ID == 0 {
+		return nil, r.discardMalformed(key, fmt.Errorf("user without an ID"))
 	}
 
-	log.Printf("Cache set: user:id = %d (TTL: %d seconds)", userID, cacheTTL)
-	return nil
+	log.Printf("Cache hit: %s = %d", key, user.ID)
+	return &user, nil
 }
 
-// GetTransactionAccounts retrieves cached transaction accounts for a user
-func (r *RedisCacheRepository) GetTransactionAccounts(userID int) ([]domain.TransactionAccount, error) {
-	key := fmt.Sprintf("user:%d:accounts", userID)
+// SetUser stores the user in cache with TTL
+func (r *RedisCacheRepository) SetUser(user *domain.User) error {
+	key := cacheKey(r.options.Namespace, "user:me")
 
-	// Get all fields from the hash
-	results, err := r.client.Execute("HGETALL", key)
+	data, err := json.Marshal(user)
 	if err != nil {
-		return nil, fmt.Errorf("redis hgetall %s: %w", key, err)
+		return fmt.Errorf("marshal user: %w", err)
 	}
 
-	// HGETALL returns alternating field/value pairs
-	if len(results) == 0 {
-		return nil, fmt.Errorf("cache miss: %s", key)
+	// Set the user
+	err = r.client.Set(key, data)
+	if err != nil {
+		return fmt.Errorf("redis set %s: %w", key, err)
 	}
 
-	// Parse the hash into a map
-	accountsMap := make(map[string]string)
-	for i := 0; i < len(results); i += 2 {
-		if i+1 >= len(results) {
-			break
-		}
-		field := string(results[i].Val.([]byte))
-		value := string(results[i+1].Val.([]byte))
-		accountsMap[field] = value
+	// Set expiration
+	ttl := r.ttl()
+	_, err = r.client.Execute("EXPIRE", key, ttl)
+	if err != nil {
+		return fmt.Errorf("redis expire %s: %w", key, err)
 	}
 
-	// Extract the JSON data (stored under "data" field)
-	jsonData, ok := accountsMap["data"]
-	if !ok {
-		return nil, fmt.Errorf("cache miss: %s (no data field)", key)
+	log.Printf("Cache set: %s = %d (TTL: %d seconds)", key, user.ID, ttl)
+	return nil
+}
+
+// GetAccountsAndCategories retrieves a user's cached transaction accounts and categories
+func (r *RedisCacheRepository) GetAccountsAndCategories(userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	entities, err := r.readEntities(userID)
+	r.countLookup("entities", err == nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities.Accounts, entities.Categories, nil
+}
+
+// readEntities reads a user's cached accounts and categories without counting the lookup
+func (r *RedisCacheRepository) readEntities(userID int) (*cachedEntities, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	jsonData, err := r.getHashData(key)
+	if err != nil {
+		return nil, err
 	}
 
-	var accounts []domain.TransactionAccount
-	if err := json.Unmarshal([]byte(jsonData), &accounts); err != nil {
-		return nil, fmt.Errorf("unmarshal accounts: %w", err)
+	var entities cachedEntities
+	if err := json.Unmarshal(jsonData, &entities); err != nil {
+		return nil, r.discardMalformed(key, fmt.Errorf("unmarshal entities: %w", err))
 	}
 
-	log.Printf("Cache hit: %s (%d accounts)", key, len(accounts))
-	return accounts, nil
+	log.Printf("Cache hit: %s (%d accounts, %d categories)", key, len(entities.Accounts), len(entities.Categories))
+	return &entities, nil
 }
 
-// SetTransactionAccounts stores transaction accounts in cache with TTL
-func (r *RedisCacheRepository) SetTransactionAccounts(userID int, accounts []domain.TransactionAccount) error {
-	key := fmt.Sprintf("user:%d:accounts", userID)
+// SetAccountsAndCategories stores a user's transaction accounts and categories in one hash
+// field with one TTL, so neither is ever cached without the other
+func (r *RedisCacheRepository) SetAccountsAndCategories(userID int, accounts []domain.TransactionAccount, categories []domain.Category) error {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
 
-	// Marshal accounts to JSON
-	data, err := json.Marshal(accounts)
+	// Marshal both lists to one JSON value
+	data, err := json.Marshal(cachedEntities{Accounts: accounts, Categories: categories})
 	if err != nil {
-		return fmt.Errorf("marshal accounts: %w", err)
+		return fmt.Errorf("marshal entities: %w", err)
 	}
 
 	// Store in hash
@@ -134,78 +247,366 @@ func (r *RedisCacheRepository) SetTransactionAccounts(userID int, accounts []dom
 	}
 
 	// Set expiration on the key
-	_, err = r.client.Execute("EXPIRE", key, cacheTTL)
+	ttl := r.ttl()
+	_, err = r.client.Execute("EXPIRE", key, ttl)
 	if err != nil {
 		return fmt.Errorf("redis expire %s: %w", key, err)
 	}
 
-	log.Printf("Cache set: %s (%d accounts, TTL: %d seconds)", key, len(accounts), cacheTTL)
+	log.Printf("Cache set: %s (%d accounts, %d categories, TTL: %d seconds)", key, len(accounts), len(categories), ttl)
 	return nil
 }
 
-// GetCategories retrieves cached categories for a user
-func (r *RedisCacheRepository) GetCategories(userID int) ([]domain.Category, error) {
-	key := fmt.Sprintf("user:%d:categories", userID)
-
-	// Get all fields from the hash
-	results, err := r.client.Execute("HGETALL", key)
+// UpdateCachedAccountBalance patches one account's balance in the cached accounts
+func (r *RedisCacheRepository) UpdateCachedAccountBalance(userID, accountID int, balance float64) error {
+	entities, err := r.readEntities(userID)
 	if err != nil {
-		return nil, fmt.Errorf("redis hgetall %s: %w", key, err)
+		return err
+	}
+	if err := setAccountBalance(entities.Accounts, accountID, balance); err != nil {
+		return err
 	}
 
-	// HGETALL returns alternating field/value pairs
-	if len(results) == 0 {
-		return nil, fmt.Errorf("cache miss: %s", key)
+	key, err := r.replaceEntities(userID, entities)
+	if err != nil {
+		return err
 	}
 
-	// Parse the hash into a map
-	categoriesMap := make(map[string]string)
-	for i := 0; i < len(results); i += 2 {
-		if i+1 >= len(results) {
-			break
+	log.Printf("Cache update: %s (account %d balance %.2f)", key, accountID, balance)
+	return nil
+}
+
+// setAccountBalance sets the current balance of the account with accountID in place,
+// failing when it isn't among accounts
+func setAccountBalance(accounts []domain.TransactionAccount, accountID int, balance float64) error {
+	for i := range accounts {
+		if accounts[i].ID == accountID {
+			accounts[i].CurrentBalance = balance
+			return nil
 		}
-		field := string(results[i].Val.([]byte))
-		value := string(results[i+1].Val.([]byte))
-		categoriesMap[field] = value
 	}
+	return fmt.Errorf("account %d not cached", accountID)
+}
 
-	// Extract the JSON data (stored under "data" field)
-	jsonData, ok := categoriesMap["data"]
-	if !ok {
-		return nil, fmt.Errorf("cache miss: %s (no data field)", key)
+// AddCachedCategory adds a category to the cached tree
+func (r *RedisCacheRepository) AddCachedCategory(userID int, category domain.Category) error {
+	entities, err := r.readEntities(userID)
+	if err != nil {
+		return err
+	}
+	entities.Categories, err = insertCategory(entities.Categories, category)
+	if err != nil {
+		return err
 	}
 
-	var categories []domain.Category
-	if err := json.Unmarshal([]byte(jsonData), &categories); err != nil {
-		return nil, fmt.Errorf("unmarshal categories: %w", err)
+	key, err := r.replaceEntities(userID, entities)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Cache hit: %s (%d categories)", key, len(categories))
-	return categories, nil
+	log.Printf("Cache update: %s (added category %d '%s')", key, category.ID, category.Title)
+	return nil
 }
 
-// SetCategories stores categories in cache with TTL
-func (r *RedisCacheRepository) SetCategories(userID int, categories []domain.Category) error {
-	key := fmt.Sprintf("user:%d:categories", userID)
+// replaceEntities overwrites a user's cached accounts and categories with a patched copy
+// and returns the key written
+func (r *RedisCacheRepository) replaceEntities(userID int, entities *cachedEntities) (string, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
 
-	// Marshal categories to JSON
-	data, err := json.Marshal(categories)
+	data, err := json.Marshal(entities)
 	if err != nil {
-		return fmt.Errorf("marshal categories: %w", err)
+		return "", fmt.Errorf("marshal entities: %w", err)
 	}
 
-	// Store in hash
-	_, err = r.client.Execute("HSET", key, "data", string(data))
+	// Overwriting the hash field leaves the key's expiry untouched
+	if _, err := r.client.Execute("HSET", key, "data", string(data)); err != nil {
+		return "", fmt.Errorf("redis hset %s: %w", key, err)
+	}
+	return key, nil
+}
+
+// DeleteAccountsAndCategories removes a user's cached transaction accounts and categories
+func (r *RedisCacheRepository) DeleteAccountsAndCategories(userID int) error {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	if _, err := r.client.Del(key); err != nil {
+		return fmt.Errorf("redis del %s: %w", key, err)
+	}
+
+	log.Printf("Cache delete: %s", key)
+	return nil
+}
+
+// AcquireCooldown starts a cooldown unless one is running, atomically with SET NX.
+// It isn't retried: a retry after a lost reply would find its own key and report
+// the cooldown as already running.
+func (r *RedisCacheRepository) AcquireCooldown(userID int, name string, seconds int) (bool, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:cooldown:%s", userID, name)
+
+	results, err := r.onceClient.Execute("SET", key, "1", "NX", "EX", seconds)
 	if err != nil {
-		return fmt.Errorf("redis hset %s: %w", key, err)
+		return false, fmt.Errorf("redis set %s: %w", key, err)
 	}
 
-	// Set expiration on the key
-	_, err = r.client.Execute("EXPIRE", key, cacheTTL)
+	// SET NX replies nil when the key already exists
+	return len(results) > 0 && results[0].Kind != redis.ResultKindNil, nil
+}
+
+// ReleaseCooldown deletes a cooldown's key
+func (r *RedisCacheRepository) ReleaseCooldown(userID int, name string) error {
+	key := cacheKey(r.options.Namespace, "user:%d:cooldown:%s", userID, name)
+
+	if _, err := r.client.Del(key); err != nil {
+		return fmt.Errorf("redis del %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetJob retrieves a user's batch job
+func (r *RedisCacheRepository) GetJob(userID int, jobID string) (*domain.Job, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:job:%s", userID, jobID)
+
+	data, err := r.client.Get(key)
 	if err != nil {
-		return fmt.Errorf("redis expire %s: %w", key, err)
+		if isWrongTypeError(err) {
+			return nil, r.discardMalformed(key, err)
+		}
+		return nil, fmt.Errorf("redis get %s: %w", key, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("cache miss: %s", key)
+	}
+
+	var job domain.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, r.discardMalformed(key, fmt.Errorf("unmarshal job: %w", err))
+	}
+	return &job, nil
+}
+
+// SetJob stores a user's batch job, restarting its JobTTL without jitter
+func (r *RedisCacheRepository) SetJob(userID int, job *domain.Job) error {
+	key := cacheKey(r.options.Namespace, "user:%d:job:%s", userID, job.ID)
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+
+	if _, err := r.client.Execute("SET", key, string(data), "EX", JobTTL); err != nil {
+		return fmt.Errorf("redis set %s: %w", key, err)
 	}
 
-	log.Printf("Cache set: %s (%d categories, TTL: %d seconds)", key, len(categories), cacheTTL)
+	log.Printf("Job set: %s (%d/%d processed, TTL: %d seconds)", key, job.Processed, job.Total, JobTTL)
 	return nil
 }
+
+// GetTTL retrieves the remaining TTL of a user's cached entity. Accounts and categories
+// share one entry, so both report its TTL.
+func (r *RedisCacheRepository) GetTTL(userID int, entity domain.CacheEntity) (int, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	results, err := r.client.Execute("TTL", key)
+	if err != nil {
+		return 0, fmt.Errorf("redis ttl %s: %w", key, err)
+	}
+	if len(results) == 0 {
+		return 0, fmt.Errorf("redis ttl %s: empty result", key)
+	}
+
+	ttl, ok := results[0].Val.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis ttl %s: unexpected result kind %s", key, results[0].Kind)
+	}
+
+	// TTL returns -2 when the key doesn't exist and -1 when it has no expiry
+	if ttl < 0 {
+		return 0, fmt.Errorf("cache miss: %s (ttl %d)", key, ttl)
+	}
+
+	return int(ttl), nil
+}
+
+// Flush deletes the namespace's keys a SCAN batch at a time. Every key the proxy writes
+// starts with "user:", so only those are matched, even without a namespace, and a
+// shared Redis database is never flushed as a whole.
+func (r *RedisCacheRepository) Flush() (int, error) {
+	pattern := cacheKey(r.options.Namespace, "user:*")
+
+	deleted := 0
+	cursor := "0"
+	for {
+		results, err := r.client.Execute("SCAN", cursor, "MATCH", pattern, "COUNT", flushScanCount)
+		if err != nil {
+			return deleted, fmt.Errorf("redis scan %s: %w", pattern, err)
+		}
+
+		// SCAN replies with the next cursor followed by the batch of keys
+		var keys []string
+		cursor, keys, err = parseScanResults(results)
+		if err != nil {
+			return deleted, fmt.Errorf("redis scan %s: %w", pattern, err)
+		}
+
+		if len(keys) > 0 {
+			n, err := r.client.Del(keys...)
+			if err != nil {
+				return deleted, fmt.Errorf("redis del: %w", err)
+			}
+			deleted += int(n)
+		}
+
+		// A cursor of 0 means the scan has gone through every key
+		if cursor == "0" {
+			break
+		}
+	}
+
+	log.Printf("Cache flush: %s (%d keys deleted)", pattern, deleted)
+	return deleted, nil
+}
+
+// GetCacheStats reads the lookup counters of every entity with a single MGET
+func (r *RedisCacheRepository) GetCacheStats() (domain.CacheStats, error) {
+	keys := make([]any, 0, 2*len(cacheStatsEntities))
+	for _, entity := range cacheStatsEntities {
+		keys = append(keys, r.statsKey(entity, true), r.statsKey(entity, false))
+	}
+
+	results, err := r.client.Execute("MGET", keys...)
+	if err != nil {
+		return nil, fmt.Errorf("redis mget: %w", err)
+	}
+	if len(results) != len(keys) {
+		return nil, fmt.Errorf("redis mget: %d results for %d keys", len(results), len(keys))
+	}
+
+	stats := make(domain.CacheStats, len(cacheStatsEntities))
+	for i, entity := range cacheStatsEntities {
+		stats[entity] = domain.CacheCounter{
+			Hits:   parseCounter(results[2*i]),
+			Misses: parseCounter(results[2*i+1]),
+		}
+	}
+	return stats, nil
+}
+
+// countLookup increments the hit or miss counter of entity. Counting is best-effort:
+// the INCR is tried once without retries, and a failure is only logged, never failing
+// or delaying the lookup itself. After a failure, later lookups aren't counted, so a
+// degraded Redis isn't called again just for a metric.
+func (r *RedisCacheRepository) countLookup(entity string, hit bool) {
+	if r.statsFailed.Load() {
+		return
+	}
+	key := r.statsKey(entity, hit)
+	if _, err := r.onceClient.Execute("INCR", key); err != nil {
+		r.statsFailed.Store(true)
+		log.Printf("Warning: Failed to count cache lookup %s, not counting further lookups: %v", key, err)
+	}
+}
+
+// statsKey is the key of an entity's hit or miss counter, e.g. "stats:cache:entities:hits".
+// Counters don't start with "user:", so flushing the cache keeps them.
+func (r *RedisCacheRepository) statsKey(entity string, hit bool) string {
+	kind := "misses"
+	if hit {
+		kind = "hits"
+	}
+	return cacheKey(r.options.Namespace, "stats:cache:%s:%s", entity, kind)
+}
+
+// parseCounter reads a counter from an MGET result, which is nil when it was never incremented
+func parseCounter(result *redis.Result) int64 {
+	switch value := result.Val.(type) {
+	case []byte:
+		n, _ := strconv.ParseInt(string(value), 10, 64)
+		return n
+	case int64:
+		return value
+	default:
+		return 0
+	}
+}
+
+// parseScanResults splits a SCAN reply into the next cursor and the keys of the batch
+func parseScanResults(results []*redis.Result) (string, []string, error) {
+	if len(results) == 0 {
+		return "", nil, fmt.Errorf("empty result")
+	}
+
+	values := make([]string, len(results))
+	for i, result := range results {
+		switch value := result.Val.(type) {
+		case []byte:
+			values[i] = string(value)
+		case string:
+			values[i] = value
+		case int64:
+			values[i] = strconv.FormatInt(value, 10)
+		default:
+			return "", nil, fmt.Errorf("unexpected result kind %s", result.Kind)
+		}
+	}
+	return values[0], values[1:], nil
+}
+
+// getHashData reads the JSON stored under the "data" field of a cached hash.
+// A key holding another type of value, or a hash that isn't made of strings, was written by
+// someone else and is discarded, since HSET can't repair a key of the wrong type.
+func (r *RedisCacheRepository) getHashData(key string) ([]byte, error) {
+	// Get all fields from the hash
+	results, err := r.client.Execute("HGETALL", key)
+	if err != nil {
+		if isWrongTypeError(err) {
+			return nil, r.discardMalformed(key, err)
+		}
+		return nil, fmt.Errorf("redis hgetall %s: %w", key, err)
+	}
+
+	// HGETALL returns alternating field/value pairs
+	if len(results) == 0 {
+		return nil, fmt.Errorf("cache miss: %s", key)
+	}
+	if len(results)%2 != 0 {
+		return nil, r.discardMalformed(key, fmt.Errorf("odd number of hash results (%d)", len(results)))
+	}
+
+	// Extract the JSON data (stored under "data" field)
+	for i := 0; i < len(results); i += 2 {
+		field, ok := results[i].Val.([]byte)
+		if !ok {
+			return nil, r.discardMalformed(key, fmt.Errorf("unexpected field kind %s", results[i].Kind))
+		}
+		if string(field) != "data" {
+			continue
+		}
+		value, ok := results[i+1].Val.([]byte)
+		if !ok {
+			return nil, r.discardMalformed(key, fmt.Errorf("unexpected value kind %s", results[i+1].Kind))
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("cache miss: %s (no data field)", key)
+}
+
+// discardMalformed deletes a cached value that can't be read, e.g. one another process
+// wrote under the same key, and reports a cache miss so the next fetch repairs it
+func (r *RedisCacheRepository) discardMalformed(key string, reason error) error {
+	log.Printf("Warning: Discarding malformed cache entry %s: %v", key, reason)
+	if _, err := r.client.Del(key); err != nil {
+		log.Printf("Warning: Failed to delete malformed cache entry %s: %v", key, err)
+	}
+	return fmt.Errorf("cache miss: %s (malformed: %v)", key, reason)
+}
+
+// IsCacheMiss reports whether a getter failed because the key holds no (usable) value,
+// rather than because the cache couldn't be reached
+func IsCacheMiss(err error) bool {
+	return strings.HasPrefix(err.Error(), "cache miss: ")
+}
+
+// isWrongTypeError reports whether Redis rejected a command because the key holds another type of value
+func isWrongTypeError(err error) bool {
+	return strings.Contains(err.Error(), "WRONGTYPE")
+}
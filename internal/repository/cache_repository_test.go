@@ -0,0 +1,599 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fermyon/spin/sdk/go/v2/redis"
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// fakeRedis is an in-memory Redis holding strings and hashes, supporting the commands the
+// repository runs. Expiry isn't simulated: EXPIRE and SET EX only record the TTL.
+type fakeRedis struct {
+	strings map[string][]byte
+	hashes  map[string]map[string][]byte
+	ttls    map[string]int
+	// commands is every command run, e.g. "HSET user:42:entities"
+	commands []string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		strings: make(map[string][]byte),
+		hashes:  make(map[string]map[string][]byte),
+		ttls:    make(map[string]int),
+	}
+}
+
+// newFakeRedisRepository returns a repository backed by a fresh fakeRedis
+func newFakeRedisRepository(namespace string) (*RedisCacheRepository, *fakeRedis) {
+	fake := newFakeRedis()
+	return &RedisCacheRepository{
+		client:     fake,
+		onceClient: fake,
+		options:    Options{Namespace: namespace, Clock: clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))},
+	}, fake
+}
+
+// keys returns every stored key, sorted
+func (f *fakeRedis) keys() []string {
+	var keys []string
+	for key := range f.strings {
+		keys = append(keys, key)
+	}
+	for key := range f.hashes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeRedis) record(command string, key string) {
+	f.commands = append(f.commands, command+" "+key)
+}
+
+func (f *fakeRedis) Get(key string) ([]byte, error) {
+	f.record("GET", key)
+	if _, ok := f.hashes[key]; ok {
+		return nil, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	}
+	return f.strings[key], nil
+}
+
+func (f *fakeRedis) Set(key string, payload []byte) error {
+	f.record("SET", key)
+	delete(f.hashes, key)
+	f.strings[key] = payload
+	return nil
+}
+
+func (f *fakeRedis) Del(keys ...string) (int64, error) {
+	var deleted int64
+	for _, key := range keys {
+		f.record("DEL", key)
+		_, isString := f.strings[key]
+		_, isHash := f.hashes[key]
+		if isString || isHash {
+			deleted++
+		}
+		delete(f.strings, key)
+		delete(f.hashes, key)
+		delete(f.ttls, key)
+	}
+	return deleted, nil
+}
+
+func (f *fakeRedis) Execute(command string, arguments ...any) ([]*redis.Result, error) {
+	args := make([]string, len(arguments))
+	for i, argument := range arguments {
+		args[i] = fmt.Sprint(argument)
+	}
+	switch {
+	case command == "MGET":
+		for _, key := range args {
+			f.record(command, key)
+		}
+	case command == "SCAN":
+		// The key of a scan is the pattern it matches
+		f.record(command, args[2])
+	case len(args) > 0:
+		f.record(command, args[0])
+	default:
+		f.record(command, "")
+	}
+
+	switch command {
+	case "HSET":
+		hash := f.hashes[args[0]]
+		if hash == nil {
+			hash = make(map[string][]byte)
+			f.hashes[args[0]] = hash
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			hash[args[i]] = []byte(args[i+1])
+		}
+		return []*redis.Result{{Kind: redis.ResultKindInt64, Val: int64(1)}}, nil
+	case "HGETALL":
+		if _, ok := f.strings[args[0]]; ok {
+			return nil, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+		}
+		var results []*redis.Result
+		for field, value := range f.hashes[args[0]] {
+			results = append(results, &redis.Result{Kind: redis.ResultKindBinary, Val: []byte(field)}, &redis.Result{Kind: redis.ResultKindBinary, Val: value})
+		}
+		return results, nil
+	case "EXPIRE":
+		ttl, _ := strconv.Atoi(args[1])
+		f.ttls[args[0]] = ttl
+		return []*redis.Result{{Kind: redis.ResultKindInt64, Val: int64(1)}}, nil
+	case "TTL":
+		ttl, ok := f.ttls[args[0]]
+		if !ok {
+			ttl = -2
+		}
+		return []*redis.Result{{Kind: redis.ResultKindInt64, Val: int64(ttl)}}, nil
+	case "SET":
+		if len(args) > 2 && args[2] == "NX" {
+			if _, exists := f.strings[args[0]]; exists {
+				return []*redis.Result{{Kind: redis.ResultKindNil}}, nil
+			}
+		}
+		f.strings[args[0]] = []byte(args[1])
+		for i := 2; i+1 < len(args); i++ {
+			if args[i] == "EX" {
+				f.ttls[args[0]], _ = strconv.Atoi(args[i+1])
+			}
+		}
+		return []*redis.Result{{Kind: redis.ResultKindStatus, Val: "OK"}}, nil
+	case "INCR":
+		n, _ := strconv.ParseInt(string(f.strings[args[0]]), 10, 64)
+		n++
+		f.strings[args[0]] = []byte(strconv.FormatInt(n, 10))
+		return []*redis.Result{{Kind: redis.ResultKindInt64, Val: n}}, nil
+	case "MGET":
+		results := make([]*redis.Result, len(args))
+		for i, key := range args {
+			if value, ok := f.strings[key]; ok {
+				results[i] = &redis.Result{Kind: redis.ResultKindBinary, Val: value}
+			} else {
+				results[i] = &redis.Result{Kind: redis.ResultKindNil}
+			}
+		}
+		return results, nil
+	case "SCAN":
+		// Every matching key comes back in one batch, with the cursor ending the scan
+		results := []*redis.Result{{Kind: redis.ResultKindBinary, Val: []byte("0")}}
+		for _, key := range f.keys() {
+			if matched, _ := path.Match(args[2], key); matched {
+				results = append(results, &redis.Result{Kind: redis.ResultKindBinary, Val: []byte(key)})
+			}
+		}
+		return results, nil
+	default:
+		return nil, fmt.Errorf("fakeRedis: unsupported command %s", command)
+	}
+}
+
+func TestAcquireCooldownNotRetried(t *testing.T) {
+	once := &failingRedisClient{err: errors.New("internal server error"), failures: 1}
+	clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+	r := &RedisCacheRepository{
+		client:     newRetryingRedisClient(&failingRedisClient{}, 3, clk),
+		onceClient: once,
+		options:    Options{Clock: clk},
+	}
+
+	// A retry after a lost reply would find the key the first attempt set and report
+	// the cooldown as taken, so the failure is returned as is
+	if _, err := r.AcquireCooldown(42, "dedup:abc", 30); err == nil {
+		t.Fatal("AcquireCooldown() error = nil, want the connection failure")
+	}
+	if once.calls != 1 {
+		t.Errorf("AcquireCooldown() tried %d times, want 1", once.calls)
+	}
+	if sleeps := clk.Sleeps(); len(sleeps) != 0 {
+		t.Errorf("AcquireCooldown() slept %v, want no backoff", sleeps)
+	}
+}
+
+func TestCountLookup(t *testing.T) {
+	stats := &failingRedisClient{err: errors.New("connection reset"), failures: 10}
+	clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+	r := &RedisCacheRepository{
+		client:     newRetryingRedisClient(&failingRedisClient{}, 3, clk),
+		onceClient: stats,
+		options:    Options{Clock: clk},
+	}
+
+	r.countLookup("accounts", true)
+	r.countLookup("categories", false)
+
+	if stats.calls != 1 {
+		t.Errorf("counting tried %d times, want 1 with no retries and nothing after the failure", stats.calls)
+	}
+	if sleeps := clk.Sleeps(); len(sleeps) != 0 {
+		t.Errorf("counting slept %v, want no backoff", sleeps)
+	}
+}
+
+func TestCacheStatsCounted(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		lookups   func(r *RedisCacheRepository)
+		want      domain.CacheStats
+		wantKeys  []string
+	}{
+		{
+			name:     "nothing looked up",
+			lookups:  func(r *RedisCacheRepository) {},
+			want:     domain.CacheStats{"user": {}, "entities": {}},
+			wantKeys: nil,
+		},
+		{
+			name: "hits and misses",
+			lookups: func(r *RedisCacheRepository) {
+				r.GetUser()
+				r.SetUser(&domain.User{ID: 42})
+				r.GetUser()
+				r.GetUser()
+				r.GetAccountsAndCategories(42)
+			},
+			want:     domain.CacheStats{"user": {Hits: 2, Misses: 1}, "entities": {Misses: 1}},
+			wantKeys: []string{"stats:cache:entities:misses", "stats:cache:user:hits", "stats:cache:user:misses", "user:me"},
+		},
+		{
+			name:      "namespaced",
+			namespace: "staging",
+			lookups: func(r *RedisCacheRepository) {
+				r.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, nil)
+				r.GetAccountsAndCategories(42)
+			},
+			want:     domain.CacheStats{"user": {}, "entities": {Hits: 1}},
+			wantKeys: []string{"staging:stats:cache:entities:hits", "staging:user:42:entities"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository(tt.namespace)
+
+			tt.lookups(r)
+
+			got, err := r.GetCacheStats()
+			if err != nil {
+				t.Fatalf("GetCacheStats() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCacheStats() = %v, want %v", got, tt.want)
+			}
+			if keys := fake.keys(); !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCacheStatsBestEffort(t *testing.T) {
+	r, fake := newFakeRedisRepository("")
+	r.onceClient = &failingRedisClient{err: errors.New("connection reset"), failures: 10}
+	fake.strings["user:me"] = []byte(`{"id": 42}`)
+
+	// A counter that can't be incremented doesn't fail the lookup it counts
+	if user, err := r.GetUser(); err != nil || user.ID != 42 {
+		t.Fatalf("GetUser() = %v, %v, want the cached user", user, err)
+	}
+	if _, err := r.GetUser(); err != nil {
+		t.Fatalf("GetUser() error = %v after counting failed", err)
+	}
+}
+
+func TestAccountsAndCategoriesCachedTogether(t *testing.T) {
+	accounts := []domain.TransactionAccount{{ID: 1, Name: "Everyday", CurrentBalance: 10}}
+	categories := []domain.Category{{ID: 7, Title: "Groceries"}}
+
+	tests := []struct {
+		name           string
+		update         func(r *RedisCacheRepository) error
+		wantAccounts   []domain.TransactionAccount
+		wantCategories []domain.Category
+		wantMiss       bool
+	}{
+		{
+			name:           "set",
+			update:         func(r *RedisCacheRepository) error { return nil },
+			wantAccounts:   accounts,
+			wantCategories: categories,
+		},
+		{
+			name: "balance patched",
+			update: func(r *RedisCacheRepository) error {
+				return r.UpdateCachedAccountBalance(42, 1, 25)
+			},
+			wantAccounts:   []domain.TransactionAccount{{ID: 1, Name: "Everyday", CurrentBalance: 25}},
+			wantCategories: categories,
+		},
+		{
+			name: "category added",
+			update: func(r *RedisCacheRepository) error {
+				return r.AddCachedCategory(42, domain.Category{ID: 8, Title: "Rent"})
+			},
+			wantAccounts:   accounts,
+			wantCategories: []domain.Category{{ID: 7, Title: "Groceries"}, {ID: 8, Title: "Rent"}},
+		},
+		{
+			name:     "deleted",
+			update:   func(r *RedisCacheRepository) error { return r.DeleteAccountsAndCategories(42) },
+			wantMiss: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository("")
+			if err := r.SetAccountsAndCategories(42, accounts, categories); err != nil {
+				t.Fatalf("SetAccountsAndCategories() error = %v", err)
+			}
+
+			// Both lists are written by one HSET with one EXPIRE on one key
+			wantCommands := []string{"HSET user:42:entities", "EXPIRE user:42:entities"}
+			if fmt.Sprint(fake.commands) != fmt.Sprint(wantCommands) {
+				t.Fatalf("SetAccountsAndCategories() ran %v, want %v", fake.commands, wantCommands)
+			}
+			ttl := fake.ttls["user:42:entities"]
+
+			if err := tt.update(r); err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+
+			gotAccounts, gotCategories, err := r.GetAccountsAndCategories(42)
+			if tt.wantMiss {
+				if err == nil || !IsCacheMiss(err) {
+					t.Fatalf("GetAccountsAndCategories() error = %v, want a cache miss", err)
+				}
+				if _, ok := fake.hashes["user:42:entities"]; ok {
+					t.Error("entry not deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAccountsAndCategories() error = %v", err)
+			}
+			if fmt.Sprint(gotAccounts) != fmt.Sprint(tt.wantAccounts) || fmt.Sprint(gotCategories) != fmt.Sprint(tt.wantCategories) {
+				t.Errorf("GetAccountsAndCategories() = %v, %v, want %v, %v", gotAccounts, gotCategories, tt.wantAccounts, tt.wantCategories)
+			}
+
+			// Patching keeps the one entry and its TTL
+			if got := fake.ttls["user:42:entities"]; got != ttl {
+				t.Errorf("TTL = %d after update, want %d kept", got, ttl)
+			}
+			if got, err := r.GetTTL(42, domain.CacheEntityCategories); err != nil || got != ttl {
+				t.Errorf("GetTTL(categories) = %d, %v, want %d", got, err, ttl)
+			}
+		})
+	}
+}
+
+func TestUserCached(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		wantUser *domain.User
+	}{
+		{name: "profile", stored: `{"id": 42, "login": "jane", "time_zone": "Pacific/Auckland"}`, wantUser: &domain.User{ID: 42, Login: "jane", TimeZone: "Pacific/Auckland"}},
+		{name: "bare ID", stored: "42"},
+		{name: "no ID", stored: `{"login": "jane"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository("")
+			fake.strings["user:me"] = []byte(tt.stored)
+
+			user, err := r.GetUser()
+			if tt.wantUser == nil {
+				// Unreadable entries are dropped so the next fetch stores a fresh copy
+				if err == nil || !IsCacheMiss(err) {
+					t.Fatalf("GetUser() = %v, %v, want a cache miss", user, err)
+				}
+				if _, ok := fake.strings["user:me"]; ok {
+					t.Error("malformed entry not deleted")
+				}
+				return
+			}
+			if err != nil || *user != *tt.wantUser {
+				t.Fatalf("GetUser() = %v, %v, want %v", user, err, tt.wantUser)
+			}
+		})
+	}
+
+	r, fake := newFakeRedisRepository("")
+	if err := r.SetUser(&domain.User{ID: 7, TimeZone: "Europe/London"}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if user, err := r.GetUser(); err != nil || user.TimeZone != "Europe/London" {
+		t.Errorf("GetUser() after SetUser() = %v, %v, want the time zone kept", user, err)
+	}
+	if fake.ttls["user:me"] != cacheTTL {
+		t.Errorf("TTL = %d, want %d", fake.ttls["user:me"], cacheTTL)
+	}
+}
+
+func TestMalformedEntriesRepaired(t *testing.T) {
+	user := &domain.User{ID: 42}
+	accounts := []domain.TransactionAccount{{ID: 1, Name: "Everyday"}}
+	categories := []domain.Category{{ID: 7, Title: "Groceries"}}
+
+	tests := []struct {
+		name  string
+		key   string
+		store func(fake *fakeRedis)
+		// get reads the entry, set repairs it
+		get func(r *RedisCacheRepository) error
+		set func(r *RedisCacheRepository) error
+	}{
+		{
+			name:  "non-numeric user",
+			key:   "user:me",
+			store: func(fake *fakeRedis) { fake.strings["user:me"] = []byte("not-a-user") },
+			get:   func(r *RedisCacheRepository) error { _, err := r.GetUser(); return err },
+			set:   func(r *RedisCacheRepository) error { return r.SetUser(user) },
+		},
+		{
+			name:  "user stored as a hash",
+			key:   "user:me",
+			store: func(fake *fakeRedis) { fake.hashes["user:me"] = map[string][]byte{"id": []byte("42")} },
+			get:   func(r *RedisCacheRepository) error { _, err := r.GetUser(); return err },
+			set:   func(r *RedisCacheRepository) error { return r.SetUser(user) },
+		},
+		{
+			name:  "entities with invalid JSON",
+			key:   "user:42:entities",
+			store: func(fake *fakeRedis) { fake.hashes["user:42:entities"] = map[string][]byte{"data": []byte("{")} },
+			get:   func(r *RedisCacheRepository) error { _, _, err := r.GetAccountsAndCategories(42); return err },
+			set:   func(r *RedisCacheRepository) error { return r.SetAccountsAndCategories(42, accounts, categories) },
+		},
+		{
+			name:  "entities stored as a string",
+			key:   "user:42:entities",
+			store: func(fake *fakeRedis) { fake.strings["user:42:entities"] = []byte("[]") },
+			get:   func(r *RedisCacheRepository) error { _, _, err := r.GetAccountsAndCategories(42); return err },
+			set:   func(r *RedisCacheRepository) error { return r.SetAccountsAndCategories(42, accounts, categories) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository("")
+			tt.store(fake)
+
+			if err := tt.get(r); err == nil || !IsCacheMiss(err) {
+				t.Fatalf("read error = %v, want a cache miss", err)
+			}
+			_, isString := fake.strings[tt.key]
+			_, isHash := fake.hashes[tt.key]
+			if isString || isHash {
+				t.Fatalf("malformed %s not deleted", tt.key)
+			}
+
+			// The next fetch stores a fresh copy that reads back
+			if err := tt.set(r); err != nil {
+				t.Fatalf("set error = %v", err)
+			}
+			if err := tt.get(r); err != nil {
+				t.Errorf("read error = %v after repair, want a hit", err)
+			}
+			if fake.ttls[tt.key] == 0 {
+				t.Errorf("repaired %s has no TTL", tt.key)
+			}
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	stored := []string{
+		"user:me", "user:42:entities", "stats:cache:entities:hits",
+		"prod:user:me", "prod:user:42:entities", "prod:user:42:job:job-1", "prod:stats:cache:entities:hits",
+	}
+	tests := []struct {
+		name        string
+		namespace   string
+		wantDeleted int
+		wantKept    []string
+	}{
+		{
+			name:        "unprefixed",
+			wantDeleted: 2,
+			wantKept:    []string{"prod:stats:cache:entities:hits", "prod:user:42:entities", "prod:user:42:job:job-1", "prod:user:me", "stats:cache:entities:hits"},
+		},
+		{
+			name:        "namespaced",
+			namespace:   "prod",
+			wantDeleted: 3,
+			wantKept:    []string{"prod:stats:cache:entities:hits", "stats:cache:entities:hits", "user:42:entities", "user:me"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository(tt.namespace)
+			for _, key := range stored {
+				if strings.HasSuffix(key, ":entities") {
+					fake.hashes[key] = map[string][]byte{"data": []byte("{}")}
+				} else {
+					fake.strings[key] = []byte("{}")
+				}
+			}
+
+			deleted, err := r.Flush()
+			if err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("Flush() deleted %d keys, want %d", deleted, tt.wantDeleted)
+			}
+			if got := fake.keys(); !reflect.DeepEqual(got, tt.wantKept) {
+				t.Errorf("keys left = %v, want %v", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestNamespaceOnEveryKey(t *testing.T) {
+	// Every key the repository touches, unprefixed, with the SCAN pattern of a flush
+	keys := []string{
+		"stats:cache:entities:hits", "stats:cache:entities:misses",
+		"stats:cache:user:hits", "stats:cache:user:misses",
+		"user:*", "user:42:cooldown:dedup:abc", "user:42:entities", "user:42:job:job-1", "user:me",
+	}
+	tests := []struct {
+		name      string
+		namespace string
+		prefix    string
+	}{
+		{name: "empty namespace keeps the keys as they were"},
+		{name: "namespace prefixes every key", namespace: "prod", prefix: "prod:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRedisRepository(tt.namespace)
+
+			r.SetUser(&domain.User{ID: 42})
+			r.GetUser()
+			r.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 10}}, []domain.Category{{ID: 20}})
+			r.GetAccountsAndCategories(42)
+			r.UpdateCachedAccountBalance(42, 10, 5)
+			r.AddCachedCategory(42, domain.Category{ID: 21})
+			r.GetTTL(42, domain.CacheEntityAccounts)
+			r.AcquireCooldown(42, "dedup:abc", 30)
+			r.ReleaseCooldown(42, "dedup:abc")
+			r.SetJob(42, &domain.Job{ID: "job-1"})
+			r.GetJob(42, "job-1")
+			r.GetCacheStats()
+			r.DeleteAccountsAndCategories(42)
+			r.Flush()
+
+			touched := make(map[string]bool)
+			for _, command := range fake.commands {
+				_, key, _ := strings.Cut(command, " ")
+				touched[key] = true
+			}
+			var got []string
+			for key := range touched {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			want := make([]string, len(keys))
+			for i, key := range keys {
+				want[i] = tt.prefix + key
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("keys touched = %v, want %v", got, want)
+			}
+		})
+	}
+}
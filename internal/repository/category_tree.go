@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// insertCategory adds a category to a cached category tree: at the top level, keeping
+// it sorted by title, when it has no parent, otherwise among its parent's children.
+// A category already in the tree is left as is. It fails when the parent isn't in the tree.
+func insertCategory(categories []domain.Category, category domain.Category) ([]domain.Category, error) {
+	if containsCategory(categories, category.ID) {
+		return categories, nil
+	}
+	if category.ParentID == nil {
+		i, _ := slices.BinarySearchFunc(categories, category.Title, func(existing domain.Category, title string) int {
+			return strings.Compare(existing.Title, title)
+		})
+		return slices.Insert(categories, i, category), nil
+	}
+	if !appendChild(categories, *category.ParentID, category) {
+		return nil, fmt.Errorf("parent category %d not cached", *category.ParentID)
+	}
+	return categories, nil
+}
+
+// containsCategory reports whether a category with the ID is anywhere in the tree
+func containsCategory(categories []domain.Category, id int) bool {
+	for _, category := range categories {
+		if category.ID == id || containsCategory(category.Children, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendChild appends child to the children of the category with parentID, reporting whether it was found
+func appendChild(categories []domain.Category, parentID int, child domain.Category) bool {
+	for i := range categories {
+		if categories[i].ID == parentID {
+			categories[i].Children = append(categories[i].Children, child)
+			return true
+		}
+		if appendChild(categories[i].Children, parentID, child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// categoryTree returns Food > Groceries > Fruit and Salary, freshly built for each use
+func categoryTree() []domain.Category {
+	food, groceries := 30, 31
+	return []domain.Category{
+		{ID: 30, Title: "Food", Children: []domain.Category{
+			{ID: 31, Title: "Groceries", ParentID: &food, Children: []domain.Category{
+				{ID: 32, Title: "Fruit", ParentID: &groceries},
+			}},
+		}},
+		{ID: 21, Title: "Salary"},
+	}
+}
+
+// flatTitles lists every title in the tree, parents before their children
+func flatTitles(categories []domain.Category) []string {
+	var titles []string
+	for _, category := range categories {
+		titles = append(titles, category.Title)
+		titles = append(titles, flatTitles(category.Children)...)
+	}
+	return titles
+}
+
+func TestInsertCategory(t *testing.T) {
+	food, groceries, missing := 30, 31, 99
+	tests := []struct {
+		name     string
+		category domain.Category
+		want     []string
+		wantErr  bool
+	}{
+		{name: "top level, sorted", category: domain.Category{ID: 40, Title: "Rent"}, want: []string{"Food", "Groceries", "Fruit", "Rent", "Salary"}},
+		{name: "top level, first", category: domain.Category{ID: 41, Title: "Bills"}, want: []string{"Bills", "Food", "Groceries", "Fruit", "Salary"}},
+		{name: "child", category: domain.Category{ID: 33, Title: "Dining", ParentID: &food}, want: []string{"Food", "Groceries", "Fruit", "Dining", "Salary"}},
+		{name: "grandchild", category: domain.Category{ID: 34, Title: "Bakery", ParentID: &groceries}, want: []string{"Food", "Groceries", "Fruit", "Bakery", "Salary"}},
+		{name: "duplicate", category: domain.Category{ID: 32, Title: "Fruit", ParentID: &groceries}, want: []string{"Food", "Groceries", "Fruit", "Salary"}},
+		{name: "parent not cached", category: domain.Category{ID: 35, Title: "Snacks", ParentID: &missing}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insertCategory(categoryTree(), tt.category)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("insertCategory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if titles := flatTitles(got); fmt.Sprint(titles) != fmt.Sprint(tt.want) {
+				t.Errorf("insertCategory() = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/fermyon/spin/sdk/go/v2/kv"
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// kvEntry is a cached value stored as a JSON blob with its own expiry,
+// since the Spin key-value store has no native TTL
+type kvEntry struct {
+	ExpiresAt int64           `json:"expires_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// kvStore is the subset of the Spin key-value store used by the repository
+type kvStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+	Exists(key string) (bool, error)
+	GetKeys() ([]string, error)
+	Close()
+}
+
+// KVCacheRepository implements CacheRepository using the Spin key-value store
+type KVCacheRepository struct {
+	storeName string
+	// openStore opens the store for one operation (defaults to the named Spin store)
+	openStore func() (kvStore, error)
+	options   Options
+}
+
+// NewKVCacheRepository creates a new cache repository backed by a Spin key-value store
+func NewKVCacheRepository(storeName string, options Options) CacheRepository {
+	options.Clock = clock.OrReal(options.Clock)
+	return &KVCacheRepository{
+		storeName: storeName,
+		openStore: func() (kvStore, error) {
+			return kv.OpenStore(storeName)
+		},
+		options: options,
+	}
+}
+
+// GetUser retrieves the cached user
+func (r *KVCacheRepository) GetUser() (*domain.User, error) {
+	key := cacheKey(r.options.Namespace, "user:me")
+
+	var user domain.User
+	if _, err := r.get(key, &user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Cache hit: %s = %d", key, user.ID)
+	return &user, nil
+}
+
+// SetUser stores the user in cache with TTL
+func (r *KVCacheRepository) SetUser(user *domain.User) error {
+	key := cacheKey(r.options.Namespace, "user:me")
+
+	ttl, err := r.set(key, user)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cache set: %s = %d (TTL: %d seconds)", key, user.ID, ttl)
+	return nil
+}
+
+// GetAccountsAndCategories retrieves a user's cached transaction accounts and categories
+func (r *KVCacheRepository) GetAccountsAndCategories(userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	var entities cachedEntities
+	if _, err := r.get(key, &entities); err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("Cache hit: %s (%d accounts, %d categories)", key, len(entities.Accounts), len(entities.Categories))
+	return entities.Accounts, entities.Categories, nil
+}
+
+// SetAccountsAndCategories stores a user's transaction accounts and categories in one
+// entry with one expiry, so neither is ever cached without the other
+func (r *KVCacheRepository) SetAccountsAndCategories(userID int, accounts []domain.TransactionAccount, categories []domain.Category) error {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	ttl, err := r.set(key, cachedEntities{Accounts: accounts, Categories: categories})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cache set: %s (%d accounts, %d categories, TTL: %d seconds)", key, len(accounts), len(categories), ttl)
+	return nil
+}
+
+// UpdateCachedAccountBalance patches one account's balance in the cached accounts
+func (r *KVCacheRepository) UpdateCachedAccountBalance(userID, accountID int, balance float64) error {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	var entities cachedEntities
+	entry, err := r.get(key, &entities)
+	if err != nil {
+		return err
+	}
+	if err := setAccountBalance(entities.Accounts, accountID, balance); err != nil {
+		return err
+	}
+
+	// Keep the entry's original expiry
+	if err := r.put(key, entry.ExpiresAt, entities); err != nil {
+		return err
+	}
+
+	log.Printf("Cache update: %s (account %d balance %.2f)", key, accountID, balance)
+	return nil
+}
+
+// AddCachedCategory adds a category to the cached tree
+func (r *KVCacheRepository) AddCachedCategory(userID int, category domain.Category) error {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	var entities cachedEntities
+	entry, err := r.get(key, &entities)
+	if err != nil {
+		return err
+	}
+	entities.Categories, err = insertCategory(entities.Categories, category)
+	if err != nil {
+		return err
+	}
+
+	// Keep the entry's original expiry
+	if err := r.put(key, entry.ExpiresAt, entities); err != nil {
+		return err
+	}
+
+	log.Printf("Cache update: %s (added category %d '%s')", key, category.ID, category.Title)
+	return nil
+}
+
+// DeleteAccountsAndCategories removes a user's cached transaction accounts and categories
+func (r *KVCacheRepository) DeleteAccountsAndCategories(userID int) error {
+	return r.delete(cacheKey(r.options.Namespace, "user:%d:entities", userID))
+}
+
+// AcquireCooldown starts a cooldown unless an unexpired one is stored.
+// The key-value store has no atomic set-if-absent, so concurrent callers may both acquire it.
+func (r *KVCacheRepository) AcquireCooldown(userID int, name string, seconds int) (bool, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:cooldown:%s", userID, name)
+
+	if _, err := r.get(key, nil); err == nil {
+		return false, nil
+	}
+	if err := r.put(key, r.options.Clock.Now().Unix()+int64(seconds), true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseCooldown deletes a cooldown's entry
+func (r *KVCacheRepository) ReleaseCooldown(userID int, name string) error {
+	return r.delete(cacheKey(r.options.Namespace, "user:%d:cooldown:%s", userID, name))
+}
+
+// GetJob retrieves a user's batch job
+func (r *KVCacheRepository) GetJob(userID int, jobID string) (*domain.Job, error) {
+	var job domain.Job
+	if _, err := r.get(cacheKey(r.options.Namespace, "user:%d:job:%s", userID, jobID), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SetJob stores a user's batch job, restarting its JobTTL without jitter
+func (r *KVCacheRepository) SetJob(userID int, job *domain.Job) error {
+	key := cacheKey(r.options.Namespace, "user:%d:job:%s", userID, job.ID)
+
+	if err := r.put(key, r.options.Clock.Now().Unix()+JobTTL, job); err != nil {
+		return err
+	}
+
+	log.Printf("Job set: %s (%d/%d processed, TTL: %d seconds)", key, job.Processed, job.Total, JobTTL)
+	return nil
+}
+
+// GetTTL retrieves the remaining TTL of a user's cached entity. Accounts and categories
+// share one entry, so both report its TTL.
+func (r *KVCacheRepository) GetTTL(userID int, entity domain.CacheEntity) (int, error) {
+	key := cacheKey(r.options.Namespace, "user:%d:entities", userID)
+
+	entry, err := r.get(key, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int(entry.ExpiresAt - r.options.Clock.Now().Unix()), nil
+}
+
+// Flush deletes every key under the namespace that the proxy writes, all of which start
+// with "user:", leaving other keys in the store alone
+func (r *KVCacheRepository) Flush() (int, error) {
+	prefix := cacheKey(r.options.Namespace, "user:")
+
+	store, err := r.openStore()
+	if err != nil {
+		return 0, fmt.Errorf("kv open store %s: %w", r.storeName, err)
+	}
+	defer store.Close()
+
+	keys, err := store.GetKeys()
+	if err != nil {
+		return 0, fmt.Errorf("kv get keys %s: %w", r.storeName, err)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := store.Delete(key); err != nil {
+			return deleted, fmt.Errorf("kv delete %s: %w", key, err)
+		}
+		deleted++
+	}
+
+	log.Printf("Cache flush: %s* (%d keys deleted)", prefix, deleted)
+	return deleted, nil
+}
+
+// GetCacheStats returns empty stats: the key-value store has no atomic increment, so
+// lookups aren't counted
+func (r *KVCacheRepository) GetCacheStats() (domain.CacheStats, error) {
+	return domain.CacheStats{}, nil
+}
+
+// get reads an unexpired entry and decodes its data into out (when not nil).
+// Expired entries are deleted and reported as a cache miss.
+func (r *KVCacheRepository) get(key string, out any) (*kvEntry, error) {
+	store, err := r.openStore()
+	if err != nil {
+		return nil, fmt.Errorf("kv open store %s: %w", r.storeName, err)
+	}
+	defer store.Close()
+
+	exists, err := store.Exists(key)
+	if err != nil {
+		return nil, fmt.Errorf("kv exists %s: %w", key, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("cache miss: %s", key)
+	}
+
+	raw, err := store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("kv get %s: %w", key, err)
+	}
+
+	var entry kvEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, discardMalformedKV(store, key, err)
+	}
+
+	if r.options.Clock.Now().Unix() >= entry.ExpiresAt {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Warning: Failed to delete expired cache entry %s: %v", key, err)
+		}
+		return nil, fmt.Errorf("cache miss: %s (expired)", key)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(entry.Data, out); err != nil {
+			return nil, discardMalformedKV(store, key, err)
+		}
+	}
+	return &entry, nil
+}
+
+// discardMalformedKV deletes a stored entry that can't be decoded, e.g. one written by an
+// older version, and reports a cache miss so the next fetch repairs it
+func discardMalformedKV(store kvStore, key string, reason error) error {
+	log.Printf("Warning: Discarding malformed cache entry %s: %v", key, reason)
+	if err := store.Delete(key); err != nil {
+		log.Printf("Warning: Failed to delete malformed cache entry %s: %v", key, err)
+	}
+	return fmt.Errorf("cache miss: %s (malformed: %v)", key, reason)
+}
+
+// set stores value under key with a jittered TTL and returns the TTL used
+func (r *KVCacheRepository) set(key string, value any) (int, error) {
+	ttl := jitteredTTL(cacheTTL, r.options.TTLJitter)
+	if err := r.put(key, r.options.Clock.Now().Unix()+int64(ttl), value); err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// put stores value under key, expiring at the given Unix time
+func (r *KVCacheRepository) put(key string, expiresAt int64, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", key, err)
+	}
+
+	raw, err := json.Marshal(kvEntry{
+		ExpiresAt: expiresAt,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", key, err)
+	}
+
+	store, err := r.openStore()
+	if err != nil {
+		return fmt.Errorf("kv open store %s: %w", r.storeName, err)
+	}
+	defer store.Close()
+
+	if err := store.Set(key, raw); err != nil {
+		return fmt.Errorf("kv set %s: %w", key, err)
+	}
+	return nil
+}
+
+// delete removes key from the store
+func (r *KVCacheRepository) delete(key string) error {
+	store, err := r.openStore()
+	if err != nil {
+		return fmt.Errorf("kv open store %s: %w", r.storeName, err)
+	}
+	defer store.Close()
+
+	if err := store.Delete(key); err != nil {
+		return fmt.Errorf("kv delete %s: %w", key, err)
+	}
+
+	log.Printf("Cache delete: %s", key)
+	return nil
+}
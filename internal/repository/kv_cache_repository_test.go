@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// fakeKV is an in-memory key-value store shared by every open of it
+type fakeKV struct {
+	data map[string][]byte
+}
+
+func (f *fakeKV) Get(key string) ([]byte, error) {
+	value, ok := f.data[key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return value, nil
+}
+
+func (f *fakeKV) Set(key string, value []byte) error {
+	f.data[key] = value
+	return nil
+}
+
+func (f *fakeKV) Delete(key string) error {
+	delete(f.data, key)
+	return nil
+}
+
+func (f *fakeKV) Exists(key string) (bool, error) {
+	_, ok := f.data[key]
+	return ok, nil
+}
+
+func (f *fakeKV) GetKeys() ([]string, error) {
+	var keys []string
+	for key := range f.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (f *fakeKV) Close() {}
+
+// newFakeKVRepository returns a KV cache repository backed by an in-memory store
+func newFakeKVRepository(options Options) (*KVCacheRepository, *fakeKV) {
+	fake := &fakeKV{data: make(map[string][]byte)}
+	repo := NewKVCacheRepository("default", options).(*KVCacheRepository)
+	repo.openStore = func() (kvStore, error) {
+		return fake, nil
+	}
+	return repo, fake
+}
+
+func TestKVCacheRepositoryExpiry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+	repo, _ := newFakeKVRepository(Options{Namespace: "expiry-test", Clock: clk})
+
+	if err := repo.SetUser(&domain.User{ID: 42}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if user, err := repo.GetUser(); err != nil || user.ID != 42 {
+		t.Fatalf("GetUser() = %v, %v, want user 42", user, err)
+	}
+
+	clk.Advance(time.Duration(cacheTTL-1) * time.Second)
+	if _, err := repo.GetUser(); err != nil {
+		t.Fatalf("GetUser() a second before expiry error = %v", err)
+	}
+
+	clk.Advance(time.Second)
+	if _, err := repo.GetUser(); err == nil || !strings.HasPrefix(err.Error(), "cache miss") {
+		t.Errorf("GetUser() after expiry error = %v, want a cache miss", err)
+	}
+}
+
+func TestKVCacheRepositoryMalformedEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "not JSON", raw: "not json"},
+		{name: "data of the wrong type", raw: `{"expires_at": 4102444800, "data": [1, 2]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, store := newFakeKVRepository(Options{Namespace: "malformed-test"})
+			key := cacheKey("malformed-test", "user:%d:job:%s", 1, "job-1")
+			store.data[key] = []byte(tt.raw)
+
+			// A job that can't be decoded reads as missing, not as a failure
+			job, err := repo.GetJob(1, "job-1")
+			if err == nil || !IsCacheMiss(err) {
+				t.Fatalf("GetJob() = %v, %v, want a cache miss", job, err)
+			}
+			if _, ok := store.data[key]; ok {
+				t.Error("malformed entry was not deleted")
+			}
+		})
+	}
+}
+
+func TestKVCacheRepositoryFlush(t *testing.T) {
+	repo, store := newFakeKVRepository(Options{Namespace: "prod"})
+	for _, key := range []string{"prod:user:me", "prod:user:42:entities", "staging:user:me", "other"} {
+		store.data[key] = []byte("{}")
+	}
+
+	deleted, err := repo.Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Flush() deleted %d keys, want 2", deleted)
+	}
+	if keys, _ := store.GetKeys(); !reflect.DeepEqual(keys, []string{"other", "staging:user:me"}) {
+		t.Errorf("keys left = %v, want the other namespace's and the unprefixed one", keys)
+	}
+}
+
+func TestKVCacheRepositoryAddCachedCategory(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+	repo, store := newFakeKVRepository(Options{Namespace: "category-test", Clock: clk})
+	key := cacheKey("category-test", "user:%d:entities", 42)
+
+	if err := repo.AddCachedCategory(42, domain.Category{ID: 40, Title: "Rent"}); err == nil || !IsCacheMiss(err) {
+		t.Fatalf("AddCachedCategory() before caching error = %v, want a cache miss", err)
+	}
+
+	if err := repo.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, categoryTree()); err != nil {
+		t.Fatalf("SetAccountsAndCategories() error = %v", err)
+	}
+	expiresAt := func() int64 {
+		var entry kvEntry
+		if err := json.Unmarshal(store.data[key], &entry); err != nil {
+			t.Fatalf("stored entry: %v", err)
+		}
+		return entry.ExpiresAt
+	}
+	before := expiresAt()
+
+	clk.Advance(time.Minute)
+	groceries := 31
+	for _, category := range []domain.Category{
+		{ID: 40, Title: "Rent"},
+		{ID: 34, Title: "Bakery", ParentID: &groceries},
+		{ID: 34, Title: "Bakery", ParentID: &groceries},
+	} {
+		if err := repo.AddCachedCategory(42, category); err != nil {
+			t.Fatalf("AddCachedCategory(%d) error = %v", category.ID, err)
+		}
+	}
+
+	accounts, categories, err := repo.GetAccountsAndCategories(42)
+	if err != nil {
+		t.Fatalf("GetAccountsAndCategories() error = %v", err)
+	}
+	want := []string{"Food", "Groceries", "Fruit", "Bakery", "Rent", "Salary"}
+	if titles := flatTitles(categories); !reflect.DeepEqual(titles, want) {
+		t.Errorf("categories = %v, want %v", titles, want)
+	}
+	if len(categories) != 3 || len(categories[0].Children[0].Children) != 2 {
+		t.Errorf("categories = %+v, want Rent at the top level and Bakery under Groceries", categories)
+	}
+	if len(accounts) != 1 {
+		t.Errorf("accounts = %v, want them kept", accounts)
+	}
+	if after := expiresAt(); after != before {
+		t.Errorf("expires at %d after adding, want %d kept", after, before)
+	}
+}
+
+func TestKVCacheRepositoryUpdateCachedAccountBalance(t *testing.T) {
+	tests := []struct {
+		name         string
+		cached       bool
+		accountID    int
+		wantErr      bool
+		wantAccounts []domain.TransactionAccount
+	}{
+		{
+			name:         "patched in place",
+			cached:       true,
+			accountID:    2,
+			wantAccounts: []domain.TransactionAccount{{ID: 1, CurrentBalance: 10}, {ID: 2, CurrentBalance: 75.5}},
+		},
+		{
+			name:         "account not cached",
+			cached:       true,
+			accountID:    3,
+			wantErr:      true,
+			wantAccounts: []domain.TransactionAccount{{ID: 1, CurrentBalance: 10}, {ID: 2, CurrentBalance: 20}},
+		},
+		{name: "nothing cached", accountID: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+			repo, store := newFakeKVRepository(Options{Namespace: "balance-test", Clock: clk})
+			key := cacheKey("balance-test", "user:%d:entities", 42)
+			categories := []domain.Category{{ID: 7, Title: "Groceries"}}
+			if tt.cached {
+				accounts := []domain.TransactionAccount{{ID: 1, CurrentBalance: 10}, {ID: 2, CurrentBalance: 20}}
+				if err := repo.SetAccountsAndCategories(42, accounts, categories); err != nil {
+					t.Fatalf("SetAccountsAndCategories() error = %v", err)
+				}
+			}
+			before := string(store.data[key])
+
+			clk.Advance(time.Minute)
+			err := repo.UpdateCachedAccountBalance(42, tt.accountID, 75.5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateCachedAccountBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.cached {
+				if !IsCacheMiss(err) {
+					t.Errorf("UpdateCachedAccountBalance() error = %v, want a cache miss", err)
+				}
+				return
+			}
+			if tt.wantErr && string(store.data[key]) != before {
+				t.Errorf("entry = %s after a failed update, want %s kept", store.data[key], before)
+			}
+
+			accounts, gotCategories, err := repo.GetAccountsAndCategories(42)
+			if err != nil {
+				t.Fatalf("GetAccountsAndCategories() error = %v", err)
+			}
+			if !reflect.DeepEqual(accounts, tt.wantAccounts) || !reflect.DeepEqual(gotCategories, categories) {
+				t.Errorf("GetAccountsAndCategories() = %v, %v, want %v, %v", accounts, gotCategories, tt.wantAccounts, categories)
+			}
+
+			// The patched entry keeps its original expiry
+			var entry, original kvEntry
+			json.Unmarshal(store.data[key], &entry)
+			json.Unmarshal([]byte(before), &original)
+			if entry.ExpiresAt != original.ExpiresAt {
+				t.Errorf("expires at %d after the update, want %d kept", entry.ExpiresAt, original.ExpiresAt)
+			}
+		})
+	}
+}
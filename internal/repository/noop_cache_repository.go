@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// NoopCacheRepository implements CacheRepository without caching anything: every get misses
+// and every write is dropped, so each lookup goes to PocketSmith through the same code paths
+// as a cold cache
+type NoopCacheRepository struct{}
+
+// NewNoopCacheRepository creates a cache repository that never caches
+func NewNoopCacheRepository() CacheRepository {
+	return NoopCacheRepository{}
+}
+
+// GetUser always misses
+func (NoopCacheRepository) GetUser() (*domain.User, error) {
+	return nil, fmt.Errorf("cache miss: user:me (cache disabled)")
+}
+
+// SetUser does nothing
+func (NoopCacheRepository) SetUser(user *domain.User) error {
+	return nil
+}
+
+// GetAccountsAndCategories always misses
+func (NoopCacheRepository) GetAccountsAndCategories(userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	return nil, nil, fmt.Errorf("cache miss: user:%d:entities (cache disabled)", userID)
+}
+
+// SetAccountsAndCategories does nothing
+func (NoopCacheRepository) SetAccountsAndCategories(userID int, accounts []domain.TransactionAccount, categories []domain.Category) error {
+	return nil
+}
+
+// UpdateCachedAccountBalance does nothing, since there are no cached accounts to go stale
+func (NoopCacheRepository) UpdateCachedAccountBalance(userID, accountID int, balance float64) error {
+	return nil
+}
+
+// AddCachedCategory does nothing, since there is no cached tree to go stale
+func (NoopCacheRepository) AddCachedCategory(userID int, category domain.Category) error {
+	return nil
+}
+
+// DeleteAccountsAndCategories does nothing
+func (NoopCacheRepository) DeleteAccountsAndCategories(userID int) error {
+	return nil
+}
+
+// AcquireCooldown always acquires, since there is nowhere to remember a running cooldown
+func (NoopCacheRepository) AcquireCooldown(userID int, name string, seconds int) (bool, error) {
+	return true, nil
+}
+
+// ReleaseCooldown does nothing
+func (NoopCacheRepository) ReleaseCooldown(userID int, name string) error {
+	return nil
+}
+
+// GetJob always misses
+func (NoopCacheRepository) GetJob(userID int, jobID string) (*domain.Job, error) {
+	return nil, fmt.Errorf("cache miss: user:%d:job:%s (cache disabled)", userID, jobID)
+}
+
+// SetJob fails, since a job that isn't stored could never be processed
+func (NoopCacheRepository) SetJob(userID int, job *domain.Job) error {
+	return errors.New("batch jobs need the cache, which is disabled")
+}
+
+// GetTTL always misses
+func (NoopCacheRepository) GetTTL(userID int, entity domain.CacheEntity) (int, error) {
+	return 0, fmt.Errorf("cache miss: user:%d:entities (cache disabled)", userID)
+}
+
+// Flush has nothing to delete
+func (NoopCacheRepository) Flush() (int, error) {
+	return 0, nil
+}
+
+// GetCacheStats returns empty stats, since nothing is looked up
+func (NoopCacheRepository) GetCacheStats() (domain.CacheStats, error) {
+	return domain.CacheStats{}, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestNoopCacheRepositoryNeverCaches(t *testing.T) {
+	r := NewNoopCacheRepository()
+
+	// Everything written is dropped, so nothing written can be read back stale
+	if err := r.SetUser(&domain.User{ID: 42}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if err := r.SetAccountsAndCategories(42, []domain.TransactionAccount{{ID: 1}}, []domain.Category{{ID: 7}}); err != nil {
+		t.Fatalf("SetAccountsAndCategories() error = %v", err)
+	}
+	if err := r.UpdateCachedAccountBalance(42, 1, 25); err != nil {
+		t.Errorf("UpdateCachedAccountBalance() error = %v", err)
+	}
+	if err := r.AddCachedCategory(42, domain.Category{ID: 8}); err != nil {
+		t.Errorf("AddCachedCategory() error = %v", err)
+	}
+
+	if user, err := r.GetUser(); err == nil || !IsCacheMiss(err) {
+		t.Errorf("GetUser() = %v, %v, want a cache miss", user, err)
+	}
+	if accounts, categories, err := r.GetAccountsAndCategories(42); err == nil || !IsCacheMiss(err) {
+		t.Errorf("GetAccountsAndCategories() = %v, %v, %v, want a cache miss", accounts, categories, err)
+	}
+	if ttl, err := r.GetTTL(42, domain.CacheEntityCategories); err == nil || !IsCacheMiss(err) {
+		t.Errorf("GetTTL() = %d, %v, want a cache miss", ttl, err)
+	}
+	if n, err := r.Flush(); err != nil || n != 0 {
+		t.Errorf("Flush() = %d, %v, want nothing to delete", n, err)
+	}
+	if stats, err := r.GetCacheStats(); err != nil || len(stats) != 0 {
+		t.Errorf("GetCacheStats() = %v, %v, want empty stats", stats, err)
+	}
+
+	// Without storage, cooldowns are always free and jobs can't be kept
+	for i := 0; i < 2; i++ {
+		if acquired, err := r.AcquireCooldown(42, "self-heal", 60); err != nil || !acquired {
+			t.Errorf("AcquireCooldown() = %v, %v, want acquired", acquired, err)
+		}
+	}
+	if err := r.SetJob(42, &domain.Job{ID: "job-1"}); err == nil {
+		t.Error("SetJob() error = nil, want jobs refused")
+	}
+}
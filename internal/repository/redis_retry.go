@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/fermyon/spin/sdk/go/v2/redis"
+	"github.com/pocketsmith-proxy/internal/clock"
+)
+
+// DefaultRedisRetryAttempts is the number of tries per Redis operation when not configured
+const DefaultRedisRetryAttempts = 3
+
+// redisRetryBackoff is the delay before the first retry, doubled for each further one
+const redisRetryBackoff = 20 * time.Millisecond
+
+// redisClient is the subset of the Spin Redis client used by the repository
+type redisClient interface {
+	Get(key string) ([]byte, error)
+	Set(key string, payload []byte) error
+	Del(keys ...string) (int64, error)
+	Execute(command string, arguments ...any) ([]*redis.Result, error)
+}
+
+// retryingRedisClient retries failed Redis operations with a short jittered backoff,
+// so a brief failover or network hiccup doesn't fall through to PocketSmith
+type retryingRedisClient struct {
+	client   redisClient
+	attempts int
+	clock    clock.Clock
+}
+
+// newRetryingRedisClient wraps client, trying each operation up to attempts times
+// (DefaultRedisRetryAttempts when not positive) and waiting on clk between tries
+func newRetryingRedisClient(client redisClient, attempts int, clk clock.Clock) *retryingRedisClient {
+	if attempts <= 0 {
+		attempts = DefaultRedisRetryAttempts
+	}
+	return &retryingRedisClient{client: client, attempts: attempts, clock: clock.OrReal(clk)}
+}
+
+func (c *retryingRedisClient) Get(key string) (data []byte, err error) {
+	c.retry("GET", func() error {
+		data, err = c.client.Get(key)
+		return err
+	})
+	return data, err
+}
+
+func (c *retryingRedisClient) Set(key string, payload []byte) (err error) {
+	c.retry("SET", func() error {
+		err = c.client.Set(key, payload)
+		return err
+	})
+	return err
+}
+
+func (c *retryingRedisClient) Del(keys ...string) (deleted int64, err error) {
+	c.retry("DEL", func() error {
+		deleted, err = c.client.Del(keys...)
+		return err
+	})
+	return deleted, err
+}
+
+func (c *retryingRedisClient) Execute(command string, arguments ...any) (results []*redis.Result, err error) {
+	if !isIdempotentRedisCommand(command, arguments) {
+		return c.client.Execute(command, arguments...)
+	}
+	c.retry(command, func() error {
+		results, err = c.client.Execute(command, arguments...)
+		return err
+	})
+	return results, err
+}
+
+// retry runs op until it succeeds, fails permanently, or runs out of attempts
+func (c *retryingRedisClient) retry(command string, op func() error) {
+	backoff := redisRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= c.attempts || !isTransientRedisError(err) {
+			return
+		}
+
+		// Sleep between half and the full backoff so retries don't line up
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("Warning: Redis %s failed (attempt %d of %d), retrying in %v: %v", command, attempt, c.attempts, delay, err)
+		c.clock.Sleep(delay)
+		backoff *= 2
+	}
+}
+
+// isIdempotentRedisCommand reports whether a command can be sent again after its reply
+// was lost. INCR would count twice, and SET NX would find the key its first attempt set.
+func isIdempotentRedisCommand(command string, arguments []any) bool {
+	switch strings.ToUpper(command) {
+	case "INCR", "INCRBY", "DECR", "DECRBY":
+		return false
+	case "SET":
+		// Options follow the key and the value
+		for _, argument := range arguments[min(2, len(arguments)):] {
+			if option, ok := argument.(string); ok && strings.EqualFold(option, "NX") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// transientRedisErrors are the messages of failures to reach Redis or read its reply.
+// The Spin host reports any such failure as "internal server error" without details;
+// the others cover hosts that pass the connection error on.
+var transientRedisErrors = []string{"internal server error", "connection", "broken pipe", "timed out", "timeout", "eof"}
+
+// isTransientRedisError reports whether an error came from the Redis connection, so the
+// same command may succeed when sent again. Anything else, such as arguments the client
+// rejects before sending, isn't retried. Misses aren't errors: Redis returns them as empty
+// results. A key of the wrong type fails the same way every time, so it isn't retried either.
+func isTransientRedisError(err error) bool {
+	if isWrongTypeError(err) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientRedisErrors {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fermyon/spin/sdk/go/v2/redis"
+	"github.com/pocketsmith-proxy/internal/clock"
+)
+
+// failingRedisClient fails the first failures operations with err, then succeeds
+type failingRedisClient struct {
+	err      error
+	failures int
+	calls    int
+}
+
+func (c *failingRedisClient) do() error {
+	c.calls++
+	if c.calls <= c.failures {
+		return c.err
+	}
+	return nil
+}
+
+func (c *failingRedisClient) Get(key string) ([]byte, error) {
+	if err := c.do(); err != nil {
+		return nil, err
+	}
+	return []byte("value"), nil
+}
+
+func (c *failingRedisClient) Set(key string, payload []byte) error { return c.do() }
+
+func (c *failingRedisClient) Del(keys ...string) (int64, error) {
+	return int64(len(keys)), c.do()
+}
+
+func (c *failingRedisClient) Execute(command string, arguments ...any) ([]*redis.Result, error) {
+	return nil, c.do()
+}
+
+func TestRetryingRedisClientBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		failures   int
+		attempts   int
+		wantCalls  int
+		wantSleeps int
+		wantErr    bool
+	}{
+		{name: "succeeds first time", err: errors.New("connection reset"), attempts: 3, wantCalls: 1},
+		{name: "fails once then succeeds", err: errors.New("internal server error"), failures: 1, attempts: 3, wantCalls: 2, wantSleeps: 1},
+		{name: "recovers", err: errors.New("connection reset"), failures: 2, attempts: 3, wantCalls: 3, wantSleeps: 2},
+		{name: "runs out of attempts", err: errors.New("connection reset"), failures: 10, attempts: 4, wantCalls: 4, wantSleeps: 3, wantErr: true},
+		{name: "default attempts", err: errors.New("connection reset"), failures: 10, wantCalls: DefaultRedisRetryAttempts, wantSleeps: DefaultRedisRetryAttempts - 1, wantErr: true},
+		{name: "permanent error not retried", err: errors.New("payload is empty"), failures: 10, attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "wrong type not retried", err: errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), failures: 10, attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "unknown error not retried", err: errors.New("unsupported parameter type: float32"), failures: 10, attempts: 3, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &failingRedisClient{err: tt.err, failures: tt.failures}
+			clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+			client := newRetryingRedisClient(fake, tt.attempts, clk)
+
+			data, err := client.Get("key")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(data) != "value" {
+				t.Errorf("Get() = %q, want the value once it succeeds", data)
+			}
+			if fake.calls != tt.wantCalls {
+				t.Errorf("Get() tried %d times, want %d", fake.calls, tt.wantCalls)
+			}
+
+			// Each delay is jittered between half and the full backoff, which doubles
+			sleeps := clk.Sleeps()
+			if len(sleeps) != tt.wantSleeps {
+				t.Fatalf("Get() slept %v, want %d sleeps", sleeps, tt.wantSleeps)
+			}
+			backoff := redisRetryBackoff
+			for i, sleep := range sleeps {
+				if sleep < backoff/2 || sleep > backoff {
+					t.Errorf("sleep %d = %v, want between %v and %v", i+1, sleep, backoff/2, backoff)
+				}
+				backoff *= 2
+			}
+		})
+	}
+}
+
+func TestRetryingRedisClientNonIdempotent(t *testing.T) {
+	tests := []struct {
+		command   string
+		arguments []any
+		wantCalls int
+	}{
+		{command: "SET", arguments: []any{"key", "1", "NX", "EX", 60}, wantCalls: 1},
+		{command: "INCR", arguments: []any{"key"}, wantCalls: 1},
+		{command: "SET", arguments: []any{"key", "NX", "EX", 60}, wantCalls: 3},
+		{command: "HSET", arguments: []any{"key", "data", "{}"}, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		fake := &failingRedisClient{err: errors.New("internal server error"), failures: 10}
+		client := newRetryingRedisClient(fake, 3, clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)))
+
+		if _, err := client.Execute(tt.command, tt.arguments...); err == nil {
+			t.Fatalf("Execute(%s %v) error = nil, want the failure", tt.command, tt.arguments)
+		}
+		if fake.calls != tt.wantCalls {
+			t.Errorf("Execute(%s %v) tried %d times, want %d", tt.command, tt.arguments, fake.calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestJitteredTTL(t *testing.T) {
+	if got := jitteredTTL(3600, 0); got != 3600 {
+		t.Errorf("jitteredTTL(3600, 0) = %d, want 3600", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := jitteredTTL(3600, 0.1); got < 3240 || got > 3960 {
+			t.Fatalf("jitteredTTL(3600, 0.1) = %d, want within ±10%%", got)
+		}
+	}
+	if got := jitteredTTL(1, 0.99); got < 1 {
+		t.Errorf("jitteredTTL(1, 0.99) = %d, want at least 1", got)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	if got := cacheKey("", "user:%d:accounts", 42); got != "user:42:accounts" {
+		t.Errorf("cacheKey() = %q", got)
+	}
+	if got := cacheKey("prod", "user:%d:accounts", 42); got != "prod:user:42:accounts" {
+		t.Errorf("cacheKey() = %q", got)
+	}
+}
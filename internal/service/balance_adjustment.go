@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// DefaultAdjustmentPayee is the payee of balance adjustments when none is configured
+const DefaultAdjustmentPayee = "Balance adjustment"
+
+// AdjustBalance implements TransactionService.AdjustBalance.
+// The balance is fetched fresh rather than from the cache, since a stale one would
+// post the wrong delta. The adjustment is dated today in the user's time zone, when known.
+func (s *TransactionServiceImpl) AdjustBalance(ctx context.Context, account, currency, target string) (*domain.BalanceAdjustment, error) {
+	targetBalance, err := strconv.ParseFloat(target, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target balance %q: %w", target, err)
+	}
+
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	accounts, err := s.client.RefreshTransactionAccounts(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh transaction accounts: %w", err)
+	}
+	found, err := resolveAccount(accounts, account, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work in the account currency's precision, so float noise can't leave a tiny delta
+	precision := currencyPrecision(found.CurrencyCode)
+	adjustment := &domain.BalanceAdjustment{
+		Currency:        strings.ToUpper(found.CurrencyCode),
+		PreviousBalance: roundTo(found.CurrentBalance, precision),
+		Balance:         roundTo(targetBalance, precision),
+	}
+	adjustment.Delta = roundTo(adjustment.Balance-adjustment.PreviousBalance, precision)
+	if adjustment.Delta == 0 {
+		log.Printf("Account %d is already at balance %.*f, no adjustment needed", found.ID, precision, adjustment.Balance)
+		return adjustment, nil
+	}
+
+	var categoryID *int
+	if s.options.AdjustmentCategory != "" {
+		categories, err := s.getCategories(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get categories: %w", err)
+		}
+		categoryID, err = s.resolveCategory(categories, nil, s.options.AdjustmentCategory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payee := s.options.AdjustmentPayee
+	if payee == "" {
+		payee = DefaultAdjustmentPayee
+	}
+	now := s.options.Clock.Now()
+	if zone := s.userTimeZone(user); zone != nil {
+		now = now.In(zone)
+	}
+	psTx := &domain.PocketSmithTransaction{
+		Payee:      payee,
+		Amount:     strconv.FormatFloat(adjustment.Delta, 'f', precision, 64),
+		Date:       now.Format("2006-01-02"),
+		CategoryID: categoryID,
+		Labels:     strings.Join(s.options.DefaultLabels, ","),
+	}
+
+	adjustment.TransactionID, err = s.client.CreateTransaction(ctx, found.ID, psTx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the balance changed, so patch it into the cached accounts rather than dropping them
+	s.client.UpdateCachedAccountBalance(ctx, user.ID, found.ID, adjustment.Balance)
+
+	log.Printf("Adjusted account %d from %.*f to %.*f with transaction %d (amount: %s)", found.ID, precision, adjustment.PreviousBalance, precision, adjustment.Balance, adjustment.TransactionID, psTx.Amount)
+	return adjustment, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// balanceClient serves accounts with balances on refresh and records the balances
+// patched into the cache
+type balanceClient struct {
+	*recordingClient
+	patched map[int]float64
+}
+
+func (c *balanceClient) RefreshTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	c.count("RefreshTransactionAccounts")
+	return c.accounts, nil
+}
+
+func (c *balanceClient) UpdateCachedAccountBalance(ctx context.Context, userID, accountID int, balance float64) {
+	c.patched[accountID] = balance
+}
+
+func TestAdjustBalance(t *testing.T) {
+	tests := []struct {
+		name        string
+		account     string
+		target      string
+		want        domain.BalanceAdjustment
+		wantAmount  string
+		wantPatched map[int]float64
+	}{
+		{
+			name:    "positive delta",
+			account: "Everyday",
+			target:  "150.25",
+			want:    domain.BalanceAdjustment{TransactionID: 101, Currency: "AUD", PreviousBalance: 100.1, Balance: 150.25, Delta: 50.15},
+			// The delta is formatted in the account currency's precision
+			wantAmount:  "50.15",
+			wantPatched: map[int]float64{10: 150.25},
+		},
+		{
+			name:        "negative delta",
+			account:     "Everyday",
+			target:      "80",
+			want:        domain.BalanceAdjustment{TransactionID: 101, Currency: "AUD", PreviousBalance: 100.1, Balance: 80, Delta: -20.1},
+			wantAmount:  "-20.10",
+			wantPatched: map[int]float64{10: 80},
+		},
+		{
+			name:        "zero decimal currency",
+			account:     "Tokyo",
+			target:      "5000",
+			want:        domain.BalanceAdjustment{TransactionID: 101, Currency: "JPY", PreviousBalance: 4200, Balance: 5000, Delta: 800},
+			wantAmount:  "800",
+			wantPatched: map[int]float64{12: 5000},
+		},
+		{
+			name:        "already balanced",
+			account:     "Everyday",
+			target:      "100.10",
+			want:        domain.BalanceAdjustment{Currency: "AUD", PreviousBalance: 100.1, Balance: 100.1},
+			wantPatched: map[int]float64{},
+		},
+		{
+			name:        "balanced within the currency's precision",
+			account:     "Everyday",
+			target:      "100.104",
+			want:        domain.BalanceAdjustment{Currency: "AUD", PreviousBalance: 100.1, Balance: 100.1},
+			wantPatched: map[int]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &balanceClient{recordingClient: newRecordingClient(), patched: make(map[int]float64)}
+			client.accounts = []domain.TransactionAccount{
+				{ID: 10, Name: "Everyday", CurrencyCode: "aud", CurrentBalance: 100.1},
+				{ID: 12, Name: "Tokyo", CurrencyCode: "jpy", CurrentBalance: 4200},
+			}
+			clk := clock.NewFake(time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC))
+			svc := NewTransactionService(client, Options{Clock: clk, AdjustmentPayee: "Reconciliation"})
+
+			got, err := svc.AdjustBalance(context.Background(), tt.account, "", tt.target)
+			if err != nil {
+				t.Fatalf("AdjustBalance() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("AdjustBalance() = %+v, want %+v", *got, tt.want)
+			}
+			if !reflect.DeepEqual(client.patched, tt.wantPatched) {
+				t.Errorf("patched balances = %v, want %v", client.patched, tt.wantPatched)
+			}
+			if tt.wantAmount == "" {
+				if len(client.created) > 0 {
+					t.Errorf("created %d transactions, want none", len(client.created))
+				}
+				return
+			}
+			if len(client.created) != 1 {
+				t.Fatalf("created %d transactions, want 1", len(client.created))
+			}
+			created := client.created[0]
+			if created.Amount != tt.wantAmount || created.Payee != "Reconciliation" || created.Date != "2025-01-13" {
+				t.Errorf("created %+v, want amount %s from Reconciliation on 2025-01-13", created, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestAdjustBalanceCategory(t *testing.T) {
+	client := &balanceClient{recordingClient: newRecordingClient(), patched: make(map[int]float64)}
+	client.accounts = []domain.TransactionAccount{{ID: 10, Name: "Everyday", CurrencyCode: "aud", CurrentBalance: 10}}
+	svc := NewTransactionService(client, Options{AdjustmentCategory: "Groceries"})
+
+	if _, err := svc.AdjustBalance(context.Background(), "Everyday", "", "12"); err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Fatalf("created %d transactions, want 1", len(client.created))
+	}
+	created := client.created[0]
+	if created.CategoryID == nil || *created.CategoryID != 20 || created.Payee != DefaultAdjustmentPayee {
+		t.Errorf("created %+v, want category 20 from %s", created, DefaultAdjustmentPayee)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// DefaultBatchConcurrency is the number of batch items created at once when not configured
+const DefaultBatchConcurrency = 4
+
+const (
+	// batchRateLimitRetries is how many times a batch item rejected with 429 is retried
+	batchRateLimitRetries = 3
+
+	// batchRateLimitBackoff is how long the pool pauses after a 429 without Retry-After
+	batchRateLimitBackoff = 2 * time.Second
+)
+
+// ErrRateLimitExhausted means an item was skipped because PocketSmith reported
+// no requests remaining in the current rate-limit window
+var ErrRateLimitExhausted = errors.New("PocketSmith rate limit exhausted, item not attempted")
+
+// BatchResult is the outcome of one item of AddTransactions
+type BatchResult struct {
+	// ID is the PocketSmith transaction ID, when the item succeeded and it is known
+	ID  int
+	Err error
+}
+
+// AddTransactions implements TransactionService.AddTransactions.
+// Items are created by a pool of BatchConcurrency workers, and each result is
+// returned at the index of its transaction. When PocketSmith answers 429, the whole
+// pool pauses for its Retry-After and the item is tried again.
+func (s *TransactionServiceImpl) AddTransactions(ctx context.Context, txs []*domain.Transaction) []BatchResult {
+	concurrency := s.options.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]BatchResult, len(txs))
+	indexes := make(chan int)
+	pause := &batchPause{clock: s.options.Clock}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(txs); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = s.addBatchItem(ctx, txs[i], pause)
+			}
+		}()
+	}
+
+	for i := range txs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// addBatchItem creates one batch item, waiting out pool pauses and retrying it after a 429
+func (s *TransactionServiceImpl) addBatchItem(ctx context.Context, tx *domain.Transaction, pause *batchPause) BatchResult {
+	for attempt := 0; ; attempt++ {
+		pause.wait()
+
+		// Don't start items nobody is waiting for any more
+		if err := ctx.Err(); err != nil {
+			return BatchResult{Err: err}
+		}
+
+		added, err := s.AddTransaction(ctx, tx)
+		if err == nil {
+			return BatchResult{ID: added.ID}
+		}
+		if !IsRateLimited(err) || attempt == batchRateLimitRetries {
+			return BatchResult{Err: err}
+		}
+
+		// A 429 means nothing was created, so the item is safe to send again once
+		// PocketSmith's window has moved on, unless that's past the request's deadline
+		wait := RetryAfter(err)
+		if wait <= 0 {
+			wait = batchRateLimitBackoff
+		}
+		if deadline, ok := ctx.Deadline(); ok && s.options.Clock.Now().Add(wait).After(deadline) {
+			return BatchResult{Err: err}
+		}
+		log.Printf("Warning: Batch item rate limited (attempt %d of %d), pausing the batch for %v", attempt+1, batchRateLimitRetries+1, wait)
+		pause.extend(wait)
+	}
+}
+
+// batchPause holds back every worker of a batch pool while PocketSmith's rate limit cools down
+type batchPause struct {
+	clock clock.Clock
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// extend makes the pause last at least d from now
+func (p *batchPause) extend(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until := p.clock.Now().Add(d); until.After(p.until) {
+		p.until = until
+	}
+}
+
+// wait returns once the pause is over. The lock is held while sleeping, so the other
+// workers queue behind the sleeper rather than each sleeping the pause again.
+func (p *batchPause) wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if d := p.until.Sub(p.clock.Now()); d > 0 {
+		p.clock.Sleep(d)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// batchClient creates transactions through create, keyed by the whole amount of each,
+// and tracks how many creates run at once
+type batchClient struct {
+	*countingClient
+	create func(item int, attempt int) (int, error)
+
+	mu          sync.Mutex
+	attempts    map[int]int
+	inFlight    int
+	maxInFlight int
+}
+
+func newBatchClient(create func(item int, attempt int) (int, error)) *batchClient {
+	return &batchClient{countingClient: newCountingClient(), create: create, attempts: make(map[int]int)}
+}
+
+func (c *batchClient) CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
+	amount, err := strconv.ParseFloat(transaction.Amount, 64)
+	if err != nil {
+		return 0, err
+	}
+	item := int(math.Abs(amount))
+
+	c.mu.Lock()
+	c.attempts[item]++
+	attempt := c.attempts[item]
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+	return c.create(item, attempt)
+}
+
+// batchItems returns n transactions whose amounts are their indexes
+func batchItems(n int) []*domain.Transaction {
+	txs := make([]*domain.Transaction, n)
+	for i := range txs {
+		txs[i] = &domain.Transaction{Account: "Everyday", Category: "Groceries", Amount: fmt.Sprintf("-%d.00", i), Date: "2025-01-13"}
+	}
+	return txs
+}
+
+func TestAddTransactionsConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		items       int
+		want        int
+	}{
+		{name: "one at a time", concurrency: 1, items: 6, want: 1},
+		{name: "configured", concurrency: 3, items: 12, want: 3},
+		{name: "default", items: 12, want: DefaultBatchConcurrency},
+		{name: "fewer items than workers", concurrency: 8, items: 2, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newBatchClient(func(item, attempt int) (int, error) {
+				time.Sleep(10 * time.Millisecond)
+				return 1000 + item, nil
+			})
+			svc := NewTransactionService(client, Options{BatchConcurrency: tt.concurrency})
+
+			for i, result := range svc.AddTransactions(context.Background(), batchItems(tt.items)) {
+				if result.Err != nil {
+					t.Errorf("item %d error = %v", i, result.Err)
+				}
+			}
+			if client.maxInFlight != tt.want {
+				t.Errorf("%d creates ran at once, want %d", client.maxInFlight, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionsIndexMapping(t *testing.T) {
+	// Later items finish first, so results come back out of order
+	client := newBatchClient(func(item, attempt int) (int, error) {
+		time.Sleep(time.Duration(10-item) * time.Millisecond)
+		if item == 4 {
+			return 0, &api.StatusError{StatusCode: 422, Body: `{"error": "bad"}`}
+		}
+		return 1000 + item, nil
+	})
+	svc := NewTransactionService(client, Options{BatchConcurrency: 4})
+
+	txs := batchItems(10)
+	txs[7].Account = "Cash"
+	results := svc.AddTransactions(context.Background(), txs)
+
+	if len(results) != len(txs) {
+		t.Fatalf("%d results for %d items", len(results), len(txs))
+	}
+	for i, result := range results {
+		switch i {
+		case 4:
+			if api.StatusCode(result.Err) != 422 {
+				t.Errorf("item 4 error = %v, want the 422", result.Err)
+			}
+		case 7:
+			if !IsLookupError(result.Err) {
+				t.Errorf("item 7 error = %v, want the unknown account", result.Err)
+			}
+		default:
+			if result.Err != nil || result.ID != 1000+i {
+				t.Errorf("item %d = %d, %v, want ID %d", i, result.ID, result.Err, 1000+i)
+			}
+		}
+	}
+}
+
+func TestAddTransactionsRateLimitBackoff(t *testing.T) {
+	rateLimited := func(after time.Duration) error { return &api.RateLimitedError{RetryAfter: after} }
+	tests := []struct {
+		name         string
+		create       func(item, attempt int) (int, error)
+		timeout      time.Duration
+		wantSleeps   []time.Duration
+		wantAttempts map[int]int
+		wantLimited  []int
+	}{
+		{
+			name: "pauses for Retry-After and retries",
+			create: func(item, attempt int) (int, error) {
+				if item == 1 && attempt == 1 {
+					return 0, rateLimited(30 * time.Second)
+				}
+				return 1000 + item, nil
+			},
+			wantSleeps:   []time.Duration{30 * time.Second},
+			wantAttempts: map[int]int{0: 1, 1: 2, 2: 1},
+		},
+		{
+			name: "default pause without Retry-After",
+			create: func(item, attempt int) (int, error) {
+				if item == 0 && attempt == 1 {
+					return 0, rateLimited(0)
+				}
+				return 1000 + item, nil
+			},
+			wantSleeps:   []time.Duration{batchRateLimitBackoff},
+			wantAttempts: map[int]int{0: 2, 1: 1, 2: 1},
+		},
+		{
+			name: "gives up after the retries",
+			create: func(item, attempt int) (int, error) {
+				if item == 2 {
+					return 0, rateLimited(time.Second)
+				}
+				return 1000 + item, nil
+			},
+			wantSleeps:   []time.Duration{time.Second, time.Second, time.Second},
+			wantAttempts: map[int]int{0: 1, 1: 1, 2: batchRateLimitRetries + 1},
+			wantLimited:  []int{2},
+		},
+		{
+			name: "pause past the deadline isn't waited",
+			create: func(item, attempt int) (int, error) {
+				if item == 0 {
+					return 0, rateLimited(time.Minute)
+				}
+				return 1000 + item, nil
+			},
+			timeout:      10 * time.Second,
+			wantAttempts: map[int]int{0: 1, 1: 1, 2: 1},
+			wantLimited:  []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Now())
+			client := newBatchClient(tt.create)
+			svc := NewTransactionService(client, Options{BatchConcurrency: 1, Clock: clk})
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			results := svc.AddTransactions(ctx, batchItems(3))
+
+			limited := map[int]bool{}
+			for _, i := range tt.wantLimited {
+				limited[i] = true
+			}
+			for i, result := range results {
+				if limited[i] != IsRateLimited(result.Err) || (!limited[i] && result.Err != nil) {
+					t.Errorf("item %d error = %v, want rate limited %t", i, result.Err, limited[i])
+				}
+			}
+			if fmt.Sprint(clk.Sleeps()) != fmt.Sprint(tt.wantSleeps) {
+				t.Errorf("slept %v, want %v", clk.Sleeps(), tt.wantSleeps)
+			}
+			if fmt.Sprint(client.attempts) != fmt.Sprint(tt.wantAttempts) {
+				t.Errorf("attempts = %v, want %v", client.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestAddTransactionsPausesWholePool(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var limitedOnce atomic.Bool
+	client := newBatchClient(func(item, attempt int) (int, error) {
+		if item == 0 && limitedOnce.CompareAndSwap(false, true) {
+			return 0, &api.RateLimitedError{RetryAfter: 30 * time.Second}
+		}
+		return 1000 + item, nil
+	})
+	svc := NewTransactionService(client, Options{BatchConcurrency: 4, Clock: clk})
+
+	for i, result := range svc.AddTransactions(context.Background(), batchItems(8)) {
+		if result.Err != nil {
+			t.Errorf("item %d error = %v", i, result.Err)
+		}
+	}
+
+	// Workers waiting on the pause queue behind the one sleeping it, so it's slept once
+	if sleeps := clk.Sleeps(); len(sleeps) != 1 || sleeps[0] > 30*time.Second {
+		t.Errorf("slept %v, want one pause of at most 30s", sleeps)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// CategoryImportResult is the outcome of one path of ImportCategories
+type CategoryImportResult struct {
+	// ID is the PocketSmith ID of the path's last category, whether created or existing
+	ID int
+	// Created is false when the category already existed and was skipped
+	Created bool
+	Err     error
+}
+
+// ImportCategories implements TransactionService.ImportCategories.
+// Paths are imported in order so later ones can nest under categories created by
+// earlier ones, and the cached categories are dropped once at the end.
+func (s *TransactionServiceImpl) ImportCategories(ctx context.Context, paths [][]string) ([]CategoryImportResult, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Start from a fresh copy so existing categories are reliably skipped
+	categories, err := s.client.RefreshCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	results := make([]CategoryImportResult, len(paths))
+	created := false
+	for i, path := range paths {
+		// Don't start paths nobody is waiting for any more
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		// Back off once PocketSmith says the window is used up, rather than piling on
+		if s.client.RateLimit().Remaining == "0" {
+			results[i].Err = ErrRateLimitExhausted
+			continue
+		}
+		results[i] = s.importCategoryPath(ctx, user.ID, &categories, path, &created)
+	}
+
+	if created {
+		if err := s.client.InvalidateCategories(ctx, user.ID); err != nil {
+			log.Printf("Warning: Failed to invalidate cached categories after import: %v", err)
+		}
+	}
+	return results, nil
+}
+
+// importCategoryPath walks path down the tree, matching titles case-insensitively and
+// creating each category that doesn't exist yet. New categories are added to the tree
+// and recorded in created.
+func (s *TransactionServiceImpl) importCategoryPath(ctx context.Context, userID int, tree *[]domain.Category, path []string, created *bool) CategoryImportResult {
+	var result CategoryImportResult
+	var parentID *int
+	level := tree
+	for _, title := range path {
+		i := indexCategoryByTitle(*level, title)
+		result.Created = i < 0
+		if result.Created {
+			category, err := s.client.PostCategory(ctx, userID, title, parentID)
+			if err != nil {
+				return CategoryImportResult{Err: err}
+			}
+			*created = true
+			*level = append(*level, *category)
+			i = len(*level) - 1
+		}
+
+		id := (*level)[i].ID
+		result.ID, parentID = id, &id
+		level = &(*level)[i].Children
+	}
+	return result
+}
+
+// indexCategoryByTitle returns the index of the category titled title (case-insensitive), or -1
+func indexCategoryByTitle(categories []domain.Category, title string) int {
+	for i, category := range categories {
+		if strings.EqualFold(category.Title, title) {
+			return i
+		}
+	}
+	return -1
+}
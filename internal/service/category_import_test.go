@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// importClient creates categories with increasing IDs from 100, except the titles in
+// failing, and counts the cache invalidations
+type importClient struct {
+	*recordingClient
+	failing       map[string]bool
+	posted        []string
+	invalidations int
+}
+
+func (c *importClient) RefreshCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	return append([]domain.Category(nil), c.categories...), nil
+}
+
+func (c *importClient) PostCategory(ctx context.Context, userID int, title string, parentID *int) (*domain.Category, error) {
+	if c.failing[title] {
+		return nil, errors.New("title rejected")
+	}
+	c.posted = append(c.posted, title)
+	return &domain.Category{ID: 99 + len(c.posted), Title: title, ParentID: parentID}, nil
+}
+
+func (c *importClient) InvalidateCategories(ctx context.Context, userID int) error {
+	c.invalidations++
+	return nil
+}
+
+func (c *importClient) RateLimit() domain.RateLimit {
+	return domain.RateLimit{}
+}
+
+func TestImportCategories(t *testing.T) {
+	tests := []struct {
+		name              string
+		paths             [][]string
+		want              []CategoryImportResult
+		wantPosted        []string
+		wantInvalidations int
+	}{
+		{
+			name: "new, existing and failing",
+			paths: [][]string{
+				{"food", "groceries"},
+				{"Rent"},
+				{"Food", "Dining"},
+				{"Broken"},
+				{"Rent", "Bond"},
+				{"rent"},
+			},
+			want: []CategoryImportResult{
+				{ID: 31},
+				{ID: 100, Created: true},
+				{ID: 101, Created: true},
+				{Err: errors.New("title rejected")},
+				{ID: 102, Created: true},
+				{ID: 100},
+			},
+			wantPosted:        []string{"Rent", "Dining", "Bond"},
+			wantInvalidations: 1,
+		},
+		{
+			name:  "all existing",
+			paths: [][]string{{"Food"}, {"Food", "Groceries"}, {"SALARY"}},
+			want:  []CategoryImportResult{{ID: 30}, {ID: 31}, {ID: 21}},
+		},
+		{
+			name:  "all failing",
+			paths: [][]string{{"Broken"}},
+			want:  []CategoryImportResult{{Err: errors.New("title rejected")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &importClient{recordingClient: newRecordingClient(), failing: map[string]bool{"Broken": true}}
+			client.categories = nestedCategories()
+			svc := NewTransactionService(client, Options{})
+
+			results, err := svc.ImportCategories(context.Background(), tt.paths)
+			if err != nil {
+				t.Fatalf("ImportCategories() error = %v", err)
+			}
+			if !reflect.DeepEqual(results, tt.want) {
+				t.Errorf("ImportCategories() = %+v, want %+v", results, tt.want)
+			}
+			if !reflect.DeepEqual(client.posted, tt.wantPosted) {
+				t.Errorf("posted %q, want %q", client.posted, tt.wantPosted)
+			}
+			// The cache is dropped once at the end, and only when something was created
+			if client.invalidations != tt.wantInvalidations {
+				t.Errorf("invalidated the cache %d times, want %d", client.invalidations, tt.wantInvalidations)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// categorySelfHealCooldown is the minimum time between self-heal refreshes of a user's
+// categories, so clients sending a genuinely unknown category can't stampede PocketSmith
+const categorySelfHealCooldown = time.Minute
+
+// selfHealCategories refetches the categories after a lookup miss, in case the cache
+// predates a category created in PocketSmith. It returns nil when a refresh already
+// ran within the cooldown or the refetch fails, leaving the original miss to be reported.
+func (s *TransactionServiceImpl) selfHealCategories(ctx context.Context, userID int) []domain.Category {
+	acquired, err := s.client.AcquireCooldown(ctx, userID, "category_self_heal", categorySelfHealCooldown)
+	if err != nil {
+		log.Printf("Warning: Failed to check category self-heal cooldown, skipping refresh: %v", err)
+		return nil
+	}
+	if !acquired {
+		log.Printf("Category self-heal for user %d skipped, categories were refreshed within the last %v", userID, categorySelfHealCooldown)
+		return nil
+	}
+
+	log.Printf("Category not found for user %d, refetching categories in case the cache is stale", userID)
+	categories, err := s.client.RefreshCategories(ctx, userID)
+	if err != nil {
+		log.Printf("Warning: Category self-heal refresh failed: %v", err)
+		return nil
+	}
+
+	// Later lookups in this request (e.g. other batch items) should see the refetched list
+	if cache := requestCacheFrom(ctx); cache != nil {
+		cache.categoriesMu.Lock()
+		cache.categories[userID] = categories
+		cache.categoriesMu.Unlock()
+	}
+	return categories
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// selfHealClient serves stale categories until they're refreshed, after which it serves
+// fresh ones, and keeps cooldowns expiring on a fake clock
+type selfHealClient struct {
+	*recordingClient
+	fresh     []domain.Category
+	refreshes int
+	clock     *clock.Fake
+	cooldowns map[string]time.Time
+}
+
+func newSelfHealClient(stale, fresh []domain.Category) *selfHealClient {
+	client := &selfHealClient{
+		recordingClient: newRecordingClient(),
+		fresh:           fresh,
+		clock:           clock.NewFake(time.Date(2025, 1, 13, 8, 30, 0, 0, time.UTC)),
+		cooldowns:       make(map[string]time.Time),
+	}
+	client.categories = stale
+	return client
+}
+
+func (c *selfHealClient) RefreshCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	c.refreshes++
+	c.categories = c.fresh
+	return c.fresh, nil
+}
+
+func (c *selfHealClient) AcquireCooldown(ctx context.Context, userID int, name string, period time.Duration) (bool, error) {
+	if until, ok := c.cooldowns[name]; ok && c.clock.Now().Before(until) {
+		return false, nil
+	}
+	c.cooldowns[name] = c.clock.Now().Add(period)
+	return true, nil
+}
+
+func TestAddTransactionCategorySelfHeal(t *testing.T) {
+	stale := []domain.Category{{ID: 20, Title: "Groceries"}}
+	fresh := []domain.Category{{ID: 20, Title: "Groceries"}, {ID: 22, Title: "Rent"}}
+	tests := []struct {
+		name          string
+		selfHeal      bool
+		category      string
+		cooldownHeld  bool
+		wantID        int
+		wantRefreshes int
+	}{
+		{name: "stale cache, then found", selfHeal: true, category: "Rent", wantID: 22, wantRefreshes: 1},
+		{name: "genuinely missing", selfHeal: true, category: "Holidays", wantRefreshes: 1},
+		{name: "found without a refresh", selfHeal: true, category: "Groceries", wantID: 20},
+		{name: "refreshed within the cooldown", selfHeal: true, category: "Rent", cooldownHeld: true},
+		{name: "self-heal off", category: "Rent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newSelfHealClient(stale, fresh)
+			if tt.cooldownHeld {
+				client.AcquireCooldown(context.Background(), 1, "category_self_heal", time.Minute)
+			}
+			svc := NewTransactionService(client, Options{CategorySelfHeal: tt.selfHeal})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.category, Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+			})
+			if client.refreshes != tt.wantRefreshes {
+				t.Errorf("refreshed %d times, want %d", client.refreshes, tt.wantRefreshes)
+			}
+			if tt.wantID == 0 {
+				if ErrorCode(err) != CodeCategoryNotFound {
+					t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, CodeCategoryNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if got := client.created[0].CategoryID; got == nil || *got != tt.wantID {
+				t.Errorf("created with category %v, want %d", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCategorySelfHealCooldown(t *testing.T) {
+	client := newSelfHealClient([]domain.Category{{ID: 20, Title: "Groceries"}}, []domain.Category{{ID: 20, Title: "Groceries"}})
+	svc := NewTransactionService(client, Options{CategorySelfHeal: true})
+	addMissing := func() {
+		_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+			Account: "Everyday", Category: "Holidays", Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+		})
+		if ErrorCode(err) != CodeCategoryNotFound {
+			t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, CodeCategoryNotFound)
+		}
+	}
+
+	// Misses refresh at most once per cooldown, however often they repeat
+	addMissing()
+	addMissing()
+	if client.refreshes != 1 {
+		t.Errorf("refreshed %d times within the cooldown, want 1", client.refreshes)
+	}
+
+	client.clock.Advance(categorySelfHealCooldown)
+	addMissing()
+	if client.refreshes != 2 {
+		t.Errorf("refreshed %d times after the cooldown, want 2", client.refreshes)
+	}
+}
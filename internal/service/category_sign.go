@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// checkCategorySign compares the sign of the amount with the orientation of the category
+// it is posted to: income categories take positive amounts and expense categories negative
+// ones. A mismatch is logged, or rejected with EnforceCategorySign.
+// Transfers and zero amounts have no orientation and are never checked.
+func (s *TransactionServiceImpl) checkCategorySign(categories []domain.Category, categoryID int, tx *domain.Transaction) error {
+	if tx.IsTransfer {
+		return nil
+	}
+	amount, err := strconv.ParseFloat(tx.Amount, 64)
+	if err != nil || amount == 0 {
+		return nil
+	}
+	category, ok := categoryByID(categories, categoryID)
+	if !ok || category.IsTransfer || category.IsIncome == (amount > 0) {
+		return nil
+	}
+
+	orientation, sign := "an expense", "positive"
+	if category.IsIncome {
+		orientation, sign = "an income", "negative"
+	}
+	message := fmt.Sprintf("category %s is %s category but the amount %s is %s", category.Title, orientation, tx.Amount, sign)
+	if s.options.EnforceCategorySign {
+		log.Printf("ERROR: Rejected transaction: %s", message)
+		return &lookupError{code: CodeCategorySignMismatch, message: message}
+	}
+	log.Printf("Warning: %s (payee: '%s')", message, tx.Merchant)
+	return nil
+}
+
+// categoryByID finds a category anywhere in the tree
+func categoryByID(categories []domain.Category, id int) (domain.Category, bool) {
+	for _, category := range flattenCategories(categories) {
+		if category.ID == id {
+			return category, true
+		}
+	}
+	return domain.Category{}, false
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestCheckCategorySign(t *testing.T) {
+	tests := []struct {
+		name       string
+		category   string
+		amount     string
+		isTransfer bool
+		enforce    bool
+		wantReject bool
+	}{
+		{name: "expense with a negative amount", category: "Groceries", amount: "-5.00", enforce: true},
+		{name: "income with a positive amount", category: "Salary", amount: "1000.00", enforce: true},
+		{name: "expense with a positive amount, warned", category: "Groceries", amount: "5.00"},
+		{name: "income with a negative amount, warned", category: "Salary", amount: "-1000.00"},
+		{name: "expense with a positive amount, enforced", category: "Groceries", amount: "5.00", enforce: true, wantReject: true},
+		{name: "income with a negative amount, enforced", category: "Salary", amount: "-1000.00", enforce: true, wantReject: true},
+		{name: "zero amount", category: "Salary", amount: "0.00", enforce: true},
+		{name: "transfer", category: "Groceries", amount: "5.00", isTransfer: true, enforce: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = nestedCategories()
+			svc := NewTransactionService(client, Options{EnforceCategorySign: tt.enforce})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.category, Merchant: "Corner Store", Amount: tt.amount, Date: "2025-01-13", IsTransfer: tt.isTransfer,
+			})
+			if tt.wantReject {
+				if ErrorCode(err) != CodeCategorySignMismatch {
+					t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, CodeCategorySignMismatch)
+				}
+				if len(client.created) > 0 {
+					t.Errorf("created %d transactions, want none", len(client.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if len(client.created) != 1 {
+				t.Errorf("created %d transactions, want 1", len(client.created))
+			}
+		})
+	}
+}
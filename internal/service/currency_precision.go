@@ -0,0 +1,34 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// defaultCurrencyPrecision is the number of minor-unit digits for currencies not in the table
+const defaultCurrencyPrecision = 2
+
+// currencyPrecisions lists ISO 4217 currencies whose minor unit isn't 2 digits
+var currencyPrecisions = map[string]int{
+	// No minor unit
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// Three-digit minor unit
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// currencyPrecision returns the number of decimal places used by a currency (case-insensitive)
+func currencyPrecision(currency string) int {
+	if precision, ok := currencyPrecisions[strings.ToUpper(currency)]; ok {
+		return precision
+	}
+	return defaultCurrencyPrecision
+}
+
+// RoundAmount rounds a normalized amount to the currency's minor-unit precision, exactly
+// on its decimal digits. The amount is returned unchanged if it isn't a number.
+func RoundAmount(amount, currency string) string {
+	rounded, _ := domain.RoundDecimal(amount, currencyPrecision(currency))
+	return rounded
+}
@@ -0,0 +1,22 @@
+package service
+
+import "testing"
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		{amount: "2.675", currency: "usd", want: "2.68"},
+		{amount: "-1234.5", currency: "JPY", want: "-1235"},
+		{amount: "1.2345", currency: "KWD", want: "1.235"},
+		{amount: "10", currency: "EUR", want: "10.00"},
+		{amount: "not a number", currency: "EUR", want: "not a number"},
+	}
+	for _, tt := range tests {
+		if got := RoundAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("RoundAmount(%q, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// DedupMode selects what happens to a transaction identical to one submitted within the dedup window
+type DedupMode string
+
+const (
+	// DedupReject fails the duplicate with ErrDuplicateSubmission (default)
+	DedupReject DedupMode = "reject"
+	// DedupIgnore answers the duplicate like a success without creating it
+	DedupIgnore DedupMode = "ignore"
+)
+
+// CodeDuplicateSubmission identifies ErrDuplicateSubmission in error bodies
+const CodeDuplicateSubmission LookupErrorCode = "duplicate_submission"
+
+// ErrDuplicateSubmission means an identical transaction was submitted within the dedup window,
+// e.g. by a double-tapped shortcut
+var ErrDuplicateSubmission = errors.New("duplicate submission: an identical transaction was submitted moments ago")
+
+// ParseDedupMode parses a dedup mode, defaulting to reject when empty
+func ParseDedupMode(value string) (DedupMode, error) {
+	switch mode := DedupMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return DedupReject, nil
+	case DedupReject, DedupIgnore:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown dedup mode: %s", value)
+	}
+}
+
+// claimSubmission records a transaction's content hash for the dedup window, reporting false
+// when the same content was already claimed within it. The hash covers the transaction as it
+// would be posted, so "coffee shop" and "Coffee Shop" sent to the same account and category
+// match. It takes the calendar day rather than any time of day, since a double-tapped
+// shortcut stamping "now" sends times seconds apart. Cache failures let the transaction
+// through rather than blocking it.
+func (s *TransactionServiceImpl) claimSubmission(ctx context.Context, userID int, accountID int, psTx *domain.PocketSmithTransaction) (claimed bool, name string) {
+	categoryID := 0
+	if psTx.CategoryID != nil {
+		categoryID = *psTx.CategoryID
+	}
+	content := fmt.Sprintf("%d\x00%d\x00%s\x00%s\x00%s", accountID, categoryID, psTx.Amount, psTx.Day(), strings.ToLower(psTx.Payee))
+	hash := sha256.Sum256([]byte(content))
+	name = "dedup:" + hex.EncodeToString(hash[:16])
+
+	acquired, err := s.client.AcquireCooldown(ctx, userID, name, s.options.DedupWindow)
+	if err != nil {
+		log.Printf("Warning: Failed to check for a duplicate submission, creating the transaction: %v", err)
+		return true, ""
+	}
+	return acquired, name
+}
+
+// releaseSubmission forgets a claimed content hash after the create failed, so an immediate
+// retry isn't mistaken for a duplicate
+func (s *TransactionServiceImpl) releaseSubmission(ctx context.Context, userID int, name string) {
+	if name == "" {
+		return
+	}
+	if err := s.client.ReleaseCooldown(ctx, userID, name); err != nil {
+		log.Printf("Warning: Failed to release duplicate submission check %s: %v", name, err)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// dedupClient adds cooldowns expiring on a fake clock and counted creates to countingClient
+type dedupClient struct {
+	*countingClient
+	clock     *clock.Fake
+	cooldowns map[string]time.Time
+	created   int
+}
+
+func newDedupClient(clk *clock.Fake) *dedupClient {
+	return &dedupClient{countingClient: newCountingClient(), clock: clk, cooldowns: make(map[string]time.Time)}
+}
+
+func (c *dedupClient) AcquireCooldown(ctx context.Context, userID int, name string, period time.Duration) (bool, error) {
+	if until, ok := c.cooldowns[name]; ok && c.clock.Now().Before(until) {
+		return false, nil
+	}
+	c.cooldowns[name] = c.clock.Now().Add(period)
+	return true, nil
+}
+
+func (c *dedupClient) ReleaseCooldown(ctx context.Context, userID int, name string) error {
+	delete(c.cooldowns, name)
+	return nil
+}
+
+func (c *dedupClient) CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
+	c.created++
+	return 100 + c.created, nil
+}
+
+func TestAddTransactionDedup(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 13, 8, 30, 0, 0, time.UTC))
+	client := newDedupClient(clk)
+	svc := NewTransactionService(client, Options{DedupWindow: 30 * time.Second})
+
+	submission := func(dateTime, amount string) *domain.Transaction {
+		return &domain.Transaction{Account: "Everyday", Category: "Groceries", Merchant: "Corner Store", Amount: amount, Date: "2025-01-13", DateTime: dateTime}
+	}
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		tx          *domain.Transaction
+		wantErr     error
+		wantCreated int
+	}{
+		{name: "first submission", tx: submission("2025-01-13T08:30:00+13:00", "-5.00"), wantCreated: 1},
+		{name: "double tap within the window, stamped seconds later", advance: 4 * time.Second, tx: submission("2025-01-13T08:30:04+13:00", "-5.00"), wantErr: ErrDuplicateSubmission, wantCreated: 1},
+		{name: "different amount within the window", tx: submission("2025-01-13T08:30:05+13:00", "-6.00"), wantCreated: 2},
+		{name: "same transaction after the window", advance: time.Minute, tx: submission("2025-01-13T08:31:05+13:00", "-5.00"), wantCreated: 3},
+	}
+	for _, step := range steps {
+		clk.Advance(step.advance)
+		_, err := svc.AddTransaction(context.Background(), step.tx)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: AddTransaction() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if client.created != step.wantCreated {
+			t.Fatalf("%s: created %d transactions, want %d", step.name, client.created, step.wantCreated)
+		}
+	}
+}
+
+func TestAddTransactionDedupIgnore(t *testing.T) {
+	client := newDedupClient(clock.NewFake(time.Date(2025, 1, 13, 8, 30, 0, 0, time.UTC)))
+	svc := NewTransactionService(client, Options{DedupWindow: 30 * time.Second, DedupMode: DedupIgnore})
+	tx := &domain.Transaction{Account: "Everyday", Category: "Groceries", Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13"}
+
+	if _, err := svc.AddTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("AddTransaction() error = %v", err)
+	}
+	added, err := svc.AddTransaction(context.Background(), tx)
+	if err != nil || !added.Duplicate || added.ID != 0 {
+		t.Fatalf("AddTransaction() = %+v, %v, want a duplicate answered like a success", added, err)
+	}
+	if client.created != 1 {
+		t.Errorf("created %d transactions, want 1", client.created)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// JobStepSize is the most items of a batch job created by one ProcessJob call
+const JobStepSize = 20
+
+// jobLockPeriod bounds how long a step that never finished keeps other polls from processing its job
+const jobLockPeriod = time.Minute
+
+// ErrJobNotFound means no batch job exists with the ID, e.g. because it expired
+var ErrJobNotFound = errors.New("job not found")
+
+// CreateJob implements TransactionService.CreateJob
+func (s *TransactionServiceImpl) CreateJob(ctx context.Context, job *domain.Job) error {
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	if err := s.client.SaveJob(ctx, user.ID, job); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+
+	log.Printf("Job %s created for user %d: %d items, %d pending", job.ID, user.ID, job.Total, len(job.Pending))
+	return nil
+}
+
+// ProcessJob implements TransactionService.ProcessJob.
+// Steps are serialized by a lock kept in the cache, so concurrent polls never create an
+// item twice: a poll finding the job locked gets it as last saved. Items skipped because
+// the rate limit ran out stay pending for the next step instead of failing.
+func (s *TransactionServiceImpl) ProcessJob(ctx context.Context, jobID string, describe func(BatchResult) domain.BatchItemResult) (*domain.Job, error) {
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	job, err := s.getJob(ctx, user.ID, jobID)
+	if err != nil || job.Status == domain.JobCompleted {
+		return job, err
+	}
+
+	lock := "job:" + jobID
+	acquired, err := s.client.AcquireCooldown(ctx, user.ID, lock, jobLockPeriod)
+	if err != nil {
+		log.Printf("Warning: Failed to lock job %s, returning it unprocessed: %v", jobID, err)
+		return job, nil
+	}
+	if !acquired {
+		log.Printf("Job %s is being processed by another request", jobID)
+		return job, nil
+	}
+	defer func() {
+		if err := s.client.ReleaseCooldown(ctx, user.ID, lock); err != nil {
+			log.Printf("Warning: Failed to unlock job %s: %v", jobID, err)
+		}
+	}()
+
+	// Reload, in case another request finished a step between the first read and the lock
+	if job, err = s.getJob(ctx, user.ID, jobID); err != nil || job.Status == domain.JobCompleted {
+		return job, err
+	}
+
+	step := job.Pending[:min(JobStepSize, len(job.Pending))]
+	txs := make([]*domain.Transaction, len(step))
+	for i, item := range step {
+		txs[i] = item.Transaction
+	}
+
+	var pending []domain.JobItem
+	for i, result := range s.AddTransactions(ctx, txs) {
+		if errors.Is(result.Err, ErrRateLimitExhausted) || IsRateLimited(result.Err) {
+			pending = append(pending, step[i])
+			continue
+		}
+		itemResult := describe(result)
+		itemResult.Index = step[i].Index
+		job.Results[step[i].Index] = itemResult
+		job.Processed++
+	}
+	job.Pending = append(pending, job.Pending[len(step):]...)
+
+	// A step that only hit the rate limit leaves a pending job pending
+	switch {
+	case len(job.Pending) == 0:
+		job.Status = domain.JobCompleted
+	case len(pending) < len(step):
+		job.Status = domain.JobRunning
+	}
+	job.UpdatedAt = s.options.Clock.Now().UTC().Format(time.RFC3339)
+
+	// The step's transactions exist now, so a job that can't be saved would create them again
+	if err := s.client.SaveJob(ctx, user.ID, job); err != nil {
+		log.Printf("ERROR: Failed to save job %s after processing a step, its items may be created twice: %v", jobID, err)
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	log.Printf("Job %s for user %d: %d/%d processed (%s)", jobID, user.ID, job.Processed, job.Total, job.Status)
+	return job, nil
+}
+
+// getJob reads a user's job, reporting ErrJobNotFound when it doesn't exist
+func (s *TransactionServiceImpl) getJob(ctx context.Context, userID int, jobID string) (*domain.Job, error) {
+	job, err := s.client.GetJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/clock"
+	"github.com/pocketsmith-proxy/internal/domain"
+	"github.com/pocketsmith-proxy/internal/repository"
+)
+
+// jobClient keeps jobs and locks like the cache does, each job expiring JobTTL after it
+// was last saved, and creates transactions like batchClient
+type jobClient struct {
+	*batchClient
+	clock *clock.Fake
+
+	jobMu   sync.Mutex
+	jobs    map[string][]byte
+	savedAt map[string]time.Time
+	locks   map[string]bool
+}
+
+func newJobClient(clk *clock.Fake, create func(item int, attempt int) (int, error)) *jobClient {
+	return &jobClient{
+		batchClient: newBatchClient(create),
+		clock:       clk,
+		jobs:        make(map[string][]byte),
+		savedAt:     make(map[string]time.Time),
+		locks:       make(map[string]bool),
+	}
+}
+
+func (c *jobClient) GetJob(ctx context.Context, userID int, jobID string) (*domain.Job, error) {
+	c.jobMu.Lock()
+	defer c.jobMu.Unlock()
+	raw, ok := c.jobs[jobID]
+	if !ok || !c.clock.Now().Before(c.savedAt[jobID].Add(repository.JobTTL*time.Second)) {
+		return nil, nil
+	}
+	var job domain.Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *jobClient) SaveJob(ctx context.Context, userID int, job *domain.Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	c.jobMu.Lock()
+	defer c.jobMu.Unlock()
+	c.jobs[job.ID] = raw
+	c.savedAt[job.ID] = c.clock.Now()
+	return nil
+}
+
+func (c *jobClient) AcquireCooldown(ctx context.Context, userID int, name string, period time.Duration) (bool, error) {
+	c.jobMu.Lock()
+	defer c.jobMu.Unlock()
+	if c.locks[name] {
+		return false, nil
+	}
+	c.locks[name] = true
+	return true, nil
+}
+
+func (c *jobClient) ReleaseCooldown(ctx context.Context, userID int, name string) error {
+	c.jobMu.Lock()
+	defer c.jobMu.Unlock()
+	delete(c.locks, name)
+	return nil
+}
+
+// newTestJob returns a pending job of n items whose amounts are their indexes
+func newTestJob(n int) *domain.Job {
+	job := &domain.Job{ID: "job-1", Status: domain.JobPending, Total: n, Results: make([]domain.BatchItemResult, n)}
+	for i, tx := range batchItems(n) {
+		job.Pending = append(job.Pending, domain.JobItem{Index: i, Transaction: tx})
+	}
+	return job
+}
+
+// describeTestResult describes a created item as 201 and a failed one as 500
+func describeTestResult(result BatchResult) domain.BatchItemResult {
+	if result.Err != nil {
+		return domain.BatchItemResult{Status: http.StatusInternalServerError, Error: result.Err.Error()}
+	}
+	return domain.BatchItemResult{Status: http.StatusCreated, ID: result.ID}
+}
+
+func TestProcessJobSteps(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+	client := newJobClient(clk, func(item, attempt int) (int, error) {
+		return 1000 + item, nil
+	})
+	svc := NewTransactionService(client, Options{Clock: clk})
+	ctx := context.Background()
+
+	if err := svc.CreateJob(ctx, newTestJob(45)); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	steps := []struct {
+		wantStatus    domain.JobStatus
+		wantProcessed int
+		wantPending   int
+	}{
+		{wantStatus: domain.JobRunning, wantProcessed: 20, wantPending: 25},
+		{wantStatus: domain.JobRunning, wantProcessed: 40, wantPending: 5},
+		{wantStatus: domain.JobCompleted, wantProcessed: 45},
+		// A completed job is returned as is
+		{wantStatus: domain.JobCompleted, wantProcessed: 45},
+	}
+	for i, step := range steps {
+		job, err := svc.ProcessJob(ctx, "job-1", describeTestResult)
+		if err != nil {
+			t.Fatalf("step %d: ProcessJob() error = %v", i+1, err)
+		}
+		if job.Status != step.wantStatus || job.Processed != step.wantProcessed || len(job.Pending) != step.wantPending {
+			t.Errorf("step %d: job %s with %d processed and %d pending, want %s with %d and %d",
+				i+1, job.Status, job.Processed, len(job.Pending), step.wantStatus, step.wantProcessed, step.wantPending)
+		}
+	}
+
+	job, _ := client.GetJob(ctx, 42, "job-1")
+	for i, result := range job.Results {
+		if result.Index != i || result.Status != http.StatusCreated || result.ID != 1000+i {
+			t.Errorf("result %d = %+v, want item %d created as %d", i, result, i, 1000+i)
+		}
+	}
+	if created := len(client.attempts); created != 45 {
+		t.Errorf("created %d items, want each of the 45 once", created)
+	}
+}
+
+func TestProcessJobStatus(t *testing.T) {
+	rateLimited := &api.RateLimitedError{RetryAfter: time.Second}
+	tests := []struct {
+		name          string
+		items         int
+		create        func(item, attempt int) (int, error)
+		locked        bool
+		wantStatus    domain.JobStatus
+		wantProcessed int
+		wantPending   int
+		wantCreates   int
+	}{
+		{
+			name:       "failed items get their result",
+			items:      3,
+			create:     func(item, attempt int) (int, error) { return 0, errors.New("boom") },
+			wantStatus: domain.JobCompleted, wantProcessed: 3, wantCreates: 3,
+		},
+		{
+			name:       "rate limited items stay pending",
+			items:      3,
+			create:     func(item, attempt int) (int, error) { return 0, rateLimited },
+			wantStatus: domain.JobPending, wantPending: 3, wantCreates: 3,
+		},
+		{
+			name:  "some items rate limited",
+			items: 3,
+			create: func(item, attempt int) (int, error) {
+				if item == 1 {
+					return 0, rateLimited
+				}
+				return 1000 + item, nil
+			},
+			wantStatus: domain.JobRunning, wantProcessed: 2, wantPending: 1, wantCreates: 3,
+		},
+		{
+			name:       "locked by another request",
+			items:      3,
+			create:     func(item, attempt int) (int, error) { return 1000 + item, nil },
+			locked:     true,
+			wantStatus: domain.JobPending, wantPending: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+			client := newJobClient(clk, tt.create)
+			client.locks["job:job-1"] = tt.locked
+			svc := NewTransactionService(client, Options{Clock: clk})
+			if err := svc.CreateJob(context.Background(), newTestJob(tt.items)); err != nil {
+				t.Fatalf("CreateJob() error = %v", err)
+			}
+
+			job, err := svc.ProcessJob(context.Background(), "job-1", describeTestResult)
+			if err != nil {
+				t.Fatalf("ProcessJob() error = %v", err)
+			}
+			if job.Status != tt.wantStatus || job.Processed != tt.wantProcessed || len(job.Pending) != tt.wantPending {
+				t.Errorf("job %s with %d processed and %d pending, want %s with %d and %d",
+					job.Status, job.Processed, len(job.Pending), tt.wantStatus, tt.wantProcessed, tt.wantPending)
+			}
+			if created := len(client.attempts); created != tt.wantCreates {
+				t.Errorf("tried to create %d items, want %d", created, tt.wantCreates)
+			}
+			if locked := client.locks["job:job-1"]; locked != tt.locked {
+				t.Errorf("job locked = %v after processing, want %v", locked, tt.locked)
+			}
+		})
+	}
+}
+
+func TestJobExpiry(t *testing.T) {
+	ttl := repository.JobTTL * time.Second
+	tests := []struct {
+		name      string
+		advance   []time.Duration
+		wantFound bool
+	}{
+		{name: "kept until the TTL", advance: []time.Duration{ttl - time.Second}, wantFound: true},
+		{name: "expired after the TTL", advance: []time.Duration{ttl}},
+		{name: "processing restarts the TTL", advance: []time.Duration{ttl - time.Second, 0, 2 * time.Second}, wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC))
+			client := newJobClient(clk, func(item, attempt int) (int, error) { return 1000 + item, nil })
+			svc := NewTransactionService(client, Options{Clock: clk})
+			if err := svc.CreateJob(context.Background(), newTestJob(30)); err != nil {
+				t.Fatalf("CreateJob() error = %v", err)
+			}
+
+			var err error
+			for _, advance := range tt.advance {
+				clk.Advance(advance)
+				_, err = svc.ProcessJob(context.Background(), "job-1", describeTestResult)
+			}
+			if found := !errors.Is(err, ErrJobNotFound); found != tt.wantFound {
+				t.Errorf("ProcessJob() error = %v, want found %v", err, tt.wantFound)
+			}
+		})
+	}
+
+	// An ID that was never created is as missing as an expired one
+	svc := NewTransactionService(newJobClient(clock.NewFake(time.Now()), nil), Options{})
+	if _, err := svc.ProcessJob(context.Background(), "unknown", describeTestResult); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("ProcessJob(unknown) error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import "strings"
+
+// ParseLabels parses a comma-separated list of labels, dropping empty entries
+func ParseLabels(value string) []string {
+	var labels []string
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// mergeLabels concatenates label lists in order, keeping the first spelling of labels
+// repeated case-insensitively, since PocketSmith treats them as the same label
+func mergeLabels(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, labels := range lists {
+		for _, label := range labels {
+			key := strings.ToLower(label)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, label)
+		}
+	}
+	return merged
+}
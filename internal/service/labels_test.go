@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: ""},
+		{value: " via-proxy ", want: []string{"via-proxy"}},
+		{value: "via-proxy, shortcut,,", want: []string{"via-proxy", "shortcut"}},
+	}
+	for _, tt := range tests {
+		if got := ParseLabels(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLabels(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAddTransactionDefaultLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults []string
+		labels   []string
+		want     string
+	}{
+		{name: "neither"},
+		{name: "defaults only", defaults: []string{"via-proxy", "shortcut"}, want: "via-proxy,shortcut"},
+		{name: "per-request only", labels: []string{"coffee"}, want: "coffee"},
+		{name: "merged, per-request first", defaults: []string{"via-proxy"}, labels: []string{"coffee", "work"}, want: "coffee,work,via-proxy"},
+		{name: "merged, deduplicated in any case", defaults: []string{"via-proxy", "Work"}, labels: []string{"work", "VIA-PROXY"}, want: "work,VIA-PROXY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			svc := NewTransactionService(client, Options{DefaultLabels: tt.defaults})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: "Groceries", Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13", Labels: tt.labels,
+			})
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if got := client.created[0].Labels; got != tt.want {
+				t.Errorf("created with labels %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MerchantRule derives a category and/or labels for transactions whose merchant matches
+type MerchantRule struct {
+	// Contains matches merchants containing this substring (case-insensitive)
+	Contains string `json:"contains,omitempty"`
+	// Pattern matches merchants against this regular expression
+	Pattern string `json:"pattern,omitempty"`
+	// Category is the category title applied on a match
+	Category string `json:"category,omitempty"`
+	// Labels are applied to the transaction on a match
+	Labels []string `json:"labels,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseMerchantRules parses a JSON array of merchant rules, compiling their patterns.
+// An empty value means no rules.
+func ParseMerchantRules(value string) ([]MerchantRule, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var rules []MerchantRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("parse merchant rules: %w", err)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if (rule.Contains == "") == (rule.Pattern == "") {
+			return nil, fmt.Errorf("merchant rule %d: exactly one of contains or pattern is required", i)
+		}
+		if rule.Category == "" && len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("merchant rule %d: category or labels is required", i)
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("merchant rule %d: invalid pattern: %w", i, err)
+			}
+			rule.pattern = pattern
+		}
+	}
+	return rules, nil
+}
+
+// matches reports whether the rule applies to a merchant
+func (rule *MerchantRule) matches(merchant string) bool {
+	if rule.pattern != nil {
+		return rule.pattern.MatchString(merchant)
+	}
+	return strings.Contains(strings.ToLower(merchant), strings.ToLower(rule.Contains))
+}
+
+// matchMerchantRule returns the first rule matching a merchant, or nil if none does
+func matchMerchantRule(rules []MerchantRule, merchant string) *MerchantRule {
+	for i := range rules {
+		if rules[i].matches(merchant) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseMerchantRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", value: " "},
+		{name: "substring and regex", value: `[{"contains": "uber", "category": "Transport"}, {"pattern": "^Wool(worths)?", "labels": ["groceries"]}]`, want: 2},
+		{name: "invalid regex", value: `[{"pattern": "(", "category": "Transport"}]`, wantErr: true},
+		{name: "both contains and pattern", value: `[{"contains": "uber", "pattern": "uber", "category": "Transport"}]`, wantErr: true},
+		{name: "neither contains nor pattern", value: `[{"category": "Transport"}]`, wantErr: true},
+		{name: "neither category nor labels", value: `[{"contains": "uber"}]`, wantErr: true},
+		{name: "not JSON", value: `uber=Transport`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseMerchantRules(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMerchantRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(rules) != tt.want {
+				t.Errorf("ParseMerchantRules() = %d rules, want %d", len(rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionMerchantRules(t *testing.T) {
+	rules, err := ParseMerchantRules(`[
+		{"contains": "uber eats", "category": "Dining", "labels": ["delivery"]},
+		{"contains": "UBER", "category": "Transport"},
+		{"pattern": "^Wool(worths)? [0-9]+$", "category": "Groceries", "labels": ["supermarket"]},
+		{"contains": "parking", "labels": ["car"]}
+	]`)
+	if err != nil {
+		t.Fatalf("ParseMerchantRules() error = %v", err)
+	}
+	categories := []domain.Category{{ID: 20, Title: "Groceries"}, {ID: 22, Title: "Transport"}, {ID: 23, Title: "Dining"}}
+	tests := []struct {
+		name       string
+		merchant   string
+		category   string
+		wantID     int
+		wantLabels string
+		wantCode   LookupErrorCode
+	}{
+		{name: "substring, any case", merchant: "Uber Trip", wantID: 22},
+		{name: "earlier rule wins", merchant: "Uber Eats", wantID: 23, wantLabels: "delivery"},
+		{name: "regex", merchant: "Woolworths 1234", wantID: 20, wantLabels: "supermarket"},
+		{name: "regex not matching", merchant: "Woolworths Metro", wantCode: CodeNoMerchantRule},
+		{name: "sent category kept", merchant: "Uber Trip", category: "Groceries", wantID: 20},
+		{name: "rule without a category", merchant: "City Parking", wantCode: CodeNoMerchantRule},
+		{name: "no match", merchant: "Corner Store", wantCode: CodeNoMerchantRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = categories
+			svc := NewTransactionService(client, Options{MerchantRules: rules})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.category, Merchant: tt.merchant, Amount: "-5.00", Date: "2025-01-13",
+			})
+			if tt.wantCode != "" {
+				if !IsLookupError(err) || ErrorCode(err) != tt.wantCode {
+					t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			created := client.created[0]
+			if created.CategoryID == nil || *created.CategoryID != tt.wantID {
+				t.Errorf("created with category %v, want %d", created.CategoryID, tt.wantID)
+			}
+			if created.Labels != tt.wantLabels {
+				t.Errorf("created with labels %q, want %q", created.Labels, tt.wantLabels)
+			}
+		})
+	}
+}
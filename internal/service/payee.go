@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// MerchantTarget selects where the merchant is recorded when a separate payee is sent
+type MerchantTarget string
+
+const (
+	// MerchantNote writes the merchant to the transaction's note (default)
+	MerchantNote MerchantTarget = "note"
+	// MerchantLabel adds the merchant as a label
+	MerchantLabel MerchantTarget = "label"
+)
+
+// ParseMerchantTarget parses a merchant target, defaulting to note when empty
+func ParseMerchantTarget(value string) (MerchantTarget, error) {
+	switch target := MerchantTarget(strings.ToLower(strings.TrimSpace(value))); target {
+	case "":
+		return MerchantNote, nil
+	case MerchantNote, MerchantLabel:
+		return target, nil
+	default:
+		return "", fmt.Errorf("unknown merchant target: %s", value)
+	}
+}
+
+// payeeOf returns the PocketSmith payee of a transaction: its payee when one was sent,
+// otherwise the merchant. With a separate payee, the merchant is kept as a note or a label
+// per the merchant target; a label can't hold commas, so they are dropped.
+func (s *TransactionServiceImpl) payeeOf(tx *domain.Transaction) (payee, note string, labels []string) {
+	if tx.Payee == "" {
+		return tx.Merchant, "", nil
+	}
+	if s.options.MerchantTarget == MerchantLabel {
+		if label := strings.TrimSpace(strings.ReplaceAll(tx.Merchant, ",", "")); label != "" {
+			labels = []string{label}
+		}
+		return tx.Payee, "", labels
+	}
+	return tx.Payee, tx.Merchant, nil
+}
+
+// joinNotes joins the non-empty notes recorded on a transaction
+func joinNotes(notes ...string) string {
+	var kept []string
+	for _, note := range notes {
+		if note != "" {
+			kept = append(kept, note)
+		}
+	}
+	return strings.Join(kept, "; ")
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseMerchantTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    MerchantTarget
+		wantErr bool
+	}{
+		{value: "", want: MerchantNote},
+		{value: "note", want: MerchantNote},
+		{value: " Label ", want: MerchantLabel},
+		{value: "payee", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseMerchantTarget(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseMerchantTarget(%q) = %q, %v, want %q, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddTransactionPayee(t *testing.T) {
+	tests := []struct {
+		name       string
+		merchant   string
+		payee      string
+		source     string
+		target     MerchantTarget
+		wantPayee  string
+		wantNote   string
+		wantLabels string
+	}{
+		{name: "payee absent", merchant: "AMZN Mktp", wantPayee: "AMZN Mktp"},
+		{name: "payee absent, label target", merchant: "AMZN Mktp", target: MerchantLabel, wantPayee: "AMZN Mktp"},
+		{name: "merchant as the note", merchant: "AMZN Mktp", payee: "Amazon", wantPayee: "Amazon", wantNote: "AMZN Mktp"},
+		{name: "merchant before the source note", merchant: "AMZN Mktp", payee: "Amazon", source: "shortcut", wantPayee: "Amazon", wantNote: "AMZN Mktp; shortcut"},
+		{name: "merchant as a label", merchant: "AMZN Mktp", payee: "Amazon", target: MerchantLabel, wantPayee: "Amazon", wantLabels: "AMZN Mktp"},
+		{name: "label without commas", merchant: "AMZN, Mktp", payee: "Amazon", target: MerchantLabel, wantPayee: "Amazon", wantLabels: "AMZN Mktp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			svc := NewTransactionService(client, Options{MerchantTarget: tt.target, SourceTarget: SourceNote})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: "Groceries", Merchant: tt.merchant, Payee: tt.payee, Source: tt.source, Amount: "-5.00", Date: "2025-01-13",
+			})
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			created := client.created[0]
+			if created.Payee != tt.wantPayee || created.Note != tt.wantNote || created.Labels != tt.wantLabels {
+				t.Errorf("created payee %q, note %q, labels %q, want %q, %q, %q", created.Payee, created.Note, created.Labels, tt.wantPayee, tt.wantNote, tt.wantLabels)
+			}
+		})
+	}
+}
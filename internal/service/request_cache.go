@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// requestCacheKey is the context key under which the per-request cache is stored
+type requestCacheKey struct{}
+
+// requestCache memoizes PocketSmith lookups for the lifetime of a single request
+type requestCache struct {
+	userMu       sync.Mutex
+	user         *domain.User
+	accountsMu   sync.Mutex
+	accounts     map[int][]domain.TransactionAccount
+	categoriesMu sync.Mutex
+	categories   map[int][]domain.Category
+}
+
+// WithRequestCache returns a context carrying a fresh per-request cache.
+// Service methods called with this context fetch each entity at most once,
+// their cache hits and misses are reported by CacheStatus, and capped lists by Truncated.
+func WithRequestCache(ctx context.Context) context.Context {
+	ctx = api.WithCacheStatus(ctx)
+	ctx = api.WithTruncation(ctx)
+	return context.WithValue(ctx, requestCacheKey{}, &requestCache{
+		accounts:   make(map[int][]domain.TransactionAccount),
+		categories: make(map[int][]domain.Category),
+	})
+}
+
+// CacheStatus reports whether the entities looked up with ctx came from the cache:
+// HIT, MISS, PARTIAL, or empty when nothing was looked up
+func CacheStatus(ctx context.Context) string {
+	return api.CacheStatus(ctx)
+}
+
+// Truncated reports whether accounts or categories looked up with ctx reached the
+// configured cap, so results built from them may be incomplete
+func Truncated(ctx context.Context) bool {
+	return api.Truncated(ctx)
+}
+
+// requestCacheFrom returns the per-request cache stored in ctx, or nil if there is none
+func requestCacheFrom(ctx context.Context) *requestCache {
+	cache, _ := ctx.Value(requestCacheKey{}).(*requestCache)
+	return cache
+}
+
+// getUser returns the current user, memoized per request
+func (s *TransactionServiceImpl) getUser(ctx context.Context) (*domain.User, error) {
+	cache := requestCacheFrom(ctx)
+	if cache == nil {
+		return s.client.GetMe(ctx)
+	}
+
+	cache.userMu.Lock()
+	defer cache.userMu.Unlock()
+
+	if cache.user != nil {
+		return cache.user, nil
+	}
+
+	user, err := s.client.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache.user = user
+	return user, nil
+}
+
+// getTransactionAccounts returns the user's transaction accounts, memoized per request
+func (s *TransactionServiceImpl) getTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	cache := requestCacheFrom(ctx)
+	if cache == nil {
+		return s.client.GetTransactionAccounts(ctx, userID)
+	}
+
+	cache.accountsMu.Lock()
+	defer cache.accountsMu.Unlock()
+
+	if accounts, ok := cache.accounts[userID]; ok {
+		return accounts, nil
+	}
+
+	accounts, err := s.client.GetTransactionAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cache.accounts[userID] = accounts
+	return accounts, nil
+}
+
+// getCategories returns the user's categories, memoized per request
+func (s *TransactionServiceImpl) getCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	cache := requestCacheFrom(ctx)
+	if cache == nil {
+		return s.client.GetCategories(ctx, userID)
+	}
+
+	cache.categoriesMu.Lock()
+	defer cache.categoriesMu.Unlock()
+
+	if categories, ok := cache.categories[userID]; ok {
+		return categories, nil
+	}
+
+	categories, err := s.client.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cache.categories[userID] = categories
+	return categories, nil
+}
+
+// fetchAccountsAndCategories returns a user's transaction accounts and categories, memoized
+// per request. Cache misses of both are fetched concurrently, so they cost one round trip
+// instead of two, and cached together. Each list carries its own error, so callers can
+// decide how to combine them.
+func (s *TransactionServiceImpl) fetchAccountsAndCategories(ctx context.Context, userID int) api.AccountsAndCategories {
+	cache := requestCacheFrom(ctx)
+	if cache == nil {
+		return s.client.GetAccountsAndCategories(ctx, userID)
+	}
+
+	// Always lock accounts before categories, so concurrent callers can't deadlock
+	cache.accountsMu.Lock()
+	defer cache.accountsMu.Unlock()
+	cache.categoriesMu.Lock()
+	defer cache.categoriesMu.Unlock()
+
+	accounts, haveAccounts := cache.accounts[userID]
+	categories, haveCategories := cache.categories[userID]
+	var lists api.AccountsAndCategories
+	switch {
+	case haveAccounts && haveCategories:
+		return api.AccountsAndCategories{Accounts: accounts, Categories: categories}
+	case haveAccounts:
+		lists.Categories, lists.CategoriesErr = s.client.GetCategories(ctx, userID)
+		lists.Accounts = accounts
+	case haveCategories:
+		lists.Accounts, lists.AccountsErr = s.client.GetTransactionAccounts(ctx, userID)
+		lists.Categories = categories
+	default:
+		lists = s.client.GetAccountsAndCategories(ctx, userID)
+	}
+
+	if lists.AccountsErr == nil {
+		cache.accounts[userID] = lists.Accounts
+	}
+	if lists.CategoriesErr == nil {
+		cache.categories[userID] = lists.Categories
+	}
+	return lists
+}
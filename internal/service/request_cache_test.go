@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// countingClient serves fixed entities, counting how often each is fetched.
+// Methods it doesn't override panic through the nil embedded client.
+type countingClient struct {
+	api.PocketSmithClient
+
+	mu    sync.Mutex
+	calls map[string]int
+	// refreshErr fails RefreshAccountsAndCategories when set
+	refreshErr error
+	// categoriesErr fails every categories lookup when set
+	categoriesErr error
+}
+
+func newCountingClient() *countingClient {
+	return &countingClient{calls: make(map[string]int)}
+}
+
+func (c *countingClient) count(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[method]++
+}
+
+func (c *countingClient) GetMe(ctx context.Context) (*domain.User, error) {
+	c.count("GetMe")
+	return &domain.User{ID: 1}, nil
+}
+
+func (c *countingClient) GetTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	c.count("GetTransactionAccounts")
+	return testAccounts(), nil
+}
+
+func (c *countingClient) GetCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	c.count("GetCategories")
+	if c.categoriesErr != nil {
+		return nil, c.categoriesErr
+	}
+	return testCategories(), nil
+}
+
+func (c *countingClient) GetAccountsAndCategories(ctx context.Context, userID int) api.AccountsAndCategories {
+	c.count("GetAccountsAndCategories")
+	if c.categoriesErr != nil {
+		return api.AccountsAndCategories{Accounts: testAccounts(), CategoriesErr: c.categoriesErr}
+	}
+	return api.AccountsAndCategories{Accounts: testAccounts(), Categories: testCategories()}
+}
+
+func (c *countingClient) RefreshAccountsAndCategories(ctx context.Context, userID int) ([]domain.TransactionAccount, []domain.Category, error) {
+	c.count("RefreshAccountsAndCategories")
+	if c.refreshErr != nil {
+		return nil, nil, c.refreshErr
+	}
+	return testAccounts(), testCategories(), nil
+}
+
+func testAccounts() []domain.TransactionAccount {
+	return []domain.TransactionAccount{
+		{ID: 10, Name: "Everyday", CurrencyCode: "aud", Type: "bank"},
+		{ID: 11, Name: "Travel", CurrencyCode: "usd", Type: "bank"},
+	}
+}
+
+func testCategories() []domain.Category {
+	return []domain.Category{{ID: 20, Title: "Groceries"}, {ID: 21, Title: "Salary", IsIncome: true}}
+}
+
+func TestRequestCacheFetchesOncePerRequest(t *testing.T) {
+	client := newCountingClient()
+	svc := NewTransactionService(client, Options{})
+
+	ctx := WithRequestCache(context.Background())
+	for i := 0; i < 2; i++ {
+		if _, err := svc.GetShortcutEntities(ctx); err != nil {
+			t.Fatalf("GetShortcutEntities() error = %v", err)
+		}
+		if _, _, err := svc.ResolveEntities(ctx, &domain.Transaction{Account: "Everyday", Category: "Groceries"}); err != nil {
+			t.Fatalf("ResolveEntities() error = %v", err)
+		}
+		if _, err := svc.GetAccounts(ctx, nil); err != nil {
+			t.Fatalf("GetAccounts() error = %v", err)
+		}
+		if _, err := svc.GetCategories(ctx); err != nil {
+			t.Fatalf("GetCategories() error = %v", err)
+		}
+	}
+
+	want := map[string]int{"GetMe": 1, "GetAccountsAndCategories": 1}
+	for method, calls := range client.calls {
+		if calls != want[method] {
+			t.Errorf("%s called %d times, want %d", method, calls, want[method])
+		}
+	}
+}
+
+func TestRequestCacheIsPerRequest(t *testing.T) {
+	client := newCountingClient()
+	svc := NewTransactionService(client, Options{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.GetAccounts(WithRequestCache(context.Background()), nil); err != nil {
+			t.Fatalf("GetAccounts() error = %v", err)
+		}
+	}
+	// Without a request cache every call fetches
+	if _, err := svc.GetAccounts(context.Background(), nil); err != nil {
+		t.Fatalf("GetAccounts() error = %v", err)
+	}
+
+	if client.calls["GetMe"] != 3 || client.calls["GetTransactionAccounts"] != 3 {
+		t.Errorf("calls = %v, want GetMe and GetTransactionAccounts 3 times each", client.calls)
+	}
+}
+
+func TestFetchAccountsAndCategoriesConcurrent(t *testing.T) {
+	client := newCountingClient()
+	svc := NewTransactionService(client, Options{}).(*TransactionServiceImpl)
+	ctx := WithRequestCache(context.Background())
+
+	// Lookups racing within one request share its cache, whichever runs first
+	var wg sync.WaitGroup
+	errs := make(chan error, 30)
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			lists := svc.fetchAccountsAndCategories(ctx, 1)
+			if err := lists.Err(); err != nil {
+				errs <- err
+			} else if len(lists.Accounts) != 2 || len(lists.Categories) != 2 {
+				errs <- errors.New("fetchAccountsAndCategories() returned incomplete lists")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := svc.getTransactionAccounts(ctx, 1); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := svc.getCategories(ctx, 1); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Each list was fetched once, alone or together with the other
+	accountFetches := client.calls["GetTransactionAccounts"] + client.calls["GetAccountsAndCategories"]
+	categoryFetches := client.calls["GetCategories"] + client.calls["GetAccountsAndCategories"]
+	if accountFetches != 1 || categoryFetches != 1 {
+		t.Errorf("calls = %v, want each list fetched once", client.calls)
+	}
+}
+
+func TestFetchAccountsAndCategoriesPartialFailure(t *testing.T) {
+	failure := errors.New("PocketSmith is down")
+	client := newCountingClient()
+	client.categoriesErr = failure
+	svc := NewTransactionService(client, Options{}).(*TransactionServiceImpl)
+	ctx := WithRequestCache(context.Background())
+
+	for i := 0; i < 2; i++ {
+		lists := svc.fetchAccountsAndCategories(ctx, 1)
+		if lists.AccountsErr != nil || len(lists.Accounts) != 2 {
+			t.Errorf("accounts = %v, %v, want them despite the categories failing", lists.Accounts, lists.AccountsErr)
+		}
+		if !errors.Is(lists.CategoriesErr, failure) || lists.Categories != nil {
+			t.Errorf("categories = %v, %v, want the failure", lists.Categories, lists.CategoriesErr)
+		}
+	}
+
+	// The accounts were memoized, so the second lookup only retried the categories
+	want := map[string]int{"GetAccountsAndCategories": 1, "GetCategories": 1}
+	for method, calls := range client.calls {
+		if calls != want[method] {
+			t.Errorf("%s called %d times, want %d", method, calls, want[method])
+		}
+	}
+
+	// A caller needing both fails with the categories' cause
+	if _, err := svc.GetShortcutEntities(ctx); err != nil {
+		t.Errorf("GetShortcutEntities() error = %v, want a partial result", err)
+	}
+	if _, _, err := svc.ResolveEntities(ctx, &domain.Transaction{Account: "Everyday"}); !errors.Is(err, failure) {
+		t.Errorf("ResolveEntities() error = %v, want %v", err, failure)
+	}
+}
+
+func TestResolveAccount(t *testing.T) {
+	accounts := []domain.TransactionAccount{
+		{ID: 1, Name: "Savings", CurrencyCode: "aud"},
+		{ID: 2, Name: "Savings", CurrencyCode: "usd"},
+		{ID: 3, Name: "Everyday", CurrencyCode: "aud"},
+	}
+	tests := []struct {
+		name     string
+		accounts []domain.TransactionAccount
+		account  string
+		currency string
+		wantID   int
+		wantCode LookupErrorCode
+	}{
+		{name: "case-insensitive", accounts: accounts, account: "everyday", wantID: 3},
+		{name: "currency picks one", accounts: accounts, account: "Savings", currency: "USD", wantID: 2},
+		{name: "ambiguous", accounts: accounts, account: "Savings", wantCode: CodeAccountAmbiguous},
+		{name: "currency picks none", accounts: accounts, account: "Savings", currency: "EUR", wantCode: CodeCurrencyMismatch},
+		{name: "not found", accounts: accounts, account: "Cash", wantCode: CodeAccountNotFound},
+		{name: "no accounts", account: "Cash", wantCode: CodeNoAccounts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := resolveAccount(tt.accounts, tt.account, tt.currency)
+			if tt.wantCode != "" {
+				if code := ErrorCode(err); code != tt.wantCode {
+					t.Fatalf("resolveAccount() error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil || account.ID != tt.wantID {
+				t.Errorf("resolveAccount() = %+v, %v, want ID %d", account, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRefreshAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		refreshErr error
+	}{
+		{name: "refreshed"},
+		{name: "fetch failed", refreshErr: errors.New("failed to refresh categories: timeout")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newCountingClient()
+			client.refreshErr = tt.refreshErr
+			svc := NewTransactionService(client, Options{})
+
+			if err := svc.RefreshAll(context.Background()); !errors.Is(err, tt.refreshErr) {
+				t.Errorf("RefreshAll() error = %v, want %v", err, tt.refreshErr)
+			}
+			// Accounts and categories are refreshed together, never one at a time
+			want := map[string]int{"GetMe": 1, "RefreshAccountsAndCategories": 1}
+			if len(client.calls) != len(want) {
+				t.Errorf("calls = %v, want %v", client.calls, want)
+			}
+			for method, calls := range want {
+				if client.calls[method] != calls {
+					t.Errorf("%s called %d times, want %d", method, client.calls[method], calls)
+				}
+			}
+		})
+	}
+}
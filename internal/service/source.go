@@ -0,0 +1,28 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceTarget selects where a transaction's source tag is recorded in PocketSmith
+type SourceTarget string
+
+const (
+	// SourceLabel adds the source as a label (default)
+	SourceLabel SourceTarget = "label"
+	// SourceNote writes the source to the transaction's note
+	SourceNote SourceTarget = "note"
+)
+
+// ParseSourceTarget parses a source target, defaulting to label when empty
+func ParseSourceTarget(value string) (SourceTarget, error) {
+	switch target := SourceTarget(strings.ToLower(strings.TrimSpace(value))); target {
+	case "":
+		return SourceLabel, nil
+	case SourceLabel, SourceNote:
+		return target, nil
+	default:
+		return "", fmt.Errorf("unknown source target: %s", value)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// uncategorizedTitle labels the total of transactions without a category
+const uncategorizedTitle = "Uncategorized"
+
+// Summarize implements TransactionService.Summarize.
+// Amounts are summed per account currency, since accounts in different currencies can't
+// be added up, and each total is rounded to its currency's precision.
+func (s *TransactionServiceImpl) Summarize(ctx context.Context, account, startDate, endDate string, rollup bool) (*domain.Summary, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Fetch accounts from cache or API
+	accounts, err := s.getTransactionAccounts(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction accounts: %w", err)
+	}
+
+	// Summarize one account when named, otherwise all of them
+	if account != "" {
+		found, err := resolveAccount(accounts, account, "")
+		if err != nil {
+			return nil, err
+		}
+		accounts = []domain.TransactionAccount{*found}
+	}
+
+	// The category tree is only needed to roll up sub-categories
+	var topLevel map[int]domain.Category
+	if rollup {
+		categories, err := s.getCategories(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get categories: %w", err)
+		}
+		topLevel = topLevelCategories(categories)
+	}
+
+	// Tally each account's transactions under its currency
+	totals := make(map[string]map[string]*domain.CategorySummary)
+	for _, transactionAccount := range accounts {
+		currency := strings.ToUpper(transactionAccount.CurrencyCode)
+		if totals[currency] == nil {
+			totals[currency] = make(map[string]*domain.CategorySummary)
+		}
+		err := s.client.ListTransactions(ctx, transactionAccount.ID, startDate, endDate, func(page []domain.TransactionRecord) error {
+			for _, record := range page {
+				addToSummary(totals[currency], record, topLevel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions of account %s: %w", transactionAccount.Name, err)
+		}
+	}
+
+	summary := &domain.Summary{StartDate: startDate, EndDate: endDate, Currencies: []domain.CurrencySummary{}}
+	for currency, byCategory := range totals {
+		if len(byCategory) == 0 {
+			continue
+		}
+		precision := currencyPrecision(currency)
+		currencySummary := domain.CurrencySummary{Currency: currency}
+		for _, categorySummary := range byCategory {
+			currencySummary.Total += categorySummary.Total
+			categorySummary.Total = roundTo(categorySummary.Total, precision)
+			currencySummary.Categories = append(currencySummary.Categories, *categorySummary)
+		}
+		currencySummary.Total = roundTo(currencySummary.Total, precision)
+		sort.Slice(currencySummary.Categories, func(i, j int) bool {
+			a, b := currencySummary.Categories[i], currencySummary.Categories[j]
+			if a.Category != b.Category {
+				return a.Category < b.Category
+			}
+			return a.CategoryID != nil && (b.CategoryID == nil || *a.CategoryID < *b.CategoryID)
+		})
+		summary.Currencies = append(summary.Currencies, currencySummary)
+	}
+	sort.Slice(summary.Currencies, func(i, j int) bool {
+		return summary.Currencies[i].Currency < summary.Currencies[j].Currency
+	})
+	return summary, nil
+}
+
+// addToSummary adds a transaction to its category's total, or to its top-level
+// category's total when topLevel is given
+func addToSummary(byCategory map[string]*domain.CategorySummary, record domain.TransactionRecord, topLevel map[int]domain.Category) {
+	var categoryID *int
+	title := uncategorizedTitle
+	if record.Category != nil {
+		id := record.Category.ID
+		title = record.Category.Title
+		if top, ok := topLevel[id]; ok {
+			id, title = top.ID, top.Title
+		}
+		categoryID = &id
+	}
+
+	key := title
+	if categoryID != nil {
+		key = strconv.Itoa(*categoryID)
+	}
+	categorySummary, ok := byCategory[key]
+	if !ok {
+		categorySummary = &domain.CategorySummary{CategoryID: categoryID, Category: title}
+		byCategory[key] = categorySummary
+	}
+	categorySummary.Total += record.Amount
+	categorySummary.Count++
+}
+
+// topLevelCategories maps the ID of every category in the tree to its top-level ancestor
+func topLevelCategories(categories []domain.Category) map[int]domain.Category {
+	topLevel := make(map[int]domain.Category)
+	for _, top := range categories {
+		walkCategories([]domain.Category{top}, nil, func(category domain.Category, path []string) {
+			topLevel[category.ID] = top
+		})
+	}
+	return topLevel
+}
+
+// roundTo rounds a total to the given number of decimal places
+func roundTo(value float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// summaryClient lists each account's records, whatever the date range
+type summaryClient struct {
+	*recordingClient
+	records map[int][]domain.TransactionRecord
+}
+
+func (c *summaryClient) ListTransactions(ctx context.Context, accountID int, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	return visit(c.records[accountID])
+}
+
+func TestSummarize(t *testing.T) {
+	food := &domain.TransactionCategory{ID: 30, Title: "Food"}
+	groceries := &domain.TransactionCategory{ID: 31, Title: "Groceries"}
+	salary := &domain.TransactionCategory{ID: 21, Title: "Salary"}
+	records := map[int][]domain.TransactionRecord{
+		10: {
+			{ID: 1, Amount: -12.344, Category: groceries},
+			{ID: 2, Amount: -5, Category: food},
+			{ID: 3, Amount: 1000, Category: salary},
+			{ID: 4, Amount: -1.1},
+		},
+		11: {{ID: 5, Amount: -3.333, Category: groceries}},
+		12: {{ID: 6, Amount: -1234.6, Category: food}},
+	}
+	summary := func(currencies ...domain.CurrencySummary) *domain.Summary {
+		return &domain.Summary{StartDate: "2025-01-01", EndDate: "2025-01-31", Currencies: currencies}
+	}
+	tests := []struct {
+		name    string
+		account string
+		rollup  bool
+		want    *domain.Summary
+	}{
+		{
+			name: "grouped by category and currency",
+			want: summary(
+				domain.CurrencySummary{Currency: "AUD", Total: 981.56, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(30), Category: "Food", Total: -5, Count: 1},
+					{CategoryID: intPtr(31), Category: "Groceries", Total: -12.34, Count: 1},
+					{CategoryID: intPtr(21), Category: "Salary", Total: 1000, Count: 1},
+					{Category: "Uncategorized", Total: -1.1, Count: 1},
+				}},
+				domain.CurrencySummary{Currency: "JPY", Total: -1235, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(30), Category: "Food", Total: -1235, Count: 1},
+				}},
+				domain.CurrencySummary{Currency: "USD", Total: -3.33, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(31), Category: "Groceries", Total: -3.33, Count: 1},
+				}},
+			),
+		},
+		{
+			name:   "rolled up",
+			rollup: true,
+			want: summary(
+				domain.CurrencySummary{Currency: "AUD", Total: 981.56, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(30), Category: "Food", Total: -17.34, Count: 2},
+					{CategoryID: intPtr(21), Category: "Salary", Total: 1000, Count: 1},
+					{Category: "Uncategorized", Total: -1.1, Count: 1},
+				}},
+				domain.CurrencySummary{Currency: "JPY", Total: -1235, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(30), Category: "Food", Total: -1235, Count: 1},
+				}},
+				domain.CurrencySummary{Currency: "USD", Total: -3.33, Categories: []domain.CategorySummary{
+					{CategoryID: intPtr(30), Category: "Food", Total: -3.33, Count: 1},
+				}},
+			),
+		},
+		{
+			name:    "one account",
+			account: "travel",
+			want: summary(domain.CurrencySummary{Currency: "USD", Total: -3.33, Categories: []domain.CategorySummary{
+				{CategoryID: intPtr(31), Category: "Groceries", Total: -3.33, Count: 1},
+			}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &summaryClient{recordingClient: newRecordingClient(), records: records}
+			client.accounts = []domain.TransactionAccount{
+				{ID: 10, Name: "Everyday", CurrencyCode: "aud"},
+				{ID: 11, Name: "Travel", CurrencyCode: "usd"},
+				{ID: 12, Name: "Tokyo", CurrencyCode: "jpy"},
+			}
+			client.categories = nestedCategories()
+			svc := NewTransactionService(client, Options{})
+
+			got, err := svc.Summarize(context.Background(), tt.account, "2025-01-01", "2025-01-31", tt.rollup)
+			if err != nil {
+				t.Fatalf("Summarize() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
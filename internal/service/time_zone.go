@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	// Embed the zone database, since the Spin runtime has none to load from
+	_ "time/tzdata"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// ParseTimeZone parses an IANA time zone name such as Pacific/Auckland (empty means unknown)
+func ParseTimeZone(value string) (*time.Location, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q, expected an IANA name such as Pacific/Auckland", value)
+	}
+	return location, nil
+}
+
+// userTimeZone returns the zone the user's dates are in: TimeZone when it is set, otherwise
+// the zone PocketSmith reports for the user, or nil when neither is known
+func (s *TransactionServiceImpl) userTimeZone(user *domain.User) *time.Location {
+	if s.options.TimeZone != nil {
+		return s.options.TimeZone
+	}
+	if user == nil {
+		return nil
+	}
+	location, err := ParseTimeZone(user.TimeZone)
+	if err != nil {
+		log.Printf("Warning: Ignoring the time zone of PocketSmith user %d: %v", user.ID, err)
+		return nil
+	}
+	return location
+}
+
+// TimeZone implements TransactionService.TimeZone
+func (s *TransactionServiceImpl) TimeZone(ctx context.Context) *time.Location {
+	if s.options.TimeZone != nil {
+		return s.options.TimeZone
+	}
+	user, err := s.getUser(ctx)
+	if err != nil {
+		log.Printf("Warning: Failed to get the user's time zone: %v", err)
+		return nil
+	}
+	return s.userTimeZone(user)
+}
+
+// transactionDate returns the date sent to PocketSmith: the plain date, or the date-time
+// converted to zone when it carries a UTC offset and the zone is known.
+// Naive date-times are passed on as sent, assumed to already be in the user's time.
+func transactionDate(tx *domain.Transaction, zone *time.Location) string {
+	if tx.DateTime == "" {
+		return tx.Date
+	}
+	if zone == nil {
+		return tx.DateTime
+	}
+	t, err := time.Parse(time.RFC3339, tx.DateTime)
+	if err != nil {
+		return tx.DateTime
+	}
+	return t.In(zone).Format(time.RFC3339)
+}
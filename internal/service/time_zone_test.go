@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// zoneClient reports a user in timeZone and records the date of each created transaction
+type zoneClient struct {
+	*countingClient
+	timeZone string
+	dates    []string
+}
+
+func (c *zoneClient) GetMe(ctx context.Context) (*domain.User, error) {
+	return &domain.User{ID: 1, TimeZone: c.timeZone}, nil
+}
+
+func (c *zoneClient) CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
+	c.dates = append(c.dates, transaction.Date)
+	return 100, nil
+}
+
+func TestTransactionDate(t *testing.T) {
+	auckland, _ := ParseTimeZone("Pacific/Auckland")
+	tests := []struct {
+		name     string
+		date     string
+		dateTime string
+		zone     *time.Location
+		want     string
+	}{
+		{name: "date only", date: "2025-01-13", zone: auckland, want: "2025-01-13"},
+		{name: "offset converted to the zone", date: "2025-01-13", dateTime: "2025-01-13T08:30:00Z", zone: auckland, want: "2025-01-13T21:30:00+13:00"},
+		{name: "offset crossing midnight", date: "2025-01-13", dateTime: "2025-01-13T12:30:00Z", zone: auckland, want: "2025-01-14T01:30:00+13:00"},
+		{name: "offset without a zone", date: "2025-01-13", dateTime: "2025-01-13T08:30:00+10:00", want: "2025-01-13T08:30:00+10:00"},
+		{name: "naive taken as local", date: "2025-01-13", dateTime: "2025-01-13T08:30:00", zone: auckland, want: "2025-01-13T08:30:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &domain.Transaction{Date: tt.date, DateTime: tt.dateTime}
+			if got := transactionDate(tx, tt.zone); got != tt.want {
+				t.Errorf("transactionDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserTimeZone(t *testing.T) {
+	override, _ := ParseTimeZone("Europe/London")
+	tests := []struct {
+		name     string
+		override *time.Location
+		user     *domain.User
+		want     string
+	}{
+		{name: "user's zone", user: &domain.User{ID: 1, TimeZone: "Pacific/Auckland"}, want: "Pacific/Auckland"},
+		{name: "override wins", override: override, user: &domain.User{ID: 1, TimeZone: "Pacific/Auckland"}, want: "Europe/London"},
+		{name: "override without a user zone", override: override, user: &domain.User{ID: 1}, want: "Europe/London"},
+		{name: "unknown user zone ignored", user: &domain.User{ID: 1, TimeZone: "Mars/Olympus"}},
+		{name: "neither known", user: &domain.User{ID: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionService(newCountingClient(), Options{TimeZone: tt.override}).(*TransactionServiceImpl)
+			got := svc.userTimeZone(tt.user)
+			if (got == nil) != (tt.want == "") || (got != nil && got.String() != tt.want) {
+				t.Errorf("userTimeZone() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionUserTimeZone(t *testing.T) {
+	client := &zoneClient{countingClient: newCountingClient(), timeZone: "Pacific/Auckland"}
+	svc := NewTransactionService(client, Options{})
+
+	tx := &domain.Transaction{Account: "Everyday", Category: "Groceries", Amount: "-5.00", Date: "2025-01-13", DateTime: "2025-01-13T12:30:00Z"}
+	if _, err := svc.AddTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("AddTransaction() error = %v", err)
+	}
+	if want := "2025-01-14T01:30:00+13:00"; len(client.dates) != 1 || client.dates[0] != want {
+		t.Errorf("posted dates %v, want %q in the user's zone", client.dates, want)
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// SearchTransactions implements TransactionService.SearchTransactions.
+// PocketSmith has no text search, so every page of the range is fetched and filtered here.
+func (s *TransactionServiceImpl) SearchTransactions(ctx context.Context, account, query, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	queryLower := strings.ToLower(query)
+	return s.ListTransactions(ctx, account, startDate, endDate, func(page []domain.TransactionRecord) error {
+		var matches []domain.TransactionRecord
+		for _, record := range page {
+			if strings.Contains(strings.ToLower(record.Payee), queryLower) || strings.Contains(strings.ToLower(record.Note), queryLower) {
+				matches = append(matches, record)
+			}
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		return visit(matches)
+	})
+}
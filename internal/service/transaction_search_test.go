@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// searchClient lists an account's records dated within the range, like PocketSmith does,
+// in pages of two
+type searchClient struct {
+	*recordingClient
+	records []domain.TransactionRecord
+	listed  []int
+}
+
+func (c *searchClient) ListTransactions(ctx context.Context, accountID int, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	c.listed = append(c.listed, accountID)
+	var page []domain.TransactionRecord
+	for _, record := range c.records {
+		if record.Date < startDate || record.Date > endDate {
+			continue
+		}
+		if page = append(page, record); len(page) == 2 {
+			if err := visit(page); err != nil {
+				return err
+			}
+			page = nil
+		}
+	}
+	if len(page) > 0 {
+		return visit(page)
+	}
+	return nil
+}
+
+func TestSearchTransactions(t *testing.T) {
+	records := []domain.TransactionRecord{
+		{ID: 1, Payee: "Corner Store", Date: "2025-01-02"},
+		{ID: 2, Payee: "Bakery", Note: "bread from the corner", Date: "2025-01-05"},
+		{ID: 3, Payee: "Fuel Stop", Date: "2025-01-09"},
+		{ID: 4, Payee: "CORNER STORE", Date: "2025-01-20"},
+		{ID: 5, Payee: "Cinema", Date: "2025-01-21"},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		startDate string
+		endDate   string
+		want      []int
+	}{
+		{name: "payee or note, any case", query: "corner", startDate: "2025-01-01", endDate: "2025-01-31", want: []int{1, 2, 4}},
+		{name: "date range", query: "corner", startDate: "2025-01-03", endDate: "2025-01-19", want: []int{2}},
+		{name: "no match", query: "rent", startDate: "2025-01-01", endDate: "2025-01-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &searchClient{recordingClient: newRecordingClient(), records: records}
+			svc := NewTransactionService(client, Options{})
+
+			var got []int
+			err := svc.SearchTransactions(context.Background(), "travel", tt.query, tt.startDate, tt.endDate, func(matches []domain.TransactionRecord) error {
+				if len(matches) == 0 {
+					t.Error("visited a page without matches")
+				}
+				for _, match := range matches {
+					got = append(got, match.ID)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("SearchTransactions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchTransactions() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(client.listed, []int{11}) {
+				t.Errorf("listed accounts %v, want the Travel account 11", client.listed)
+			}
+		})
+	}
+}
@@ -1,41 +1,186 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/clock"
 	"github.com/pocketsmith-proxy/internal/domain"
 )
 
 // TransactionService defines the interface for transaction business logic
 type TransactionService interface {
-	// AddTransaction adds a transaction to the appropriate account
-	AddTransaction(tx *domain.Transaction) error
+	// AddTransaction adds a transaction to the appropriate account and returns its PocketSmith ID
+	// with the account and category it was posted to
+	AddTransaction(ctx context.Context, tx *domain.Transaction) (*domain.AddedTransaction, error)
+	// ResolveEntities looks up the account and category a transaction would be posted to,
+	// without creating anything. Lookup failures are returned keyed by the param at fault,
+	// and the returned error is for failures to fetch the entities at all.
+	ResolveEntities(ctx context.Context, tx *domain.Transaction) (*domain.TransactionEntities, map[string]error, error)
+	// AddTransactions adds several transactions, returning each one's result at its index
+	AddTransactions(ctx context.Context, txs []*domain.Transaction) []BatchResult
 	// GetCategories returns all category names sorted ascending
-	GetCategories() ([]string, error)
-	// GetAccounts returns all accounts with name and currency
-	GetAccounts() ([]domain.AccountInfo, error)
+	GetCategories(ctx context.Context) ([]string, error)
+	// SearchCategories returns up to limit categories whose title starts with
+	// (ranked first) or contains the query, case-insensitive
+	SearchCategories(ctx context.Context, query string, limit int) ([]domain.CategoryMatch, error)
+	// GetCategoryDetails returns all categories with display metadata sorted by title
+	GetCategoryDetails(ctx context.Context) ([]domain.Category, error)
+	// GetCategory returns the category with the given ID anywhere in the tree, with its path.
+	// A missing category fails with a lookup error coded CodeCategoryNotFound.
+	GetCategory(ctx context.Context, id int) (*domain.CategoryDetail, error)
+	// GetAccounts returns all accounts with name, currency, and type,
+	// limited to the given account types (case-insensitive) when any are given
+	GetAccounts(ctx context.Context, types []string) ([]domain.AccountInfo, error)
+	// GetAccountDetails returns full transaction accounts, including IDs, balance, and
+	// institution, filtered by type like GetAccounts
+	GetAccountDetails(ctx context.Context, types []string) ([]domain.TransactionAccount, error)
+	// AdjustBalance creates a transaction bringing the named account's current balance
+	// to target, or none when it is already there
+	AdjustBalance(ctx context.Context, account, currency, target string) (*domain.BalanceAdjustment, error)
+	// ImportCategories creates the category at the end of each path of titles, and any
+	// missing parents on the way, skipping those that already exist
+	ImportCategories(ctx context.Context, paths [][]string) ([]CategoryImportResult, error)
+	// CreateAccount creates a transaction account and returns its ID
+	CreateAccount(ctx context.Context, params *domain.AccountParams) (int, error)
 	// GetShortcutEntities returns both accounts and categories for quick access
-	GetShortcutEntities() (*domain.ShortcutEntities, error)
+	GetShortcutEntities(ctx context.Context) (*domain.ShortcutEntities, error)
+	// WhoAmI returns the PocketSmith user the API key belongs to, fetched fresh
+	WhoAmI(ctx context.Context) (*domain.User, error)
+	// RefreshAll refetches accounts and categories and overwrites the cache
+	RefreshAll(ctx context.Context) error
+	// FlushCache deletes everything the proxy has cached and returns how many keys were removed
+	FlushCache(ctx context.Context) (int, error)
+	// GetCacheStats returns the cumulative cache hits and misses per entity
+	GetCacheStats(ctx context.Context) (domain.CacheStats, error)
+	// CreateJob stores a new batch job, to be processed by later ProcessJob calls
+	CreateJob(ctx context.Context, job *domain.Job) error
+	// ProcessJob creates the next JobStepSize pending items of a batch job, unless it is
+	// completed or another request is already doing so, and returns the job. describe turns
+	// each item's outcome into its result. Fails with ErrJobNotFound for an unknown job.
+	ProcessJob(ctx context.Context, jobID string, describe func(BatchResult) domain.BatchItemResult) (*domain.Job, error)
+	// GetCacheTTL returns how long the cached copy of an entity remains valid
+	GetCacheTTL(ctx context.Context, entity domain.CacheEntity) (time.Duration, error)
+	// TimeZone returns the zone the user's dates are in: time_zone when set, otherwise the
+	// user's PocketSmith zone, or nil when neither is known or the user can't be fetched
+	TimeZone(ctx context.Context) *time.Location
+	// RateLimit returns the latest PocketSmith rate-limit values
+	RateLimit() domain.RateLimit
+	// ListTransactions passes the named account's transactions between two dates
+	// (inclusive) to visit, one page at a time as they are fetched
+	ListTransactions(ctx context.Context, account, startDate, endDate string, visit func([]domain.TransactionRecord) error) error
+	// Summarize totals the transactions between two dates (inclusive) by category, per currency,
+	// for the named account or all accounts when account is empty. With rollup, sub-categories
+	// are counted under their top-level category.
+	Summarize(ctx context.Context, account, startDate, endDate string, rollup bool) (*domain.Summary, error)
+	// SearchTransactions is like ListTransactions but only passes transactions whose payee
+	// or note contains query, case-insensitive
+	SearchTransactions(ctx context.Context, account, query, startDate, endDate string, visit func([]domain.TransactionRecord) error) error
+}
+
+// Options holds optional service behaviors
+type Options struct {
+	// RollupToParent posts transactions to the top-level ancestor of the resolved category
+	RollupToParent bool
+	// RoundAmount rounds amounts to the minor-unit precision of their currency,
+	// e.g. 2 decimals for USD and none for JPY
+	RoundAmount bool
+	// MerchantRules derive the category and labels of transactions sent without a category.
+	// Rules are evaluated in order and the first match wins.
+	MerchantRules []MerchantRule
+	// UpsertMatchFields are the fields on which an upserted transaction must match an
+	// existing one to update it (defaults to DefaultUpsertMatchFields)
+	UpsertMatchFields []string
+	// CategorySelfHeal refetches the categories once when a category isn't found, in case
+	// the cache is stale, at most once per categorySelfHealCooldown per user
+	CategorySelfHeal bool
+	// UnknownCategory decides what happens to a transaction whose category isn't found
+	// (the zero value rejects it)
+	UnknownCategory UnknownCategoryPolicy
+	// EnforceCategorySign rejects transactions whose amount sign doesn't match the category's
+	// orientation (income positive, expenses negative) instead of only logging a warning
+	EnforceCategorySign bool
+	// DefaultLabels are added to every transaction, after its own labels
+	DefaultLabels []string
+	// TimeZone overrides the user's time zone, which date-times with a UTC offset are
+	// converted to (nil uses the zone PocketSmith reports for the user)
+	TimeZone *time.Location
+	// DefaultSource tags transactions sent without a source (empty leaves them untagged)
+	DefaultSource string
+	// SourceTarget selects whether the source is recorded as a label or the note
+	SourceTarget SourceTarget
+	// MerchantTarget selects whether the merchant is recorded as a note or a label when a
+	// separate payee is sent
+	MerchantTarget MerchantTarget
+	// AdjustmentPayee is the payee of balance adjustment transactions (defaults to DefaultAdjustmentPayee)
+	AdjustmentPayee string
+	// AdjustmentCategory is the category title of balance adjustment transactions
+	// (empty leaves them uncategorized)
+	AdjustmentCategory string
+	// BatchConcurrency caps how many batch items are created at once (defaults to DefaultBatchConcurrency)
+	BatchConcurrency int
+	// DedupWindow is how long an identical transaction counts as a duplicate submission (0 disables)
+	DedupWindow time.Duration
+	// DedupMode selects whether a duplicate submission is rejected or ignored
+	DedupMode DedupMode
+	// Clock tells the time (defaults to the wall clock)
+	Clock clock.Clock
 }
 
 // TransactionServiceImpl implements TransactionService
 type TransactionServiceImpl struct {
-	client api.PocketSmithClient
+	client  api.PocketSmithClient
+	options Options
 }
 
 // NewTransactionService creates a new transaction service
-func NewTransactionService(client api.PocketSmithClient) TransactionService {
+func NewTransactionService(client api.PocketSmithClient, options Options) TransactionService {
+	options.Clock = clock.OrReal(options.Clock)
 	return &TransactionServiceImpl{
-		client: client,
+		client:  client,
+		options: options,
 	}
 }
 
+// LookupErrorCode identifies why a lookup failed, so clients can tell failures apart
+// without parsing the message
+type LookupErrorCode string
+
+const (
+	// CodeNoAccounts means the user has no transaction accounts at all
+	CodeNoAccounts LookupErrorCode = "no_accounts"
+	// CodeNoCategories means the user has no categories at all
+	CodeNoCategories LookupErrorCode = "no_categories"
+	// CodeAccountNotFound means no account has the given name
+	CodeAccountNotFound LookupErrorCode = "account_not_found"
+	// CodeAccountAmbiguous means several accounts share the name and no account currency was sent
+	CodeAccountAmbiguous LookupErrorCode = "account_ambiguous"
+	// CodeCurrencyMismatch means the account currency sent doesn't pick one of the accounts sharing the name
+	CodeCurrencyMismatch LookupErrorCode = "currency_mismatch"
+	// CodeCategoryNotFound means no category has the given ID or title
+	CodeCategoryNotFound LookupErrorCode = "category_not_found"
+	// CodeNoMerchantRule means no category was sent and no merchant rule derives one
+	CodeNoMerchantRule LookupErrorCode = "no_merchant_rule"
+	// CodeCategorySignMismatch means the amount's sign doesn't match the category's income
+	// or expense orientation (only with EnforceCategorySign)
+	CodeCategorySignMismatch LookupErrorCode = "category_sign_mismatch"
+)
+
+// Lookup errors for users who haven't set up PocketSmith yet, distinct from name mismatches
+var (
+	errNoAccounts   = &lookupError{code: CodeNoAccounts, message: "no accounts exist for this user, create one in PocketSmith first"}
+	errNoCategories = &lookupError{code: CodeNoCategories, message: "no categories exist for this user, create one in PocketSmith first"}
+)
+
 // lookupError represents an error that should return 400 Bad Request
 type lookupError struct {
+	code    LookupErrorCode
 	message string
 }
 
@@ -49,62 +194,243 @@ func IsLookupError(err error) bool {
 	return ok
 }
 
+// ErrorCode returns the code of a lookup error or a duplicate submission, or "" for any other error
+func ErrorCode(err error) LookupErrorCode {
+	if lookupErr, ok := err.(*lookupError); ok {
+		return lookupErr.code
+	}
+	if errors.Is(err, ErrDuplicateSubmission) {
+		return CodeDuplicateSubmission
+	}
+	return ""
+}
+
+// IsRateLimited checks if an error comes from PocketSmith rate-limiting the proxy (should return 429)
+func IsRateLimited(err error) bool {
+	return api.IsRateLimited(err)
+}
+
+// IsUnavailable checks if an error comes from PocketSmith answering with something other
+// than JSON, such as an outage page (should return 503)
+func IsUnavailable(err error) bool {
+	return api.IsUnavailable(err)
+}
+
+// RetryAfter returns how long PocketSmith asked the proxy to wait after a rate-limit error
+// (0 when it didn't say)
+func RetryAfter(err error) time.Duration {
+	return api.RetryAfter(err)
+}
+
+// IsRetryable reports whether a request failing with err may succeed when sent again unchanged:
+// true for timeouts, rate limits, PocketSmith server errors (5xx) and failed connections,
+// false for lookup errors and requests PocketSmith rejected (4xx, e.g. a revoked API key)
+func IsRetryable(err error) bool {
+	switch {
+	case IsLookupError(err), errors.Is(err, ErrJobNotFound), errors.Is(err, ErrDuplicateSubmission):
+		return false
+	case errors.Is(err, ErrRateLimitExhausted), IsRateLimited(err), IsUnavailable(err):
+		return true
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return true
+	}
+	if status := api.StatusCode(err); status != 0 {
+		return status >= 500
+	}
+	return true
+}
+
 // AddTransaction implements TransactionService.AddTransaction
-func (s *TransactionServiceImpl) AddTransaction(tx *domain.Transaction) error {
-	// Normalize inputs
-	accountLower := strings.ToLower(tx.Account)
-	categoryLower := strings.ToLower(tx.Category)
+func (s *TransactionServiceImpl) AddTransaction(ctx context.Context, tx *domain.Transaction) (*domain.AddedTransaction, error) {
+	// Derive a missing category from the merchant rules
+	category, ruleLabels, err := s.transactionCategory(tx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get user ID
-	user, err := s.client.GetMe()
+	user, err := s.getUser(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get user info: %w", err)
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	// Fetch transaction accounts
-	accounts, err := s.client.GetTransactionAccounts(user.ID)
-	if err != nil {
-		return fmt.Errorf("failed to get transaction accounts: %w", err)
+	// Fetch transaction accounts and categories concurrently
+	lists := s.fetchAccountsAndCategories(ctx, user.ID)
+	if err := lists.Err(); err != nil {
+		return nil, err
 	}
+	accounts, categories := lists.Accounts, lists.Categories
 
-	// Fetch categories
-	categories, err := s.client.GetCategories(user.ID)
+	// Find transaction account by name, using the currency to tell same-named accounts apart
+	account, err := resolveAccount(accounts, tx.Account, tx.AccountCurrency)
 	if err != nil {
-		return fmt.Errorf("failed to get categories: %w", err)
+		return nil, err
 	}
 
-	// Find transaction account by name
-	var accountID *int
-	for _, account := range accounts {
-		if strings.ToLower(account.Name) == accountLower {
-			accountID = &account.ID
-			break
+	// Find the category, refetching the categories once if they may be stale
+	categoryID, err := s.resolveCategory(categories, tx.CategoryID, category)
+	if err != nil && s.options.CategorySelfHeal && IsLookupError(err) {
+		if refreshed := s.selfHealCategories(ctx, user.ID); refreshed != nil {
+			categories = refreshed
+			categoryID, err = s.resolveCategory(categories, tx.CategoryID, category)
+		}
+	}
+	if err != nil {
+		// Per on_unknown_category, create it uncategorized or in the fallback category instead
+		if categoryID, err = s.applyUnknownCategory(categories, err); err != nil {
+			return nil, err
 		}
 	}
-	if accountID == nil {
-		log.Printf("ERROR: No transaction account found in PocketSmith API with name: '%s' (searched among %d accounts)", tx.Account, len(accounts))
-		return &lookupError{message: fmt.Sprintf("no transaction account found with name: %s", tx.Account)}
+
+	added := &domain.AddedTransaction{Account: domain.ResolvedEntity{ID: account.ID, Name: account.Name}}
+	if categoryID != nil {
+		// Optionally post to the top-level parent category instead
+		if s.options.RollupToParent {
+			categoryID = s.rollupToParent(categories, *categoryID)
+		}
+		if resolved, ok := categoryByID(categories, *categoryID); ok {
+			added.Category = &domain.ResolvedEntity{ID: resolved.ID, Name: resolved.Title}
+		}
+
+		// Catch expenses posted to income categories and vice versa
+		if err := s.checkCategorySign(categories, *categoryID, tx); err != nil {
+			return nil, err
+		}
 	}
 
-	// Find category by title
-	var categoryID *int
-	categoryID = s.findCategoryByTitle(categories, categoryLower)
-	if categoryID == nil {
-		log.Printf("ERROR: No category found in PocketSmith API with title: '%s' (searched among %d categories)", tx.Category, len(categories))
-		return &lookupError{message: fmt.Sprintf("no category found with title: %s", tx.Category)}
+	// Record where the transaction came from, as a label or the note
+	source := tx.Source
+	if source == "" {
+		source = s.options.DefaultSource
 	}
+	var note string
+	var sourceLabels []string
+	if source != "" {
+		if s.options.SourceTarget == SourceNote {
+			note = source
+		} else {
+			sourceLabels = []string{source}
+		}
+	}
+
+	// A separate payee moves the merchant to the note or a label
+	payee, merchantNote, merchantLabels := s.payeeOf(tx)
+
+	// Combine the transaction's own labels with derived and default ones, without repeats
+	labels := mergeLabels(tx.Labels, ruleLabels, merchantLabels, sourceLabels, s.options.DefaultLabels)
 
 	// Transform domain transaction to PocketSmith format
 	psTx := &domain.PocketSmithTransaction{
-		Payee:      tx.Merchant,
-		Amount:     tx.Amount,
-		Date:       tx.Date,
-		IsTransfer: false,
-		CategoryID: categoryID,
+		Payee:       payee,
+		Amount:      tx.Amount,
+		Date:        transactionDate(tx, s.userTimeZone(user)),
+		IsTransfer:  tx.IsTransfer,
+		CategoryID:  categoryID,
+		Labels:      strings.Join(labels, ","),
+		NeedsReview: tx.NeedsReview,
+		Note:        joinNotes(merchantNote, note),
+	}
+
+	// Pass through the original foreign-currency amount, if given
+	if tx.ForeignAmount != "" {
+		psTx.ForeignAmount = tx.ForeignAmount
+		psTx.ForeignCurrencyCode = tx.Currency
+	}
+
+	// Optionally round amounts to their currency's precision, otherwise keep them as sent
+	if s.options.RoundAmount {
+		psTx.Amount = RoundAmount(psTx.Amount, account.CurrencyCode)
+		if psTx.ForeignAmount != "" {
+			psTx.ForeignAmount = RoundAmount(psTx.ForeignAmount, psTx.ForeignCurrencyCode)
+		}
+	}
+
+	// In upsert mode, update a matching transaction instead of creating a duplicate
+	if tx.Upsert {
+		existingID, err := s.findUpsertMatch(ctx, account.ID, psTx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up existing transaction: %w", err)
+		}
+		if existingID != nil {
+			log.Printf("Upsert: updating existing transaction %d in account %d (payee: '%s', amount: %s, date: %s)", *existingID, account.ID, psTx.Payee, psTx.Amount, psTx.Date)
+			if err := s.client.UpdateTransaction(ctx, *existingID, psTx); err != nil {
+				return nil, err
+			}
+			added.ID = *existingID
+			return added, nil
+		}
+	}
+
+	// Catch accidental double submits of the same transaction (upserts are idempotent already)
+	var submission string
+	if s.options.DedupWindow > 0 && !tx.Upsert {
+		var claimed bool
+		if claimed, submission = s.claimSubmission(ctx, user.ID, account.ID, psTx); !claimed {
+			log.Printf("Duplicate submission in account %d within %v (payee: '%s', amount: %s, date: %s)", account.ID, s.options.DedupWindow, psTx.Payee, psTx.Amount, psTx.Date)
+			if s.options.DedupMode == DedupIgnore {
+				added.Duplicate = true
+				return added, nil
+			}
+			return nil, ErrDuplicateSubmission
+		}
 	}
 
 	// Create transaction via API client
-	return s.client.CreateTransaction(*accountID, psTx)
+	added.ID, err = s.client.CreateTransaction(ctx, account.ID, psTx)
+	if err != nil {
+		s.releaseSubmission(ctx, user.ID, submission)
+		return nil, err
+	}
+
+	// The create can't be interrupted, so it may finish after the deadline.
+	// Don't fail the request then, or the client would retry and duplicate it.
+	if ctx.Err() != nil {
+		log.Printf("WARNING: Transaction created in account %d after the request deadline passed (payee: '%s', amount: %s, date: %s)", account.ID, psTx.Payee, psTx.Amount, psTx.Date)
+	}
+
+	return added, nil
+}
+
+// transactionCategory returns the category title to look up for a transaction: its own,
+// or the one set by the merchant rule it matches when it has neither title nor ID.
+// A matching rule's labels are returned too.
+func (s *TransactionServiceImpl) transactionCategory(tx *domain.Transaction) (string, []string, error) {
+	if tx.CategoryID != nil || tx.Category != "" {
+		return tx.Category, nil, nil
+	}
+	rule := matchMerchantRule(s.options.MerchantRules, tx.Merchant)
+	if rule == nil {
+		return "", nil, &lookupError{code: CodeNoMerchantRule, message: fmt.Sprintf("no category given and no merchant rule matches: %s", tx.Merchant)}
+	}
+	if rule.Category == "" {
+		return "", nil, &lookupError{code: CodeNoMerchantRule, message: fmt.Sprintf("no category given and the merchant rule matching %s sets none", tx.Merchant)}
+	}
+	return rule.Category, rule.Labels, nil
+}
+
+// resolveCategory finds a category by ID when one is given, otherwise by title (case-insensitive)
+func (s *TransactionServiceImpl) resolveCategory(categories []domain.Category, id *int, title string) (*int, error) {
+	// Tell a user without categories to create some, rather than reporting a lookup miss
+	if len(categories) == 0 {
+		log.Printf("ERROR: User has no categories in PocketSmith")
+		return nil, errNoCategories
+	}
+
+	if id != nil {
+		found := findCategoryByID(categories, *id, nil)
+		if found == nil {
+			log.Printf("ERROR: No category found in PocketSmith API with ID: %d (searched among %d top-level categories)", *id, len(categories))
+			return nil, &lookupError{code: CodeCategoryNotFound, message: fmt.Sprintf("no category found with ID: %d", *id)}
+		}
+		return &found.ID, nil
+	}
+
+	categoryID := s.findCategoryByTitle(categories, strings.ToLower(title))
+	if categoryID == nil {
+		log.Printf("ERROR: No category found in PocketSmith API with title: '%s' (searched among %d top-level categories)", title, len(categories))
+		return nil, &lookupError{code: CodeCategoryNotFound, message: fmt.Sprintf("no category found with title: %s", title)}
+	}
+	return categoryID, nil
 }
 
 // findCategoryByTitle recursively searches for a category by title (case-insensitive)
@@ -114,20 +440,81 @@ func (s *TransactionServiceImpl) findCategoryByTitle(categories []domain.Categor
 		if strings.ToLower(category.Title) == titleLower {
 			return &category.ID
 		}
+		if id := s.findCategoryByTitle(category.Children, titleLower); id != nil {
+			return id
+		}
+	}
+	return nil
+}
+
+// findCategoryByID recursively searches the category tree for a category ID, returning it
+// with the titles of its ancestors after parents (nil when it isn't in the tree)
+func findCategoryByID(categories []domain.Category, id int, parents []string) *domain.CategoryDetail {
+	for _, category := range categories {
+		path := make([]string, len(parents), len(parents)+1)
+		copy(path, parents)
+		path = append(path, category.Title)
+
+		if category.ID == id {
+			return &domain.CategoryDetail{Category: category, Path: path}
+		}
+		if found := findCategoryByID(category.Children, id, path); found != nil {
+			return found
+		}
 	}
 	return nil
 }
 
+// flattenCategories returns every category in the tree, parents before children
+func flattenCategories(categories []domain.Category) []domain.Category {
+	var flat []domain.Category
+	for _, category := range categories {
+		flat = append(flat, category)
+		flat = append(flat, flattenCategories(category.Children)...)
+	}
+	return flat
+}
+
+// rollupToParent walks up from a category to its top-level ancestor.
+// A ParentID pointing outside the category list stops the walk there.
+func (s *TransactionServiceImpl) rollupToParent(categories []domain.Category, categoryID int) *int {
+	byID := make(map[int]domain.Category)
+	for _, category := range flattenCategories(categories) {
+		byID[category.ID] = category
+	}
+
+	current := byID[categoryID]
+	visited := map[int]bool{categoryID: true}
+	for current.ParentID != nil {
+		parent, ok := byID[*current.ParentID]
+		if !ok {
+			log.Printf("Warning: Category %d has parent %d which is not in the category list, rolling up to %d", current.ID, *current.ParentID, current.ID)
+			break
+		}
+		if visited[parent.ID] {
+			log.Printf("Warning: Category parent cycle detected at %d, rolling up to %d", parent.ID, current.ID)
+			break
+		}
+		visited[parent.ID] = true
+		current = parent
+	}
+
+	if current.ID != categoryID {
+		log.Printf("Rolled up category %d to top-level parent %d (%s)", categoryID, current.ID, current.Title)
+	}
+	return &current.ID
+}
+
 // GetCategories implements TransactionService.GetCategories
-func (s *TransactionServiceImpl) GetCategories() ([]string, error) {
+func (s *TransactionServiceImpl) GetCategories(ctx context.Context) ([]string, error) {
 	// Get user ID
-	user, err := s.client.GetMe()
+	user, err := s.getUser(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
 	// Fetch categories from cache or API
-	categories, err := s.client.GetCategories(user.ID)
+	categories, err := s.getCategories(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
@@ -144,18 +531,115 @@ func (s *TransactionServiceImpl) GetCategories() ([]string, error) {
 	return categoryNames, nil
 }
 
-// GetAccounts implements TransactionService.GetAccounts
-func (s *TransactionServiceImpl) GetAccounts() ([]domain.AccountInfo, error) {
+// GetCategoryDetails implements TransactionService.GetCategoryDetails
+func (s *TransactionServiceImpl) GetCategoryDetails(ctx context.Context) ([]domain.Category, error) {
 	// Get user ID
-	user, err := s.client.GetMe()
+	user, err := s.getUser(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	// Fetch accounts from cache or API
-	accounts, err := s.client.GetTransactionAccounts(user.ID)
+	// Fetch categories from cache or API
+	categories, err := s.getCategories(ctx, user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transaction accounts: %w", err)
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	// Sort by title without mutating the shared slice
+	details := make([]domain.Category, len(categories))
+	copy(details, categories)
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].Title < details[j].Title
+	})
+
+	return details, nil
+}
+
+// GetCategory implements TransactionService.GetCategory
+func (s *TransactionServiceImpl) GetCategory(ctx context.Context, id int) (*domain.CategoryDetail, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Fetch categories from cache or API
+	categories, err := s.getCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	category := findCategoryByID(categories, id, nil)
+	if category == nil {
+		return nil, &lookupError{code: CodeCategoryNotFound, message: fmt.Sprintf("no category found with ID: %d", id)}
+	}
+	return category, nil
+}
+
+// SearchCategories implements TransactionService.SearchCategories
+func (s *TransactionServiceImpl) SearchCategories(ctx context.Context, query string, limit int) ([]domain.CategoryMatch, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Fetch categories from cache or API
+	categories, err := s.getCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	// Collect prefix and substring matches across the whole tree
+	queryLower := strings.ToLower(query)
+	var prefixMatches, substringMatches []domain.CategoryMatch
+	walkCategories(categories, nil, func(category domain.Category, path []string) {
+		titleLower := strings.ToLower(category.Title)
+		match := domain.CategoryMatch{ID: category.ID, Title: category.Title, Path: path}
+		switch {
+		case strings.HasPrefix(titleLower, queryLower):
+			prefixMatches = append(prefixMatches, match)
+		case strings.Contains(titleLower, queryLower):
+			substringMatches = append(substringMatches, match)
+		}
+	})
+
+	// Rank prefix matches before substring matches, alphabetically within each
+	byTitle := func(matches []domain.CategoryMatch) {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Title < matches[j].Title
+		})
+	}
+	byTitle(prefixMatches)
+	byTitle(substringMatches)
+
+	matches := append(prefixMatches, substringMatches...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if matches == nil {
+		matches = []domain.CategoryMatch{}
+	}
+	return matches, nil
+}
+
+// walkCategories visits every category in the tree with its path of titles from the top level
+func walkCategories(categories []domain.Category, parents []string, visit func(category domain.Category, path []string)) {
+	for _, category := range categories {
+		path := make([]string, len(parents), len(parents)+1)
+		copy(path, parents)
+		path = append(path, category.Title)
+
+		visit(category, path)
+		walkCategories(category.Children, path, visit)
+	}
+}
+
+// GetAccounts implements TransactionService.GetAccounts
+func (s *TransactionServiceImpl) GetAccounts(ctx context.Context, types []string) ([]domain.AccountInfo, error) {
+	accounts, err := s.GetAccountDetails(ctx, types)
+	if err != nil {
+		return nil, err
 	}
 
 	// Transform to AccountInfo
@@ -164,38 +648,172 @@ func (s *TransactionServiceImpl) GetAccounts() ([]domain.AccountInfo, error) {
 		accountInfos = append(accountInfos, domain.AccountInfo{
 			Name:     account.Name,
 			Currency: account.CurrencyCode,
+			Type:     account.Type,
 		})
 	}
 
 	return accountInfos, nil
 }
 
-// GetShortcutEntities implements TransactionService.GetShortcutEntities
-func (s *TransactionServiceImpl) GetShortcutEntities() (*domain.ShortcutEntities, error) {
+// GetAccountDetails implements TransactionService.GetAccountDetails
+func (s *TransactionServiceImpl) GetAccountDetails(ctx context.Context, types []string) ([]domain.TransactionAccount, error) {
 	// Get user ID
-	user, err := s.client.GetMe()
+	user, err := s.getUser(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	// Fetch accounts
-	accounts, err := s.client.GetTransactionAccounts(user.ID)
+	// Fetch accounts from cache or API
+	accounts, err := s.getTransactionAccounts(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction accounts: %w", err)
 	}
 
-	// Fetch categories
-	categories, err := s.client.GetCategories(user.ID)
+	// Build the set of requested types
+	wantedTypes := make(map[string]bool, len(types))
+	for _, accountType := range types {
+		wantedTypes[strings.ToLower(accountType)] = true
+	}
+
+	// Filter by type without mutating the shared slice
+	filtered := make([]domain.TransactionAccount, 0, len(accounts))
+	for _, account := range accounts {
+		if len(wantedTypes) > 0 && !wantedTypes[strings.ToLower(account.Type)] {
+			continue
+		}
+		filtered = append(filtered, account)
+	}
+
+	sortAccounts(filtered)
+	return filtered, nil
+}
+
+// sortAccounts sorts accounts by name (case-insensitive), then by ID, so the order
+// doesn't depend on how PocketSmith or the cache returned them
+func sortAccounts(accounts []domain.TransactionAccount) {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := strings.ToLower(accounts[i].Name), strings.ToLower(accounts[j].Name)
+		if a != b {
+			return a < b
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+}
+
+// ListTransactions implements TransactionService.ListTransactions
+func (s *TransactionServiceImpl) ListTransactions(ctx context.Context, account, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	// Get user ID
+	user, err := s.getUser(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %w", err)
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Fetch accounts from cache or API
+	accounts, err := s.getTransactionAccounts(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction accounts: %w", err)
+	}
+
+	found, err := resolveAccount(accounts, account, "")
+	if err != nil {
+		return err
+	}
+
+	return s.client.ListTransactions(ctx, found.ID, startDate, endDate, visit)
+}
+
+// resolveAccount finds the account with the given name (case-insensitive).
+// When several accounts share the name, the one whose currency matches is picked,
+// and a lookup error listing the candidates is returned if that doesn't single one out.
+func resolveAccount(accounts []domain.TransactionAccount, name, currency string) (*domain.TransactionAccount, error) {
+	var candidates []*domain.TransactionAccount
+	for i := range accounts {
+		if strings.EqualFold(accounts[i].Name, name) {
+			candidates = append(candidates, &accounts[i])
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		if len(accounts) == 0 {
+			log.Printf("ERROR: User has no transaction accounts in PocketSmith (looking for '%s')", name)
+			return nil, errNoAccounts
+		}
+		log.Printf("ERROR: No transaction account found in PocketSmith API with name: '%s' (searched among %d accounts)", name, len(accounts))
+		return nil, &lookupError{code: CodeAccountNotFound, message: fmt.Sprintf("no transaction account found with name: %s", name)}
+	case 1:
+		return candidates[0], nil
+	}
+
+	var inCurrency []*domain.TransactionAccount
+	for _, candidate := range candidates {
+		if currency != "" && strings.EqualFold(candidate.CurrencyCode, currency) {
+			inCurrency = append(inCurrency, candidate)
+		}
+	}
+	if len(inCurrency) == 1 {
+		return inCurrency[0], nil
+	}
+
+	descriptions := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", candidate.Name, strings.ToUpper(candidate.CurrencyCode)))
+	}
+	log.Printf("ERROR: %d transaction accounts in PocketSmith API match name '%s' and currency '%s' doesn't pick one: %s", len(candidates), name, currency, strings.Join(descriptions, ", "))
+	if currency == "" {
+		return nil, &lookupError{code: CodeAccountAmbiguous, message: fmt.Sprintf("multiple transaction accounts named %s, send the account currency to pick one of: %s", name, strings.Join(descriptions, ", "))}
+	}
+	return nil, &lookupError{code: CodeCurrencyMismatch, message: fmt.Sprintf("currency %s doesn't pick one of the transaction accounts named %s: %s", currency, name, strings.Join(descriptions, ", "))}
+}
+
+// CreateAccount implements TransactionService.CreateAccount
+func (s *TransactionServiceImpl) CreateAccount(ctx context.Context, params *domain.AccountParams) (int, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	return s.client.CreateAccount(ctx, user.ID, params)
+}
+
+// GetShortcutEntities implements TransactionService.GetShortcutEntities
+func (s *TransactionServiceImpl) GetShortcutEntities(ctx context.Context) (*domain.ShortcutEntities, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
+	// Fetch accounts and categories concurrently, tolerating the failure of one of them
+	lists := s.fetchAccountsAndCategories(ctx, user.ID)
+	if lists.AccountsErr != nil && lists.CategoriesErr != nil {
+		return nil, lists.Err()
+	}
+	accounts, categories := lists.Accounts, lists.Categories
+
+	var warnings []string
+	if lists.AccountsErr != nil {
+		log.Printf("Warning: Returning shortcut entities without accounts: %v", lists.AccountsErr)
+		warnings = append(warnings, fmt.Sprintf("failed to get transaction accounts: %v", lists.AccountsErr))
+	}
+	if lists.CategoriesErr != nil {
+		log.Printf("Warning: Returning shortcut entities without categories: %v", lists.CategoriesErr)
+		warnings = append(warnings, fmt.Sprintf("failed to get categories: %v", lists.CategoriesErr))
+	}
+
+	// Sort without mutating the shared slice
+	sorted := make([]domain.TransactionAccount, len(accounts))
+	copy(sorted, accounts)
+	sortAccounts(sorted)
+
 	// Transform accounts
-	accountInfos := make([]domain.AccountInfo, 0, len(accounts))
-	for _, account := range accounts {
+	accountInfos := make([]domain.AccountInfo, 0, len(sorted))
+	for _, account := range sorted {
 		accountInfos = append(accountInfos, domain.AccountInfo{
 			Name:     account.Name,
 			Currency: account.CurrencyCode,
+			Type:     account.Type,
 		})
 	}
 
@@ -211,5 +829,70 @@ func (s *TransactionServiceImpl) GetShortcutEntities() (*domain.ShortcutEntities
 	return &domain.ShortcutEntities{
 		Accounts:   accountInfos,
 		Categories: categoryNames,
+		Warnings:   warnings,
 	}, nil
 }
+
+// WhoAmI implements TransactionService.WhoAmI
+func (s *TransactionServiceImpl) WhoAmI(ctx context.Context) (*domain.User, error) {
+	user, err := s.client.RefreshUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	return user, nil
+}
+
+// RefreshAll implements TransactionService.RefreshAll.
+// It only overwrites cache entries, so requests running concurrently see either
+// the previous or the refreshed data.
+func (s *TransactionServiceImpl) RefreshAll(ctx context.Context) error {
+	// Get user ID
+	user, err := s.client.GetMe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Both lists are fetched before either is cached, so a failure leaves the cache as it was
+	accounts, categories, err := s.client.RefreshAccountsAndCategories(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Refreshed cache for user %d: %d accounts, %d categories", user.ID, len(accounts), len(categories))
+	return nil
+}
+
+// FlushCache implements TransactionService.FlushCache.
+// Unlike RefreshAll it doesn't need PocketSmith, so it works even when the API is down.
+func (s *TransactionServiceImpl) FlushCache(ctx context.Context) (int, error) {
+	deleted, err := s.client.FlushCache(ctx)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to flush cache: %w", err)
+	}
+	return deleted, nil
+}
+
+// GetCacheStats implements TransactionService.GetCacheStats
+func (s *TransactionServiceImpl) GetCacheStats(ctx context.Context) (domain.CacheStats, error) {
+	stats, err := s.client.GetCacheStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	return stats, nil
+}
+
+// GetCacheTTL implements TransactionService.GetCacheTTL
+func (s *TransactionServiceImpl) GetCacheTTL(ctx context.Context, entity domain.CacheEntity) (time.Duration, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	return s.client.GetCacheTTL(ctx, user.ID, entity)
+}
+
+// RateLimit implements TransactionService.RateLimit
+func (s *TransactionServiceImpl) RateLimit() domain.RateLimit {
+	return s.client.RateLimit()
+}
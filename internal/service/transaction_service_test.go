@@ -0,0 +1,588 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// recordingClient serves its own accounts and categories, defaulting to the test ones,
+// or fails either list with its error, and records every transaction created
+type recordingClient struct {
+	*countingClient
+	accounts    []domain.TransactionAccount
+	categories  []domain.Category
+	accountsErr error
+	created     []*domain.PocketSmithTransaction
+}
+
+func newRecordingClient() *recordingClient {
+	return &recordingClient{countingClient: newCountingClient(), accounts: testAccounts(), categories: testCategories()}
+}
+
+func (c *recordingClient) GetAccountsAndCategories(ctx context.Context, userID int) api.AccountsAndCategories {
+	c.count("GetAccountsAndCategories")
+	lists := api.AccountsAndCategories{AccountsErr: c.accountsErr, CategoriesErr: c.categoriesErr}
+	if c.accountsErr == nil {
+		lists.Accounts = c.accounts
+	}
+	if c.categoriesErr == nil {
+		lists.Categories = c.categories
+	}
+	return lists
+}
+
+func (c *recordingClient) GetTransactionAccounts(ctx context.Context, userID int) ([]domain.TransactionAccount, error) {
+	c.count("GetTransactionAccounts")
+	if c.accountsErr != nil {
+		return nil, c.accountsErr
+	}
+	return c.accounts, nil
+}
+
+func (c *recordingClient) GetCategories(ctx context.Context, userID int) ([]domain.Category, error) {
+	c.count("GetCategories")
+	if c.categoriesErr != nil {
+		return nil, c.categoriesErr
+	}
+	return c.categories, nil
+}
+
+func (c *recordingClient) CreateTransaction(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (int, error) {
+	c.created = append(c.created, transaction)
+	return 100 + len(c.created), nil
+}
+
+// nestedCategories returns Food > Groceries alongside a top-level Salary
+func nestedCategories() []domain.Category {
+	return []domain.Category{
+		{ID: 30, Title: "Food", Children: []domain.Category{{ID: 31, Title: "Groceries", ParentID: intPtr(30)}}},
+		{ID: 21, Title: "Salary", IsIncome: true},
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestAddTransactionCategoryByID(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       *int
+		title    string
+		wantID   int
+		wantCode LookupErrorCode
+	}{
+		{name: "nested ID", id: intPtr(31), wantID: 31},
+		{name: "top-level ID", id: intPtr(21), wantID: 21},
+		{name: "ID over another category's title", id: intPtr(31), title: "Salary", wantID: 31},
+		{name: "title without an ID", title: "groceries", wantID: 31},
+		{name: "unknown ID despite a known title", id: intPtr(99), title: "Salary", wantCode: CodeCategoryNotFound},
+		{name: "unknown title", title: "Rent", wantCode: CodeCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = nestedCategories()
+			svc := NewTransactionService(client, Options{})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.title, CategoryID: tt.id, Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+			})
+			if tt.wantCode != "" {
+				if !IsLookupError(err) || ErrorCode(err) != tt.wantCode {
+					t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, tt.wantCode)
+				}
+				if len(client.created) > 0 {
+					t.Errorf("created %d transactions, want none", len(client.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if got := client.created[0].CategoryID; got == nil || *got != tt.wantID {
+				t.Errorf("created with category %v, want %d", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestGetShortcutEntitiesPartialFailure(t *testing.T) {
+	outage := errors.New("pocketsmith unavailable")
+	tests := []struct {
+		name           string
+		accountsErr    error
+		categoriesErr  error
+		wantErr        bool
+		wantAccounts   int
+		wantCategories int
+		wantWarnings   []string
+	}{
+		{name: "both succeed", wantAccounts: 2, wantCategories: 2},
+		{name: "accounts fail", accountsErr: outage, wantCategories: 2, wantWarnings: []string{"failed to get transaction accounts: pocketsmith unavailable"}},
+		{name: "categories fail", categoriesErr: outage, wantAccounts: 2, wantWarnings: []string{"failed to get categories: pocketsmith unavailable"}},
+		{name: "both fail", accountsErr: outage, categoriesErr: outage, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.accountsErr, client.categoriesErr = tt.accountsErr, tt.categoriesErr
+			svc := NewTransactionService(client, Options{})
+
+			entities, err := svc.GetShortcutEntities(context.Background())
+			if tt.wantErr {
+				if err == nil || !errors.Is(err, outage) {
+					t.Fatalf("GetShortcutEntities() error = %v, want one wrapping %v", err, outage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetShortcutEntities() error = %v", err)
+			}
+			if len(entities.Accounts) != tt.wantAccounts || len(entities.Categories) != tt.wantCategories {
+				t.Errorf("got %d accounts and %d categories, want %d and %d", len(entities.Accounts), len(entities.Categories), tt.wantAccounts, tt.wantCategories)
+			}
+			if !reflect.DeepEqual(entities.Warnings, tt.wantWarnings) {
+				t.Errorf("Warnings = %q, want %q", entities.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestGetAccountsTypeFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  []string
+	}{
+		{name: "no filter", want: []string{"Everyday", "Mortgage", "Visa"}},
+		{name: "single type", types: []string{"bank"}, want: []string{"Everyday"}},
+		{name: "single type, any case", types: []string{"CREDIT"}, want: []string{"Visa"}},
+		{name: "multiple types", types: []string{"bank", "Loan"}, want: []string{"Everyday", "Mortgage"}},
+		{name: "unknown type", types: []string{"property"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.accounts = []domain.TransactionAccount{
+				{ID: 10, Name: "Everyday", CurrencyCode: "aud", Type: "bank"},
+				{ID: 12, Name: "Visa", CurrencyCode: "aud", Type: "Credit"},
+				{ID: 13, Name: "Mortgage", CurrencyCode: "aud", Type: "loan"},
+			}
+			svc := NewTransactionService(client, Options{})
+
+			accounts, err := svc.GetAccounts(context.Background(), tt.types)
+			if err != nil {
+				t.Fatalf("GetAccounts() error = %v", err)
+			}
+			names := make([]string, 0, len(accounts))
+			for _, account := range accounts {
+				names = append(names, account.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("GetAccounts(%q) = %q, want %q", tt.types, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionRollupToParent(t *testing.T) {
+	// Food > Groceries > Fruit, and Sweets under Snacks, whose parent isn't in the list
+	categories := []domain.Category{
+		{ID: 30, Title: "Food", Children: []domain.Category{
+			{ID: 31, Title: "Groceries", ParentID: intPtr(30), Children: []domain.Category{
+				{ID: 32, Title: "Fruit", ParentID: intPtr(31)},
+			}},
+		}},
+		{ID: 40, Title: "Snacks", ParentID: intPtr(99), Children: []domain.Category{
+			{ID: 41, Title: "Sweets", ParentID: intPtr(40)},
+		}},
+	}
+	tests := []struct {
+		name     string
+		rollup   bool
+		category string
+		wantID   int
+	}{
+		{name: "top level", rollup: true, category: "Food", wantID: 30},
+		{name: "one level", rollup: true, category: "Groceries", wantID: 30},
+		{name: "two levels", rollup: true, category: "Fruit", wantID: 30},
+		{name: "orphaned parent", rollup: true, category: "Snacks", wantID: 40},
+		{name: "below an orphaned parent", rollup: true, category: "Sweets", wantID: 40},
+		{name: "rollup off", category: "Fruit", wantID: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = categories
+			svc := NewTransactionService(client, Options{RollupToParent: tt.rollup})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.category, Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+			})
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if got := client.created[0].CategoryID; got == nil || *got != tt.wantID {
+				t.Errorf("created with category %v, want %d", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSearchCategories(t *testing.T) {
+	// Food > Groceries > Fruit Juice, Fuel, Salary and Seafood
+	categories := []domain.Category{
+		{ID: 30, Title: "Food", Children: []domain.Category{
+			{ID: 31, Title: "Groceries", ParentID: intPtr(30), Children: []domain.Category{
+				{ID: 32, Title: "Fruit Juice", ParentID: intPtr(31)},
+			}},
+			{ID: 33, Title: "Seafood", ParentID: intPtr(30)},
+		}},
+		{ID: 40, Title: "Fuel"},
+		{ID: 21, Title: "Salary", IsIncome: true},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		want      []string
+		wantPaths [][]string
+	}{
+		{name: "prefix before substring", query: "f", limit: 10, want: []string{"Food", "Fruit Juice", "Fuel", "Seafood"}},
+		{name: "any case", query: "FOOD", limit: 10, want: []string{"Food", "Seafood"}},
+		{name: "nested path", query: "juice", limit: 10, want: []string{"Fruit Juice"}, wantPaths: [][]string{{"Food", "Groceries", "Fruit Juice"}}},
+		{name: "limit keeps the best ranked", query: "f", limit: 2, want: []string{"Food", "Fruit Juice"}},
+		{name: "limit drops substring matches first", query: "f", limit: 3, want: []string{"Food", "Fruit Juice", "Fuel"}},
+		{name: "no match", query: "rent", limit: 10, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = categories
+			svc := NewTransactionService(client, Options{})
+
+			matches, err := svc.SearchCategories(context.Background(), tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("SearchCategories() error = %v", err)
+			}
+			titles := make([]string, 0, len(matches))
+			var paths [][]string
+			for _, match := range matches {
+				titles = append(titles, match.Title)
+				paths = append(paths, match.Path)
+			}
+			if !reflect.DeepEqual(titles, tt.want) {
+				t.Errorf("SearchCategories(%q, %d) = %q, want %q", tt.query, tt.limit, titles, tt.want)
+			}
+			if tt.wantPaths != nil && !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("paths = %q, want %q", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestAddTransactionEmptyLists(t *testing.T) {
+	tests := []struct {
+		name        string
+		accounts    []domain.TransactionAccount
+		categories  []domain.Category
+		account     string
+		category    string
+		wantCode    LookupErrorCode
+		wantMessage string
+	}{
+		{name: "no accounts", categories: testCategories(), account: "Everyday", category: "Groceries",
+			wantCode: CodeNoAccounts, wantMessage: "no accounts exist for this user, create one in PocketSmith first"},
+		{name: "no categories", accounts: testAccounts(), account: "Everyday", category: "Groceries",
+			wantCode: CodeNoCategories, wantMessage: "no categories exist for this user, create one in PocketSmith first"},
+		{name: "account name miss", accounts: testAccounts(), categories: testCategories(), account: "Savings", category: "Groceries",
+			wantCode: CodeAccountNotFound},
+		{name: "category title miss", accounts: testAccounts(), categories: testCategories(), account: "Everyday", category: "Rent",
+			wantCode: CodeCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.accounts, client.categories = tt.accounts, tt.categories
+			svc := NewTransactionService(client, Options{})
+
+			_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: tt.account, Category: tt.category, Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+			})
+			if !IsLookupError(err) || ErrorCode(err) != tt.wantCode {
+				t.Fatalf("AddTransaction() error = %v, want a %s lookup error", err, tt.wantCode)
+			}
+			if tt.wantMessage != "" && err.Error() != tt.wantMessage {
+				t.Errorf("AddTransaction() error = %q, want %q", err, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAccountOrder(t *testing.T) {
+	unsorted := []domain.TransactionAccount{
+		{ID: 14, Name: "travel", CurrencyCode: "eur"},
+		{ID: 12, Name: "Everyday", CurrencyCode: "aud"},
+		{ID: 13, Name: "Travel", CurrencyCode: "usd"},
+		{ID: 11, Name: "Travel", CurrencyCode: "gbp"},
+		{ID: 10, Name: "bills", CurrencyCode: "aud"},
+	}
+	wantIDs := []int{10, 12, 11, 13, 14}
+	wantCurrencies := []string{"aud", "aud", "gbp", "usd", "eur"}
+	tests := []struct {
+		name       string
+		currencies func(svc TransactionService) ([]string, error)
+	}{
+		{name: "accounts", currencies: func(svc TransactionService) ([]string, error) {
+			accounts, err := svc.GetAccounts(context.Background(), nil)
+			var currencies []string
+			for _, account := range accounts {
+				currencies = append(currencies, account.Currency)
+			}
+			return currencies, err
+		}},
+		{name: "shortcut entities", currencies: func(svc TransactionService) ([]string, error) {
+			entities, err := svc.GetShortcutEntities(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			var currencies []string
+			for _, account := range entities.Accounts {
+				currencies = append(currencies, account.Currency)
+			}
+			return currencies, nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.accounts = append([]domain.TransactionAccount(nil), unsorted...)
+			svc := NewTransactionService(client, Options{})
+
+			// Repeated calls give the same order
+			for range 2 {
+				currencies, err := tt.currencies(svc)
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if !reflect.DeepEqual(currencies, wantCurrencies) {
+					t.Errorf("currencies = %q, want %q", currencies, wantCurrencies)
+				}
+			}
+			if !reflect.DeepEqual(client.accounts, unsorted) {
+				t.Error("sorting reordered the client's accounts")
+			}
+		})
+	}
+
+	client := newRecordingClient()
+	client.accounts = unsorted
+	details, err := NewTransactionService(client, Options{}).GetAccountDetails(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetAccountDetails() error = %v", err)
+	}
+	var ids []int
+	for _, account := range details {
+		ids = append(ids, account.ID)
+	}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("GetAccountDetails() IDs = %v, want %v", ids, wantIDs)
+	}
+}
+
+func TestAddTransactionIsTransfer(t *testing.T) {
+	for _, isTransfer := range []bool{false, true} {
+		client := newRecordingClient()
+		svc := NewTransactionService(client, Options{})
+
+		_, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+			Account: "Everyday", Category: "Groceries", Merchant: "Card Payment", Amount: "-500.00", Date: "2025-01-13", IsTransfer: isTransfer,
+		})
+		if err != nil {
+			t.Fatalf("AddTransaction() error = %v", err)
+		}
+		if got := client.created[0].IsTransfer; got != isTransfer {
+			t.Errorf("created with IsTransfer = %v, want %v", got, isTransfer)
+		}
+	}
+}
+
+func TestLookupErrorCodes(t *testing.T) {
+	twoEveryday := []domain.TransactionAccount{
+		{ID: 10, Name: "Everyday", CurrencyCode: "aud"},
+		{ID: 12, Name: "Everyday", CurrencyCode: "usd"},
+	}
+	tests := []struct {
+		name     string
+		accounts []domain.TransactionAccount
+		tx       domain.Transaction
+		wantCode LookupErrorCode
+	}{
+		{name: "no accounts", accounts: []domain.TransactionAccount{}, tx: domain.Transaction{Account: "Everyday", Category: "Groceries"}, wantCode: CodeNoAccounts},
+		{name: "account not found", tx: domain.Transaction{Account: "Savings", Category: "Groceries"}, wantCode: CodeAccountNotFound},
+		{name: "account ambiguous", accounts: twoEveryday, tx: domain.Transaction{Account: "Everyday", Category: "Groceries"}, wantCode: CodeAccountAmbiguous},
+		{name: "currency mismatch", accounts: twoEveryday, tx: domain.Transaction{Account: "Everyday", AccountCurrency: "nzd", Category: "Groceries"}, wantCode: CodeCurrencyMismatch},
+		{name: "category not found", tx: domain.Transaction{Account: "Everyday", Category: "Rent"}, wantCode: CodeCategoryNotFound},
+		{name: "category ID not found", tx: domain.Transaction{Account: "Everyday", CategoryID: intPtr(99)}, wantCode: CodeCategoryNotFound},
+		{name: "no merchant rule", tx: domain.Transaction{Account: "Everyday"}, wantCode: CodeNoMerchantRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			if tt.accounts != nil {
+				client.accounts = tt.accounts
+			}
+			svc := NewTransactionService(client, Options{})
+
+			tx := tt.tx
+			tx.Merchant, tx.Amount, tx.Date = "Corner Store", "-5.00", "2025-01-13"
+			_, err := svc.AddTransaction(context.Background(), &tx)
+			if !IsLookupError(err) {
+				t.Fatalf("AddTransaction() error = %v, want a lookup error", err)
+			}
+			if got := ErrorCode(err); got != tt.wantCode {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorCodeOfOtherErrors(t *testing.T) {
+	for _, err := range []error{nil, errors.New("pocketsmith unavailable")} {
+		if got := ErrorCode(err); got != "" {
+			t.Errorf("ErrorCode(%v) = %q, want none", err, got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: fmt.Errorf("failed to get user info: %w", context.DeadlineExceeded), want: true},
+		{name: "rate limited", err: &api.RateLimitedError{}, want: true},
+		{name: "rate limit exhausted", err: ErrRateLimitExhausted, want: true},
+		{name: "server error", err: &api.StatusError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "outage page", err: &api.UnavailableError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "failed connection", err: errors.New("connection reset by peer"), want: true},
+		{name: "revoked API key", err: fmt.Errorf("failed to get user info: %w", &api.StatusError{StatusCode: http.StatusUnauthorized}), want: false},
+		{name: "rejected request", err: &api.StatusError{StatusCode: http.StatusUnprocessableEntity}, want: false},
+		{name: "lookup error", err: &lookupError{code: CodeAccountNotFound, message: "no transaction account found with name: Savings"}, want: false},
+		{name: "unknown job", err: ErrJobNotFound, want: false},
+		{name: "duplicate submission", err: ErrDuplicateSubmission, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionResolved(t *testing.T) {
+	groceries := &domain.ResolvedEntity{ID: 31, Name: "Groceries"}
+	tests := []struct {
+		name         string
+		tx           domain.Transaction
+		options      Options
+		wantAccount  domain.ResolvedEntity
+		wantCategory *domain.ResolvedEntity
+	}{
+		{
+			name:         "names as sent",
+			tx:           domain.Transaction{Account: "Everyday", Category: "Groceries"},
+			wantAccount:  domain.ResolvedEntity{ID: 10, Name: "Everyday"},
+			wantCategory: groceries,
+		},
+		{
+			name:         "names corrected to PocketSmith's",
+			tx:           domain.Transaction{Account: "TRAVEL", Category: "groceries"},
+			wantAccount:  domain.ResolvedEntity{ID: 11, Name: "Travel"},
+			wantCategory: groceries,
+		},
+		{
+			name:         "category by ID",
+			tx:           domain.Transaction{Account: "everyday", CategoryID: intPtr(21)},
+			wantAccount:  domain.ResolvedEntity{ID: 10, Name: "Everyday"},
+			wantCategory: &domain.ResolvedEntity{ID: 21, Name: "Salary"},
+		},
+		{
+			name:         "fallback category",
+			tx:           domain.Transaction{Account: "Everyday", Category: "Rent"},
+			options:      Options{UnknownCategory: UnknownCategoryPolicy{Mode: UnknownCategoryDefault, Fallback: "food"}},
+			wantAccount:  domain.ResolvedEntity{ID: 10, Name: "Everyday"},
+			wantCategory: &domain.ResolvedEntity{ID: 30, Name: "Food"},
+		},
+		{
+			name:        "uncategorized",
+			tx:          domain.Transaction{Account: "Everyday", Category: "Rent"},
+			options:     Options{UnknownCategory: UnknownCategoryPolicy{Mode: UnknownCategoryUncategorized}},
+			wantAccount: domain.ResolvedEntity{ID: 10, Name: "Everyday"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = nestedCategories()
+			svc := NewTransactionService(client, tt.options)
+
+			tx := tt.tx
+			tx.Merchant, tx.Amount, tx.Date = "Corner Store", "-5.00", "2025-01-13"
+			added, err := svc.AddTransaction(context.Background(), &tx)
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			want := &domain.AddedTransaction{ID: 101, Account: tt.wantAccount, Category: tt.wantCategory}
+			if !reflect.DeepEqual(added, want) {
+				t.Errorf("AddTransaction() = %+v, want %+v", added, want)
+			}
+		})
+	}
+}
+
+func TestGetCategory(t *testing.T) {
+	categories := nestedCategories()
+	categories[0].Children[0].Children = []domain.Category{{ID: 32, Title: "Fruit", ParentID: intPtr(31)}}
+	tests := []struct {
+		name     string
+		id       int
+		wantPath []string
+	}{
+		{name: "top level", id: 21, wantPath: []string{"Salary"}},
+		{name: "nested", id: 31, wantPath: []string{"Food", "Groceries"}},
+		{name: "nested twice", id: 32, wantPath: []string{"Food", "Groceries", "Fruit"}},
+		{name: "miss", id: 99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRecordingClient()
+			client.categories = categories
+			svc := NewTransactionService(client, Options{})
+
+			got, err := svc.GetCategory(context.Background(), tt.id)
+			if tt.wantPath == nil {
+				if ErrorCode(err) != CodeCategoryNotFound {
+					t.Fatalf("GetCategory() = %v, %v, want a %s lookup error", got, err, CodeCategoryNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCategory() error = %v", err)
+			}
+			if got.ID != tt.id || !reflect.DeepEqual(got.Path, tt.wantPath) {
+				t.Errorf("GetCategory() = %d %v, want %d %v", got.ID, got.Path, tt.id, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// ResolveEntities implements TransactionService.ResolveEntities.
+// It follows AddTransaction's lookups, including merchant rules and rollup, but checks the
+// account and category independently so both problems are reported at once. Empty fields
+// are skipped, and the categories are never refetched, so nothing changes as a side effect.
+func (s *TransactionServiceImpl) ResolveEntities(ctx context.Context, tx *domain.Transaction) (*domain.TransactionEntities, map[string]error, error) {
+	// Get user ID
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	lists := s.fetchAccountsAndCategories(ctx, user.ID)
+	if err := lists.Err(); err != nil {
+		return nil, nil, err
+	}
+	accounts, categories := lists.Accounts, lists.Categories
+
+	entities := &domain.TransactionEntities{}
+	problems := make(map[string]error)
+
+	if tx.Account != "" {
+		if account, err := resolveAccount(accounts, tx.Account, tx.AccountCurrency); err != nil {
+			problems["account"] = err
+		} else {
+			entities.AccountID = account.ID
+			entities.Account = account.Name
+			entities.Currency = strings.ToUpper(account.CurrencyCode)
+		}
+	}
+
+	// Without a category or merchant there is nothing to look up yet
+	if tx.CategoryID == nil && tx.Category == "" && tx.Merchant == "" {
+		return entities, problems, nil
+	}
+	category, _, err := s.transactionCategory(tx)
+	if err != nil {
+		problems["category"] = err
+		return entities, problems, nil
+	}
+	categoryID, err := s.resolveCategory(categories, tx.CategoryID, category)
+	if err != nil {
+		categoryID, err = s.applyUnknownCategory(categories, err)
+	}
+	if err != nil {
+		field := "category"
+		if tx.CategoryID != nil {
+			field = "category_id"
+		}
+		problems[field] = err
+		return entities, problems, nil
+	}
+	// Created uncategorized per on_unknown_category
+	if categoryID == nil {
+		return entities, problems, nil
+	}
+	if s.options.RollupToParent {
+		categoryID = s.rollupToParent(categories, *categoryID)
+	}
+	if resolved, ok := categoryByID(categories, *categoryID); ok {
+		entities.CategoryID = resolved.ID
+		entities.Category = resolved.Title
+	}
+
+	// The amount is only known to be a number when it was valid
+	if tx.Amount != "" {
+		if err := s.checkCategorySign(categories, *categoryID, tx); err != nil {
+			problems["value"] = err
+		}
+	}
+	return entities, problems, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// UnknownCategoryMode selects what happens to a transaction whose category isn't found
+type UnknownCategoryMode string
+
+const (
+	// UnknownCategoryReject fails the transaction with category_not_found (default)
+	UnknownCategoryReject UnknownCategoryMode = "reject"
+	// UnknownCategoryUncategorized creates the transaction without a category
+	UnknownCategoryUncategorized UnknownCategoryMode = "uncategorized"
+	// UnknownCategoryDefault creates the transaction in a fallback category
+	UnknownCategoryDefault UnknownCategoryMode = "default"
+)
+
+// UnknownCategoryPolicy is the parsed on_unknown_category setting
+type UnknownCategoryPolicy struct {
+	Mode UnknownCategoryMode
+	// Fallback is the category title used in UnknownCategoryDefault mode
+	Fallback string
+}
+
+// ParseUnknownCategoryPolicy parses "reject", "uncategorized" or "default:<title>",
+// defaulting to reject when empty
+func ParseUnknownCategoryPolicy(value string) (UnknownCategoryPolicy, error) {
+	mode, fallback, hasFallback := strings.Cut(strings.TrimSpace(value), ":")
+	policy := UnknownCategoryPolicy{Mode: UnknownCategoryMode(strings.ToLower(strings.TrimSpace(mode)))}
+	switch policy.Mode {
+	case "":
+		return UnknownCategoryPolicy{Mode: UnknownCategoryReject}, nil
+	case UnknownCategoryReject, UnknownCategoryUncategorized:
+		if hasFallback {
+			return UnknownCategoryPolicy{}, fmt.Errorf("unknown category mode %s takes no category: %s", policy.Mode, value)
+		}
+		return policy, nil
+	case UnknownCategoryDefault:
+		policy.Fallback = strings.TrimSpace(fallback)
+		if policy.Fallback == "" {
+			return UnknownCategoryPolicy{}, fmt.Errorf("unknown category mode default needs a category, e.g. default:Uncategorized")
+		}
+		return policy, nil
+	default:
+		return UnknownCategoryPolicy{}, fmt.Errorf("unknown category mode: %s", value)
+	}
+}
+
+// MarshalText shows the policy as it is configured, e.g. "default:Other"
+func (p UnknownCategoryPolicy) MarshalText() ([]byte, error) {
+	if p.Mode == UnknownCategoryDefault {
+		return []byte(string(p.Mode) + ":" + p.Fallback), nil
+	}
+	return []byte(p.Mode), nil
+}
+
+// applyUnknownCategory handles a category lookup error per the on_unknown_category policy.
+// A category that isn't found is replaced by no category (nil) or the fallback; any other
+// error, or the reject mode, is returned as is. A fallback that doesn't resolve either
+// fails with category_not_found naming both.
+func (s *TransactionServiceImpl) applyUnknownCategory(categories []domain.Category, err error) (*int, error) {
+	if ErrorCode(err) != CodeCategoryNotFound {
+		return nil, err
+	}
+
+	switch s.options.UnknownCategory.Mode {
+	case UnknownCategoryUncategorized:
+		log.Printf("%v, creating the transaction uncategorized", err)
+		return nil, nil
+	case UnknownCategoryDefault:
+		fallback := s.options.UnknownCategory.Fallback
+		categoryID, fallbackErr := s.resolveCategory(categories, nil, fallback)
+		if fallbackErr != nil {
+			return nil, &lookupError{code: CodeCategoryNotFound, message: fmt.Sprintf("%v, and the fallback category %q wasn't found either", err, fallback)}
+		}
+		log.Printf("%v, using the fallback category '%s'", err, fallback)
+		return categoryID, nil
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+func TestParseUnknownCategoryPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    UnknownCategoryPolicy
+		wantErr bool
+	}{
+		{value: "", want: UnknownCategoryPolicy{Mode: UnknownCategoryReject}},
+		{value: "reject", want: UnknownCategoryPolicy{Mode: UnknownCategoryReject}},
+		{value: " Uncategorized ", want: UnknownCategoryPolicy{Mode: UnknownCategoryUncategorized}},
+		{value: "default:Other", want: UnknownCategoryPolicy{Mode: UnknownCategoryDefault, Fallback: "Other"}},
+		{value: "DEFAULT: Other Expenses ", want: UnknownCategoryPolicy{Mode: UnknownCategoryDefault, Fallback: "Other Expenses"}},
+		{value: "default", wantErr: true},
+		{value: "default:", wantErr: true},
+		{value: "reject:Other", wantErr: true},
+		{value: "ignore", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseUnknownCategoryPolicy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUnknownCategoryPolicy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseUnknownCategoryPolicy(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransactionUnknownCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		category string
+		wantID   *int
+		wantErr  string
+	}{
+		{name: "reject", policy: "reject", category: "Rent", wantErr: "no category found with title: Rent"},
+		{name: "uncategorized", policy: "uncategorized", category: "Rent"},
+		{name: "fallback", policy: "default:Salary", category: "Rent", wantID: intPtr(21)},
+		{name: "fallback missing", policy: "default:Other", category: "Rent", wantErr: `the fallback category "Other" wasn't found either`},
+		{name: "known category kept", policy: "default:Salary", category: "Groceries", wantID: intPtr(20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseUnknownCategoryPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("ParseUnknownCategoryPolicy() error = %v", err)
+			}
+			client := newRecordingClient()
+			svc := NewTransactionService(client, Options{UnknownCategory: policy})
+
+			_, err = svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: tt.category, Merchant: "Corner Store", Amount: "-5.00", Date: "2025-01-13",
+			})
+			if tt.wantErr != "" {
+				if ErrorCode(err) != CodeCategoryNotFound || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("AddTransaction() error = %v, want a %s lookup error saying %s", err, CodeCategoryNotFound, tt.wantErr)
+				}
+				if len(client.created) > 0 {
+					t.Errorf("created %d transactions, want none", len(client.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			got := client.created[0].CategoryID
+			if (got == nil) != (tt.wantID == nil) || (got != nil && *got != *tt.wantID) {
+				t.Errorf("created with category %v, want %v", got, tt.wantID)
+			}
+		})
+	}
+}
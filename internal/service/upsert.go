@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// Fields an upsert can match on. The date always has to match.
+const (
+	UpsertMatchDate   = "date"
+	UpsertMatchAmount = "amount"
+	UpsertMatchPayee  = "payee"
+)
+
+// DefaultUpsertMatchFields match on date, amount, and payee
+var DefaultUpsertMatchFields = []string{UpsertMatchDate, UpsertMatchAmount, UpsertMatchPayee}
+
+// ParseUpsertMatchFields parses a comma-separated list of upsert match fields,
+// falling back to DefaultUpsertMatchFields when the list is empty.
+// The date is always included, since candidates are looked up by date.
+func ParseUpsertMatchFields(value string) ([]string, error) {
+	fields := []string{UpsertMatchDate}
+	empty := true
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		empty = false
+		switch field {
+		case UpsertMatchDate, UpsertMatchAmount, UpsertMatchPayee:
+			if !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		default:
+			return nil, fmt.Errorf("unknown upsert match field %q, expected date, amount, or payee", field)
+		}
+	}
+
+	if empty {
+		return DefaultUpsertMatchFields, nil
+	}
+	return fields, nil
+}
+
+// findUpsertMatch returns the ID of an existing transaction in the account that matches
+// the new one on the configured fields, or nil when there is none.
+// Amounts match within half a cent and payees case-insensitively.
+func (s *TransactionServiceImpl) findUpsertMatch(ctx context.Context, accountID int, transaction *domain.PocketSmithTransaction) (*int, error) {
+	fields := s.options.UpsertMatchFields
+	if len(fields) == 0 {
+		fields = DefaultUpsertMatchFields
+	}
+
+	amount, err := strconv.ParseFloat(transaction.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
+
+	var match *int
+	err = s.client.ListTransactions(ctx, accountID, transaction.Day(), transaction.Day(), func(page []domain.TransactionRecord) error {
+		for _, record := range page {
+			if match != nil {
+				return nil
+			}
+			if slices.Contains(fields, UpsertMatchAmount) && math.Abs(record.Amount-amount) >= 0.005 {
+				continue
+			}
+			if slices.Contains(fields, UpsertMatchPayee) && !strings.EqualFold(record.Payee, transaction.Payee) {
+				continue
+			}
+			id := record.ID
+			match = &id
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/domain"
+)
+
+// upsertClient lists existing as the account's transactions on any date and records the
+// IDs of the transactions it updates
+type upsertClient struct {
+	*recordingClient
+	existing []domain.TransactionRecord
+	updated  []int
+}
+
+func (c *upsertClient) ListTransactions(ctx context.Context, accountID int, startDate, endDate string, visit func([]domain.TransactionRecord) error) error {
+	return visit(c.existing)
+}
+
+func (c *upsertClient) UpdateTransaction(ctx context.Context, transactionID int, transaction *domain.PocketSmithTransaction) error {
+	c.updated = append(c.updated, transactionID)
+	return nil
+}
+
+func TestParseUpsertMatchFields(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "", want: DefaultUpsertMatchFields},
+		{value: "amount", want: []string{"date", "amount"}},
+		{value: " Payee, date,payee ", want: []string{"date", "payee"}},
+		{value: "amount,note", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUpsertMatchFields(tt.value)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseUpsertMatchFields(%q) = %q, %v, want %q (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAddTransactionUpsert(t *testing.T) {
+	existing := []domain.TransactionRecord{
+		{ID: 7, Payee: "Bakery", Amount: -5, Date: "2025-01-13"},
+		{ID: 8, Payee: "corner store", Amount: -12.5, Date: "2025-01-13"},
+	}
+	tests := []struct {
+		name        string
+		upsert      bool
+		matchFields []string
+		merchant    string
+		amount      string
+		wantID      int
+		wantUpdated []int
+	}{
+		{name: "create without upsert", merchant: "Corner Store", amount: "-12.50", wantID: 101},
+		{name: "update on a match", upsert: true, merchant: "Corner Store", amount: "-12.50", wantID: 8, wantUpdated: []int{8}},
+		{name: "create when the amount differs", upsert: true, merchant: "Corner Store", amount: "-12.00", wantID: 101},
+		{name: "create when the payee differs", upsert: true, merchant: "Deli", amount: "-12.50", wantID: 101},
+		{name: "update when only the matched fields agree", upsert: true, matchFields: []string{"date", "amount"}, merchant: "Deli", amount: "-12.50", wantID: 8, wantUpdated: []int{8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &upsertClient{recordingClient: newRecordingClient(), existing: existing}
+			svc := NewTransactionService(client, Options{UpsertMatchFields: tt.matchFields})
+
+			added, err := svc.AddTransaction(context.Background(), &domain.Transaction{
+				Account: "Everyday", Category: "Groceries", Merchant: tt.merchant, Amount: tt.amount, Date: "2025-01-13", Upsert: tt.upsert,
+			})
+			if err != nil {
+				t.Fatalf("AddTransaction() error = %v", err)
+			}
+			if added.ID != tt.wantID {
+				t.Errorf("AddTransaction() ID = %d, want %d", added.ID, tt.wantID)
+			}
+			if !reflect.DeepEqual(client.updated, tt.wantUpdated) {
+				t.Errorf("updated %v, want %v", client.updated, tt.wantUpdated)
+			}
+			if wantCreated := len(tt.wantUpdated) == 0; (len(client.created) == 1) != wantCreated {
+				t.Errorf("created %d transactions, want created %v", len(client.created), wantCreated)
+			}
+		})
+	}
+}
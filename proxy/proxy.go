@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pocketsmith-proxy/internal/api"
+	"github.com/pocketsmith-proxy/internal/config"
+	"github.com/pocketsmith-proxy/internal/handler"
+	"github.com/pocketsmith-proxy/internal/repository"
+	"github.com/pocketsmith-proxy/internal/service"
+)
+
+// Config is the proxy configuration, normally read from Spin variables with LoadConfig.
+// Apps embedding the proxy may fill it in or adjust it themselves.
+type Config = config.Config
+
+// LoadConfig reads and validates every Spin variable of the proxy
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+// NewProxy returns the proxy as an http.Handler, so other Spin apps can mount it.
+// The layers (Cache -> API -> Service -> Handler) are built per request, since the
+// tenant, and with it the PocketSmith key and cache namespace, depends on the client key.
+func NewProxy(cfg Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(&cfg, w, r)
+	})
+}
+
+// serve handles one request with the layers built for its tenant
+func serve(cfg *Config, w http.ResponseWriter, r *http.Request) {
+	cfg = tenantConfig(cfg, r)
+
+	// Initialize layers (Cache -> API -> Service -> Handler)
+	// Layer 0: Cache Repository
+	cacheRepo, err := newCacheRepository(cfg.CacheEnabled, cfg.CacheBackend, cfg.RedisAddress, cacheOptions(cfg))
+	if err != nil {
+		log.Printf("Invalid cache_backend: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Layer 1: API Client
+	apiClient := api.NewHTTPPocketSmithClient(cfg.PocketSmithAPIKey, cacheRepo, api.Options{
+		MaxEntities: cfg.MaxEntities,
+		AuthMode:    cfg.PocketSmithAuthMode,
+		APIVersion:  cfg.PocketSmithAPIVersion,
+	})
+
+	// Layer 2: Service
+	transactionService := service.NewTransactionService(apiClient, service.Options{
+		RollupToParent:      cfg.RollupToParent,
+		RoundAmount:         cfg.RoundAmount,
+		MerchantRules:       cfg.MerchantRules,
+		UpsertMatchFields:   cfg.UpsertMatchFields,
+		BatchConcurrency:    cfg.BatchConcurrency,
+		DefaultLabels:       cfg.DefaultLabels,
+		DefaultSource:       cfg.DefaultSource,
+		SourceTarget:        cfg.SourceTarget,
+		MerchantTarget:      cfg.MerchantTarget,
+		DedupWindow:         time.Duration(cfg.DedupWindowSeconds) * time.Second,
+		DedupMode:           cfg.DedupMode,
+		AdjustmentPayee:     cfg.AdjustmentPayee,
+		AdjustmentCategory:  cfg.AdjustmentCategory,
+		CategorySelfHeal:    cfg.CategorySelfHeal,
+		UnknownCategory:     cfg.UnknownCategory,
+		EnforceCategorySign: cfg.EnforceCategorySign,
+		TimeZone:            cfg.TimeZone,
+	})
+
+	// Layer 3: Handler (Facade)
+	httpHandler := handler.NewHTTPHandler(transactionService, cfg.ClientAuthKey, handler.Options{
+		TitleCaseMerchant:    cfg.TitleCaseMerchant,
+		RequestTimeout:       cfg.RequestTimeout,
+		AuthHeaderMode:       cfg.AuthHeaderMode,
+		ClientAuthKeySHA256:  cfg.ClientAuthKeySHA256,
+		CurrencySymbols:      cfg.CurrencySymbols,
+		OnSymbolMismatch:     cfg.OnSymbolMismatch,
+		StrictParams:         cfg.StrictParams,
+		AllowedCurrencies:    cfg.AllowedCurrencies,
+		CategoryOptional:     len(cfg.MerchantRules) > 0,
+		MaxDateAgeDays:       cfg.MaxDateAgeDays,
+		MaxDateAheadDays:     cfg.MaxDateAheadDays,
+		SlowRequestThreshold: cfg.SlowRequestThreshold,
+		MaxAmountDecimals:    cfg.MaxAmountDecimals,
+		RoundAmount:          cfg.RoundAmount,
+		ParamAliases:         cfg.ParamAliases,
+		DebugRoutes:          cfg.DebugRoutes,
+		StrictPaths:          cfg.StrictPaths,
+		ServiceName:          cfg.ServiceName,
+		EffectiveConfig:      cfg.Redacted(),
+	})
+
+	// Delegate to handler
+	httpHandler.Handle(w, r)
+}
+
+// tenantConfig returns the configuration of the tenant the client key belongs to, with its
+// own PocketSmith key and cache. Without a match the top-level keys apply, so unknown
+// clients fail auth as usual.
+func tenantConfig(cfg *Config, r *http.Request) *Config {
+	if tenantCfg, ok := cfg.ForTenant(handler.ClientToken(r, cfg.AuthHeaderMode)); ok {
+		return tenantCfg
+	}
+	return cfg
+}
+
+// cacheOptions returns the cache repository options of a configuration
+func cacheOptions(cfg *Config) repository.Options {
+	return repository.Options{
+		TTLJitter:          cfg.CacheTTLJitter,
+		Namespace:          cfg.CacheNamespace,
+		RedisRetryAttempts: cfg.RedisRetryAttempts,
+	}
+}
+
+// newCacheRepository creates the cache repository for the configured backend ("redis" or "kv"),
+// or one that never caches when the cache is disabled
+func newCacheRepository(enabled bool, backend, redisAddress string, options repository.Options) (repository.CacheRepository, error) {
+	if !enabled {
+		return repository.NewNoopCacheRepository(), nil
+	}
+	switch backend {
+	case "", "redis":
+		return repository.NewRedisCacheRepository(redisAddress, options), nil
+	case "kv":
+		return repository.NewKVCacheRepository("default", options), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", backend)
+	}
+}
@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketsmith-proxy/internal/config"
+	"github.com/pocketsmith-proxy/internal/handler"
+	"github.com/pocketsmith-proxy/internal/repository"
+)
+
+func TestTenantConfig(t *testing.T) {
+	tenants := []config.Tenant{
+		{Name: "personal", ClientAuthKey: "client-personal", PocketSmithAPIKey: "ps-personal"},
+		{Name: "business", ClientAuthKey: "client-business", PocketSmithAPIKey: "ps-business"},
+	}
+	tests := []struct {
+		name          string
+		namespace     string
+		mode          handler.AuthHeaderMode
+		header        http.Header
+		wantKey       string
+		wantNamespace string
+	}{
+		{name: "personal tenant", header: http.Header{"Authorization": {"Bearer client-personal"}}, wantKey: "ps-personal", wantNamespace: "personal"},
+		{name: "business tenant", header: http.Header{"Authorization": {"Bearer client-business"}}, wantKey: "ps-business", wantNamespace: "business"},
+		{name: "tenant under the namespace", namespace: "prod", header: http.Header{"Authorization": {"Bearer client-business"}}, wantKey: "ps-business", wantNamespace: "prod:business"},
+		{name: "tenant by API key header", mode: handler.AuthHeaderAPIKey, header: http.Header{"X-Api-Key": {"client-personal"}}, wantKey: "ps-personal", wantNamespace: "personal"},
+		{name: "tenant key in the wrong header", namespace: "prod", mode: handler.AuthHeaderAPIKey, header: http.Header{"Authorization": {"Bearer client-personal"}}, wantKey: "ps-top", wantNamespace: "prod"},
+		{name: "top-level client", namespace: "prod", header: http.Header{"Authorization": {"Bearer client-top"}}, wantKey: "ps-top", wantNamespace: "prod"},
+		{name: "unknown client", header: http.Header{"Authorization": {"Bearer nobody"}}, wantKey: "ps-top"},
+		{name: "no client key", wantKey: "ps-top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ClientAuthKey:     "client-top",
+				PocketSmithAPIKey: "ps-top",
+				CacheNamespace:    tt.namespace,
+				AuthHeaderMode:    tt.mode,
+				Tenants:           tenants,
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			got := tenantConfig(cfg, req)
+
+			if got.PocketSmithAPIKey != tt.wantKey {
+				t.Errorf("PocketSmith key = %q, want %q", got.PocketSmithAPIKey, tt.wantKey)
+			}
+			if options := cacheOptions(got); options.Namespace != tt.wantNamespace {
+				t.Errorf("cache namespace = %q, want %q", options.Namespace, tt.wantNamespace)
+			}
+			if cfg.PocketSmithAPIKey != "ps-top" || cfg.CacheNamespace != tt.namespace {
+				t.Errorf("top-level config changed to key %q, namespace %q", cfg.PocketSmithAPIKey, cfg.CacheNamespace)
+			}
+		})
+	}
+}
+
+func TestTenantCachesIsolated(t *testing.T) {
+	cfg := &Config{
+		ClientAuthKey:     "client-top",
+		PocketSmithAPIKey: "ps-top",
+		CacheNamespace:    "prod",
+		Tenants: []config.Tenant{
+			{Name: "personal", ClientAuthKey: "client-personal", PocketSmithAPIKey: "ps-personal"},
+			{Name: "business", ClientAuthKey: "client-business", PocketSmithAPIKey: "ps-business"},
+		},
+	}
+
+	// Every client, and the top-level one, keeps its entries in a namespace of its own
+	namespaces := make(map[string]string)
+	for _, token := range []string{"client-top", "client-personal", "client-business"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		namespace := cacheOptions(tenantConfig(cfg, req)).Namespace
+		if other, ok := namespaces[namespace]; ok {
+			t.Errorf("%s and %s share the cache namespace %q", other, token, namespace)
+		}
+		namespaces[namespace] = token
+	}
+}
+
+func TestNewCacheRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		backend  string
+		wantNoop bool
+		wantErr  bool
+	}{
+		{name: "redis", enabled: true, backend: "redis"},
+		{name: "kv", enabled: true, backend: "kv"},
+		{name: "unknown backend", enabled: true, backend: "memcached", wantErr: true},
+		{name: "disabled", backend: "redis", wantNoop: true},
+		{name: "disabled with kv", backend: "kv", wantNoop: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := newCacheRepository(tt.enabled, tt.backend, "redis://localhost:6379", repository.Options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCacheRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, noop := repo.(repository.NoopCacheRepository); noop != tt.wantNoop {
+				t.Errorf("newCacheRepository() = %T, want pass-through %v", repo, tt.wantNoop)
+			}
+		})
+	}
+}